@@ -1,9 +1,14 @@
 package zdb
 
 import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
 )
 
 func BenchmarkTreeSelect(b *testing.B) {
@@ -113,3 +118,166 @@ func BenchmarkTreeRangeByIndexReverse(b *testing.B) {
 		tree.RangeByIndexReverse(0, 9)
 	}
 }
+
+func BenchmarkUpsertDBExistingKey(b *testing.B) {
+	// Replacing keys that already exist among 1 million keys, like a *STORE destination
+
+	// setup
+	const N = 1e6
+	shard := NewShards(16)
+	for i := 0; i < N; i++ {
+		shard.UpsertDB("Key-"+strconv.Itoa(i), nil)
+	}
+
+	tree := NewTree()
+	tree.Add("A", 1)
+	i := 0
+	for b.Loop() {
+		shard.UpsertDB("Key-"+strconv.Itoa(i%N), tree)
+		i++
+	}
+}
+
+func BenchmarkUpsertDBNewKey(b *testing.B) {
+	// Adding keys on top of 1 million keys
+
+	// setup
+	const N = 1e6
+	shard := NewShards(16)
+	for i := 0; i < N; i++ {
+		shard.UpsertDB("Key-"+strconv.Itoa(i), nil)
+	}
+
+	i := int(N)
+	for b.Loop() {
+		shard.UpsertDB("Key-"+strconv.Itoa(i), nil)
+		i++
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	// Walking 1 million keys 10 at a time
+
+	// setup
+	const N = 1e6
+	db := NewZDB(16)
+	for i := 0; i < N; i++ {
+		db.ZAdd(&commands.ZADDCmd{Key: "Key-" + strconv.Itoa(i), Members: []commands.ZMember{{Key: "A", Score: 1}}})
+	}
+
+	cmd := &commands.ScanCmd{Cursor: "0", Count: 10}
+	for b.Loop() {
+		_, cmd.Cursor = db.Scan(cmd)
+	}
+}
+
+func BenchmarkZAddSmallSets(b *testing.B) {
+	// 16 members per key, listpacks against sets created as avl trees
+	tests := []struct {
+		Name       string
+		MaxEntries int
+	}{
+		{Name: "listpack", MaxEntries: DefaultListpackMaxEntries},
+		{Name: "avltree", MaxEntries: 0},
+	}
+
+	for _, test := range tests {
+		b.Run(test.Name, func(b *testing.B) {
+			db := NewZDB(16)
+			db.SetListpackLimits(test.MaxEntries, DefaultListpackMaxValue)
+			members := make([]commands.ZMember, 16)
+			for i := range members {
+				members[i] = commands.ZMember{Key: "member-" + strconv.Itoa(i), Score: float64(i)}
+			}
+
+			i := 0
+			for b.Loop() {
+				db.ZAdd(&commands.ZADDCmd{Key: "zset-" + strconv.Itoa(i), Members: members})
+				i++
+			}
+			b.ReportMetric(float64(db.UsedMemory())/float64(i), "bytes/key")
+		})
+	}
+}
+
+// the avl tree keeps its nodes in slabs while the skip list allocates every node, the
+// difference shows in the allocations of Add and in the time to mark the live nodes
+func BenchmarkTreeAdd(b *testing.B) {
+	for _, backend := range []Backend{AVLTreeBackend, SkipListBackend} {
+		b.Run(string(backend), func(b *testing.B) {
+			b.ReportAllocs()
+			tree := backend.new()
+			i := 0
+			for b.Loop() {
+				tree.Add("member-"+strconv.Itoa(i), float64(i))
+				i++
+			}
+		})
+	}
+}
+
+func BenchmarkTreeGC(b *testing.B) {
+	const N = 1e6
+	for _, backend := range []Backend{AVLTreeBackend, SkipListBackend} {
+		b.Run(string(backend), func(b *testing.B) {
+			tree := backend.new()
+			for i := range int(N) {
+				tree.Add("member-"+strconv.Itoa(i), float64(i))
+			}
+
+			for b.Loop() {
+				runtime.GC()
+			}
+			runtime.KeepAlive(tree)
+		})
+	}
+}
+
+func BenchmarkTreeBuild(b *testing.B) {
+	// 1e5 sorted members added one at a time, as a batch and built from sorted
+	const N = 1e5
+	sorted := make([]Node, 0, int(N))
+	for i := range int(N) {
+		sorted = append(sorted, Node{key: "member-" + strconv.Itoa(i), score: float64(i)})
+	}
+	shuffled := slices.Clone(sorted)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	b.Run("Add", func(b *testing.B) {
+		for b.Loop() {
+			tree := NewTree()
+			for _, node := range sorted {
+				tree.Add(node.key, node.score)
+			}
+		}
+	})
+	b.Run("NewTreeFromSorted", func(b *testing.B) {
+		for b.Loop() {
+			NewTreeFromSorted(sorted)
+		}
+	})
+	b.Run("AddBatchShuffled", func(b *testing.B) {
+		for b.Loop() {
+			NewTree().AddBatch(shuffled)
+		}
+	})
+}
+
+func BenchmarkTreeSplitJoin(b *testing.B) {
+	// cutting the last 100 of 1e6 members and joining them back only moves those 100
+	const N = 1e6
+	nodes := make([]Node, 0, int(N))
+	for i := range int(N) {
+		nodes = append(nodes, Node{key: "member-" + strconv.Itoa(i), score: float64(i)})
+	}
+	tree := NewTreeFromSorted(nodes)
+
+	for b.Loop() {
+		split := tree.SplitByRank(int(N) - 99)
+		if err := tree.Join(split); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+// the args functions are the inverse of the Build methods in package commands
+
+func formatScore(score float64) string {
+	switch {
+	case score <= -math.MaxFloat64:
+		return "-inf"
+	case score >= math.MaxFloat64:
+		return "+inf"
+	}
+
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+func zaddArgs(cmd *commands.ZADDCmd) []string {
+	args := []string{"ZADD", cmd.Key}
+	flags := []struct {
+		set  bool
+		name string
+	}{{cmd.XX, "XX"}, {cmd.NX, "NX"}, {cmd.LT, "LT"}, {cmd.GT, "GT"}, {cmd.CH, "CH"}, {cmd.INCR, "INCR"}}
+	for _, flag := range flags {
+		if flag.set {
+			args = append(args, flag.name)
+		}
+	}
+
+	for _, member := range cmd.Members {
+		args = append(args, formatScore(member.Score), member.Key)
+	}
+
+	return args
+}
+
+// formatLexBound is the inverse of the BYLEX bound parsing of ZRANGE
+func formatLexBound(key string, exclusive bool, inf int) string {
+	switch {
+	case inf < 0:
+		return "-"
+	case inf > 0:
+		return "+"
+	case exclusive:
+		return "(" + key
+	}
+
+	return "[" + key
+}
+
+func zrangeArgs(cmd *commands.ZRangeCmd) []string {
+	args := []string{"ZRANGE", cmd.Key}
+	var start, stop, by string
+	switch {
+	case cmd.ByScore:
+		start, stop, by = formatScore(cmd.MinScore), formatScore(cmd.MaxScore), "BYSCORE"
+	case cmd.ByLex:
+		start = formatLexBound(cmd.MinKey, cmd.MinKeyExclusive, cmd.MinKeyInf)
+		stop, by = formatLexBound(cmd.MaxKey, cmd.MaxKeyExclusive, cmd.MaxKeyInf), "BYLEX"
+	default:
+		start, stop = strconv.Itoa(cmd.StartIndex), strconv.Itoa(cmd.StopIndex)
+	}
+	// REV takes the max of a score or lex range first
+	if cmd.Reverse && by != "" {
+		start, stop = stop, start
+	}
+	args = append(args, start, stop)
+	if by != "" {
+		args = append(args, by)
+	}
+
+	if cmd.Reverse {
+		args = append(args, "REV")
+	}
+	if cmd.Limit {
+		args = append(args, "LIMIT", strconv.Itoa(cmd.Offset), strconv.Itoa(cmd.Count))
+	}
+	if cmd.WithScores {
+		args = append(args, "WITHSCORES")
+	}
+
+	return args
+}
+
+// setOpArgs serializes ZDIFF, ZINTER, ZUNION and their STORE variants
+func setOpArgs(name, dstKey string, keys []string, weights []float64, aggregate string, withScores bool) []string {
+	args := []string{name}
+	if dstKey != "" {
+		args = append(args, dstKey)
+	}
+	args = append(args, strconv.Itoa(len(keys)))
+	args = append(args, keys...)
+
+	if len(weights) > 0 {
+		args = append(args, "WEIGHTS")
+		for _, weight := range weights {
+			args = append(args, formatScore(weight))
+		}
+	}
+	if aggregate != "" {
+		args = append(args, "AGGREGATE", aggregate)
+	}
+	if withScores {
+		args = append(args, "WITHSCORES")
+	}
+
+	return args
+}
+
+func scanArgs(name string, key string, cmd *commands.ScanCmd, cursor string) []string {
+	args := []string{name}
+	if key != "" {
+		args = append(args, key)
+	}
+	args = append(args, cursor)
+
+	if cmd.MatchPattern != "" {
+		args = append(args, "MATCH", cmd.MatchPattern)
+	}
+	if cmd.Count > 0 {
+		args = append(args, "COUNT", strconv.Itoa(cmd.Count))
+	}
+	if cmd.Type != "" {
+		args = append(args, "TYPE", cmd.Type)
+	}
+
+	return args
+}
+
+func zsetcapArgs(cmd *commands.ZSetCapCmd) []string {
+	if cmd.Query {
+		return []string{"ZSETCAP", cmd.Key}
+	}
+
+	evict := "MIN"
+	if cmd.EvictMax {
+		evict = "MAX"
+	}
+
+	return []string{"ZSETCAP", cmd.Key, strconv.Itoa(cmd.MaxLen), "EVICT", evict}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+var (
+	ErrNil    = errors.New("zdb: nil reply")
+	ErrClosed = errors.New("zdb: client is closed")
+)
+
+const maxRetryBackoff = 512 * time.Millisecond
+
+// Error is an error reply sent by the server
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Client is a pool of connections to a zdb server, safe for concurrent use
+type Client struct {
+	addr   string
+	config Config
+	pool   *pool
+}
+
+func New(addr string, opts ...Option) *Client {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	c := &Client{addr: addr, config: config}
+	c.pool = newPool(config.PoolSize, config.MaxIdleConns, func(ctx context.Context) (*conn, error) {
+		return dial(ctx, addr, config)
+	})
+
+	return c
+}
+
+// Close closes the idle connections, connections in use are closed when released
+func (c *Client) Close() error {
+	c.pool.close()
+	return nil
+}
+
+// Do sends a raw command and decodes its reply into Go types as described in
+// miniresp3.Value.Interface. Error replies are returned as Error
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	reply, err := c.do(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Interface(), nil
+}
+
+// DoValue is Do without the decoding, the reply keeps its RESP type
+func (c *Client) DoValue(ctx context.Context, args ...string) (miniresp3.Value, error) {
+	return c.do(ctx, args)
+}
+
+func (c *Client) do(ctx context.Context, args []string) (miniresp3.Value, error) {
+	replies, err := c.process(ctx, [][]string{args})
+	if err != nil {
+		return miniresp3.Value{}, err
+	}
+
+	if replies[0].IsError() {
+		return replies[0], Error(replies[0].Str)
+	}
+
+	return replies[0], nil
+}
+
+// process runs cmds in a single round trip. Like most redis clients it retries
+// on a fresh connection after a network error, so a write may be applied twice
+// when the connection drops before its reply is read
+func (c *Client) process(ctx context.Context, cmds [][]string) (replies []miniresp3.Value, err error) {
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(min(time.Duration(8<<attempt)*time.Millisecond, maxRetryBackoff)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		var cn *conn
+		cn, err = c.pool.get(ctx)
+		if err == nil {
+			replies, err = cn.roundTrip(ctx, c.config.ReadWriteTimeout, cmds)
+			c.pool.put(cn)
+		}
+
+		if err == nil || !shouldRetry(ctx, err) {
+			return replies, err
+		}
+	}
+
+	return nil, err
+}
+
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+		return false
+	}
+
+	// a slow server would only get slower if we retried
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+
+	var protoErr *miniresp3.ProtocolError
+	return !errors.As(err, &protoErr)
+}
@@ -0,0 +1,310 @@
+//go:build unit
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+	"github.com/AdhityaRamadhanus/zdb/tcp"
+)
+
+// fakeServer replies to every command with handle, HELLO included
+type fakeServer struct {
+	l       net.Listener
+	handle  func(args []string, w *miniresp3.Writer)
+	accepts atomic.Int32
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeServer(t *testing.T, handle func(args []string, w *miniresp3.Writer)) *fakeServer {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	srv := &fakeServer{l: l, handle: handle}
+	t.Cleanup(func() {
+		l.Close()
+		srv.closeConns()
+	})
+	go srv.serve()
+
+	return srv
+}
+
+func (srv *fakeServer) serve() {
+	for {
+		conn, err := srv.l.Accept()
+		if err != nil {
+			return
+		}
+		srv.accepts.Add(1)
+		srv.mu.Lock()
+		srv.conns = append(srv.conns, conn)
+		srv.mu.Unlock()
+
+		go func() {
+			r, w := miniresp3.NewReader(conn), miniresp3.NewWriter(conn)
+			for {
+				args, err := r.ReadCommand()
+				if err != nil {
+					return
+				}
+				srv.handle(args, w)
+				w.Write()
+			}
+		}()
+	}
+}
+
+func (srv *fakeServer) closeConns() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, conn := range srv.conns {
+		conn.Close()
+	}
+	srv.conns = nil
+}
+
+func echoHandler(args []string, w *miniresp3.Writer) {
+	switch strings.ToUpper(args[0]) {
+	case "HELLO":
+		w.AppendMap(map[string]interface{}{"proto": 3})
+	case "ERR":
+		w.AppendSimpleError("ERR failed")
+	case "SLEEP":
+		time.Sleep(200 * time.Millisecond)
+		w.AppendSimpleStr("OK")
+	default:
+		w.AppendArrStr(args)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	srv := newFakeServer(t, echoHandler)
+	c := New(srv.l.Addr().String())
+	defer c.Close()
+
+	p := c.Pipeline()
+	p.Do("ECHO", "a")
+	p.Do("ERR")
+	p.Do("ECHO", "b")
+	got, err := p.Exec(context.Background())
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	want := []interface{}{[]interface{}{"ECHO", "a"}, Error("ERR failed"), []interface{}{"ECHO", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if p.Len() != 0 {
+		t.Errorf("got %d queued commands, want 0", p.Len())
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	srv := newFakeServer(t, echoHandler)
+	c := New(srv.l.Addr().String())
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.Do(ctx, "PING"); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	srv.closeConns()
+	if _, err := c.Do(ctx, "PING"); err != nil {
+		t.Fatalf("got err %v after the connection dropped, want nil", err)
+	}
+
+	if got := srv.accepts.Load(); got != 2 {
+		t.Errorf("got %d connections, want 2", got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Timeout time.Duration
+		Ctx     func() (context.Context, context.CancelFunc)
+		WantErr error
+	}{
+		{
+			Name:    "Context deadline",
+			Timeout: time.Second,
+			Ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			WantErr: context.DeadlineExceeded,
+		},
+		{
+			Name:    "Context canceled",
+			Timeout: time.Second,
+			Ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			WantErr: context.Canceled,
+		},
+		{
+			Name:    "Read write timeout",
+			Timeout: 50 * time.Millisecond,
+			Ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			WantErr: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			srv := newFakeServer(t, echoHandler)
+			c := New(srv.l.Addr().String(), WithTimeouts(time.Second, test.Timeout))
+			defer c.Close()
+
+			ctx, cancel := test.Ctx()
+			defer cancel()
+			_, err := c.Do(ctx, "SLEEP")
+
+			var netErr net.Error
+			if test.WantErr == nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+				t.Errorf("got err %v, want a timeout", err)
+			}
+			if test.WantErr != nil && !errors.Is(err, test.WantErr) {
+				t.Errorf("got err %v, want %v", err, test.WantErr)
+			}
+		})
+	}
+}
+
+func TestHelloFallback(t *testing.T) {
+	names := make(chan string, 1)
+	srv := newFakeServer(t, func(args []string, w *miniresp3.Writer) {
+		switch strings.ToUpper(args[0]) {
+		case "HELLO":
+			w.AppendSimpleError(commands.ErrNoProto.Error())
+		case "CLIENT":
+			names <- args[2]
+			w.AppendSimpleStr("OK")
+		default:
+			w.AppendSimpleStr("PONG")
+		}
+	})
+
+	c := New(srv.l.Addr().String(), WithClientName("worker"))
+	defer c.Close()
+
+	cn, err := c.pool.get(context.Background())
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	defer c.pool.put(cn)
+
+	if cn.proto != miniresp3.RESP2 {
+		t.Errorf("got RESP%d, want RESP2", cn.proto)
+	}
+	if got := <-names; got != "worker" {
+		t.Errorf("got client name %q, want worker", got)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tcp.NewServer("tcp", addr).Run(ctx)
+
+	for _, proto := range []int{miniresp3.RESP2, miniresp3.RESP3} {
+		c := New(addr, WithProtocol(proto))
+		defer c.Close()
+
+		// wait for the server to listen
+		for c.Ping(ctx) != nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		key := "board" + string(rune('0'+proto))
+		members := []commands.ZMember{{Key: "a", Score: 1}, {Key: "b", Score: 2.5}, {Key: "c", Score: 3}}
+		if added, err := c.ZAdd(ctx, &commands.ZADDCmd{Key: key, Members: members}); err != nil || added != 3 {
+			t.Fatalf("RESP%d ZAdd got %d, %v, want 3, nil", proto, added, err)
+		}
+
+		got, err := c.ZRange(ctx, &commands.ZRangeCmd{Key: key, StartIndex: 0, StopIndex: -1, WithScores: true})
+		if err != nil || !reflect.DeepEqual(got, members) {
+			t.Errorf("RESP%d ZRange got %v, %v, want %v", proto, got, err, members)
+		}
+
+		if score, err := c.ZScore(ctx, &commands.ZScoreCmd{Key: key, Member: "b"}); err != nil || score != 2.5 {
+			t.Errorf("RESP%d ZScore got %v, %v, want 2.5", proto, score, err)
+		}
+		if _, err := c.ZScore(ctx, &commands.ZScoreCmd{Key: key, Member: "z"}); err != ErrNil {
+			t.Errorf("RESP%d ZScore got err %v, want ErrNil", proto, err)
+		}
+
+		if _, err := c.ZSetCap(ctx, &commands.ZSetCapCmd{Key: key, MaxLen: 3}); err != nil {
+			t.Fatalf("RESP%d ZSetCap got err %v", proto, err)
+		}
+		more := []commands.ZMember{{Key: "d", Score: 4}, {Key: "e", Score: 5}}
+		if added, err := c.ZAdd(ctx, &commands.ZADDCmd{Key: key, Members: more}); err != nil || added != 2 {
+			t.Errorf("RESP%d ZAdd on a capped key got %d, %v, want 2", proto, added, err)
+		}
+		if info, err := c.ZSetCapInfo(ctx, &commands.ZSetCapCmd{Key: key, Query: true}); err != nil || info.Evicted != 2 {
+			t.Errorf("RESP%d ZSetCapInfo got %+v, %v, want 2 evicted", proto, info, err)
+		}
+
+		if _, err := c.Do(ctx, "ZADD", key); !errors.As(err, new(Error)) {
+			t.Errorf("RESP%d got err %v, want an error reply", proto, err)
+		}
+	}
+}
+
+func TestScanIterator(t *testing.T) {
+	pages := map[string][]interface{}{
+		"0": {"7", []string{"a", "b"}},
+		"7": {"9", []string{}},
+		"9": {"0", []string{"c"}},
+	}
+	srv := newFakeServer(t, func(args []string, w *miniresp3.Writer) {
+		switch {
+		case strings.ToUpper(args[0]) == "HELLO":
+			w.AppendMap(map[string]interface{}{"proto": 3})
+		case slices.Equal(args[3:], []string{"MATCH", "*", "COUNT", "2"}):
+			w.AppendArrAny(pages[args[2]])
+		default:
+			w.AppendSimpleError("ERR syntax error")
+		}
+	})
+	c := New(srv.l.Addr().String())
+	defer c.Close()
+
+	got := []string{}
+	it := c.ZScan(context.Background(), &commands.ZScanCmd{Key: "board", ScanCmd: commands.ScanCmd{MatchPattern: "*", Count: 2}})
+	for it.Next() {
+		got = append(got, it.Val())
+	}
+
+	if it.Err() != nil || !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("got %v, %v, want [a b c], nil", got, it.Err())
+	}
+}
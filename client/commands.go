@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+// ZSetCapInfo is the reply of ZSETCAP key
+type ZSetCapInfo struct {
+	MaxLen   int
+	EvictMax bool
+	Evicted  int
+}
+
+func unexpectedReply(reply miniresp3.Value) error {
+	return fmt.Errorf("zdb: unexpected reply type %q", string(reply.Type))
+}
+
+func (c *Client) intCmd(ctx context.Context, args []string) (int, error) {
+	reply, err := c.do(ctx, args)
+	if err != nil {
+		return 0, err
+	}
+
+	return toInt(reply)
+}
+
+func toInt(reply miniresp3.Value) (int, error) {
+	if reply.Type != miniresp3.RESPNumber {
+		return 0, unexpectedReply(reply)
+	}
+
+	return int(reply.Int), nil
+}
+
+func toFloat(reply miniresp3.Value) (float64, error) {
+	switch {
+	case reply.IsNull():
+		return 0, ErrNil
+	case reply.Type == miniresp3.RESPDoubles:
+		return reply.Float, nil
+	case reply.Type == miniresp3.RESPBulkString:
+		return strconv.ParseFloat(reply.Str, 64)
+	}
+
+	return 0, unexpectedReply(reply)
+}
+
+// toMembers decodes a list of members, WITHSCORES replies may either be flat
+// or made of [member, score] pairs
+func toMembers(reply miniresp3.Value, withScores bool) ([]commands.ZMember, error) {
+	if reply.IsNull() {
+		return nil, nil
+	}
+
+	elems := reply.Elems
+	if withScores && len(elems) > 0 && elems[0].Type == miniresp3.RESPArray {
+		flat := make([]miniresp3.Value, 0, len(elems)*2)
+		for _, pair := range elems {
+			flat = append(flat, pair.Elems...)
+		}
+		elems = flat
+	}
+
+	step := 1
+	if withScores {
+		step = 2
+	}
+	if len(elems)%step != 0 {
+		return nil, fmt.Errorf("zdb: got %d elements, want member score pairs", len(elems))
+	}
+
+	members := make([]commands.ZMember, 0, len(elems)/step)
+	for i := 0; i < len(elems); i += step {
+		member := commands.ZMember{Key: elems[i].Str}
+		if withScores {
+			score, err := toFloat(elems[i+1])
+			if err != nil {
+				return nil, err
+			}
+			member.Score = score
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (c *Client) membersCmd(ctx context.Context, args []string, withScores bool) ([]commands.ZMember, error) {
+	reply, err := c.do(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return toMembers(reply, withScores)
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, []string{"PING"})
+	return err
+}
+
+func (c *Client) Expire(ctx context.Context, cmd *commands.ExpireCmd) (int, error) {
+	return c.intCmd(ctx, []string{"EXPIRE", cmd.Key, strconv.Itoa(cmd.Seconds)})
+}
+
+func (c *Client) TTL(ctx context.Context, cmd *commands.TTLCmd) (int, error) {
+	return c.intCmd(ctx, []string{"TTL", cmd.Key})
+}
+
+func (c *Client) Persist(ctx context.Context, cmd *commands.PersistCmd) (int, error) {
+	return c.intCmd(ctx, []string{"PERSIST", cmd.Key})
+}
+
+func (c *Client) ZAdd(ctx context.Context, cmd *commands.ZADDCmd) (int, error) {
+	return c.intCmd(ctx, zaddArgs(cmd))
+}
+
+func (c *Client) ZCard(ctx context.Context, cmd *commands.ZCardCmd) (int, error) {
+	return c.intCmd(ctx, []string{"ZCARD", cmd.Key})
+}
+
+func (c *Client) ZCount(ctx context.Context, cmd *commands.ZCountCmd) (int, error) {
+	return c.intCmd(ctx, []string{"ZCOUNT", cmd.Key, formatScore(cmd.Min), formatScore(cmd.Max)})
+}
+
+func (c *Client) ZDiff(ctx context.Context, cmd *commands.ZDiffCmd) ([]commands.ZMember, error) {
+	return c.membersCmd(ctx, setOpArgs("ZDIFF", "", cmd.Keys, nil, "", cmd.WithScores), cmd.WithScores)
+}
+
+func (c *Client) ZDiffStore(ctx context.Context, cmd *commands.ZDiffStoreCmd) (int, error) {
+	return c.intCmd(ctx, setOpArgs("ZDIFFSTORE", cmd.DstKey, cmd.ZDiffCmd.Keys, nil, "", false))
+}
+
+func (c *Client) ZInter(ctx context.Context, cmd *commands.ZInterCmd) ([]commands.ZMember, error) {
+	args := setOpArgs("ZINTER", "", cmd.Keys, cmd.Weights, cmd.Aggregate, cmd.WithScores)
+	return c.membersCmd(ctx, args, cmd.WithScores)
+}
+
+func (c *Client) ZInterStore(ctx context.Context, cmd *commands.ZInterStoreCmd) (int, error) {
+	inter := cmd.ZInterCmd
+	return c.intCmd(ctx, setOpArgs("ZINTERSTORE", cmd.DstKey, inter.Keys, inter.Weights, inter.Aggregate, false))
+}
+
+func (c *Client) ZUnion(ctx context.Context, cmd *commands.ZUnionCmd) ([]commands.ZMember, error) {
+	args := setOpArgs("ZUNION", "", cmd.Keys, cmd.Weights, cmd.Aggregate, cmd.WithScores)
+	return c.membersCmd(ctx, args, cmd.WithScores)
+}
+
+func (c *Client) ZUnionStore(ctx context.Context, cmd *commands.ZUnionStoreCmd) (int, error) {
+	union := cmd.ZUnionCmd
+	return c.intCmd(ctx, setOpArgs("ZUNIONSTORE", cmd.DstKey, union.Keys, union.Weights, union.Aggregate, false))
+}
+
+// ZRange returns the members in range, scores are only set WithScores
+func (c *Client) ZRange(ctx context.Context, cmd *commands.ZRangeCmd) ([]commands.ZMember, error) {
+	return c.membersCmd(ctx, zrangeArgs(cmd), cmd.WithScores)
+}
+
+func (c *Client) ZRank(ctx context.Context, cmd *commands.ZRankCmd) (int, error) {
+	return c.intCmd(ctx, []string{"ZRANK", cmd.Key, cmd.Member})
+}
+
+func (c *Client) ZRem(ctx context.Context, cmd *commands.ZRemCmd) (int, error) {
+	return c.intCmd(ctx, append([]string{"ZREM", cmd.Key}, cmd.Members...))
+}
+
+// ZScore returns ErrNil when the key or the member doesn't exist
+func (c *Client) ZScore(ctx context.Context, cmd *commands.ZScoreCmd) (float64, error) {
+	reply, err := c.do(ctx, []string{"ZSCORE", cmd.Key, cmd.Member})
+	if err != nil {
+		return 0, err
+	}
+
+	return toFloat(reply)
+}
+
+// ZSetCap returns the number of members evicted to fit the new cap
+func (c *Client) ZSetCap(ctx context.Context, cmd *commands.ZSetCapCmd) (int, error) {
+	query := *cmd
+	query.Query = false
+	return c.intCmd(ctx, zsetcapArgs(&query))
+}
+
+// ZSetCapInfo returns ErrNil when the key doesn't exist
+func (c *Client) ZSetCapInfo(ctx context.Context, cmd *commands.ZSetCapCmd) (ZSetCapInfo, error) {
+	reply, err := c.do(ctx, []string{"ZSETCAP", cmd.Key})
+	if err != nil {
+		return ZSetCapInfo{}, err
+	}
+	if reply.IsNull() {
+		return ZSetCapInfo{}, ErrNil
+	}
+
+	info := ZSetCapInfo{}
+	fields, ok := reply.Interface().(map[string]interface{})
+	if !ok {
+		// RESP2 maps are flat arrays
+		fields = map[string]interface{}{}
+		for i := 0; i+1 < len(reply.Elems); i += 2 {
+			fields[reply.Elems[i].Str] = reply.Elems[i+1].Interface()
+		}
+	}
+
+	maxLen, _ := fields["maxlen"].(int64)
+	evicted, _ := fields["evicted"].(int64)
+	info.MaxLen, info.Evicted = int(maxLen), int(evicted)
+	info.EvictMax = fields["evict"] == "max"
+
+	return info, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+type Config struct {
+	// connections kept open to the server, commands wait for a free one past this
+	PoolSize int
+	// idle connections kept in the pool, the rest are closed when released
+	MaxIdleConns int
+
+	DialTimeout time.Duration
+	// applied to every round trip, a context deadline takes precedence when it's
+	// earlier. Zero disables it
+	ReadWriteTimeout time.Duration
+
+	// times a command is retried on a fresh connection after a network error
+	MaxRetries int
+
+	// protocol requested with HELLO, the client falls back to RESP2 when the
+	// server refuses it
+	Protocol   int
+	ClientName string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PoolSize:         10,
+		MaxIdleConns:     10,
+		DialTimeout:      5 * time.Second,
+		ReadWriteTimeout: 3 * time.Second,
+		MaxRetries:       3,
+		Protocol:         miniresp3.RESP3,
+	}
+}
+
+type Option func(*Config)
+
+func WithPoolSize(poolSize, maxIdleConns int) Option {
+	return func(cfg *Config) {
+		cfg.PoolSize = poolSize
+		cfg.MaxIdleConns = maxIdleConns
+	}
+}
+
+func WithTimeouts(dial, readWrite time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.DialTimeout = dial
+		cfg.ReadWriteTimeout = readWrite
+	}
+}
+
+func WithMaxRetries(maxRetries int) Option {
+	return func(cfg *Config) {
+		cfg.MaxRetries = maxRetries
+	}
+}
+
+func WithProtocol(proto int) Option {
+	return func(cfg *Config) {
+		cfg.Protocol = proto
+	}
+}
+
+func WithClientName(name string) Option {
+	return func(cfg *Config) {
+		cfg.ClientName = name
+	}
+}
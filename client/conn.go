@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+type conn struct {
+	netConn net.Conn
+	reader  *miniresp3.Reader
+	writer  *miniresp3.Writer
+	proto   int
+	// set after a network error, broken connections are never reused
+	broken bool
+}
+
+func dial(ctx context.Context, addr string, cfg Config) (*conn, error) {
+	dialer := net.Dialer{Timeout: cfg.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{
+		netConn: netConn,
+		reader:  miniresp3.NewReader(netConn),
+		writer:  miniresp3.NewWriter(netConn),
+		proto:   miniresp3.RESP2,
+	}
+	if err := cn.hello(ctx, cfg); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return cn, nil
+}
+
+// hello negotiates the protocol and sets the client name
+func (cn *conn) hello(ctx context.Context, cfg Config) error {
+	args := []string{"HELLO", strconv.Itoa(cfg.Protocol)}
+	if cfg.ClientName != "" {
+		args = append(args, "SETNAME", cfg.ClientName)
+	}
+
+	replies, err := cn.roundTrip(ctx, cfg.ReadWriteTimeout, [][]string{args})
+	if err != nil {
+		return err
+	}
+
+	if !replies[0].IsError() {
+		cn.proto = cfg.Protocol
+		return nil
+	}
+
+	// the server refused the protocol, stay on RESP2
+	if cfg.ClientName == "" {
+		return nil
+	}
+	replies, err = cn.roundTrip(ctx, cfg.ReadWriteTimeout, [][]string{{"CLIENT", "SETNAME", cfg.ClientName}})
+	if err != nil {
+		return err
+	}
+
+	return replies[0].Err()
+}
+
+// roundTrip sends cmds at once and reads one reply per command. Error replies are
+// returned as values, the returned error is always a network or protocol error
+func (cn *conn) roundTrip(ctx context.Context, timeout time.Duration, cmds [][]string) ([]miniresp3.Value, error) {
+	deadline, ctxDeadline := ctx.Deadline()
+	if timeout > 0 && (!ctxDeadline || time.Now().Add(timeout).Before(deadline)) {
+		deadline, ctxDeadline = time.Now().Add(timeout), false
+	}
+	cn.netConn.SetDeadline(deadline)
+
+	// unblock reads and writes as soon as ctx is canceled
+	stop := context.AfterFunc(ctx, func() {
+		cn.netConn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	replies, err := cn.exchange(cmds)
+	if err != nil {
+		cn.broken = true
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// the connection can time out right before ctx does
+		var netErr net.Error
+		if ctxDeadline && errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, context.DeadlineExceeded
+		}
+		return nil, err
+	}
+
+	return replies, nil
+}
+
+func (cn *conn) exchange(cmds [][]string) ([]miniresp3.Value, error) {
+	for _, args := range cmds {
+		cn.writer.AppendArrStr(args)
+	}
+	if err := cn.writer.Write(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]miniresp3.Value, 0, len(cmds))
+	for len(replies) < len(cmds) {
+		reply, err := cn.reader.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+
+		// out of band messages aren't replies to our commands
+		if reply.IsPush() {
+			continue
+		}
+		replies = append(replies, reply)
+	}
+
+	return replies, nil
+}
+
+func (cn *conn) close() error {
+	return cn.netConn.Close()
+}
@@ -0,0 +1,48 @@
+package client
+
+import "context"
+
+// Pipeline queues commands and sends them in a single round trip
+type Pipeline struct {
+	c    *Client
+	cmds [][]string
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+func (p *Pipeline) Do(args ...string) {
+	p.cmds = append(p.cmds, args)
+}
+
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Exec sends the queued commands and returns their decoded replies in order.
+// Error replies are returned in place as Error, the pipeline is empty afterwards
+func (p *Pipeline) Exec(ctx context.Context) ([]interface{}, error) {
+	if len(p.cmds) == 0 {
+		return nil, nil
+	}
+
+	cmds := p.cmds
+	p.cmds = nil
+
+	replies, err := p.c.process(ctx, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0, len(replies))
+	for _, reply := range replies {
+		if reply.IsError() {
+			results = append(results, Error(reply.Str))
+			continue
+		}
+		results = append(results, reply.Interface())
+	}
+
+	return results, nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// pool caps the number of open connections and keeps the idle ones around
+type pool struct {
+	dial    func(ctx context.Context) (*conn, error)
+	slots   chan struct{}
+	maxIdle int
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func newPool(size, maxIdle int, dial func(ctx context.Context) (*conn, error)) *pool {
+	return &pool{
+		dial:    dial,
+		slots:   make(chan struct{}, max(size, 1)),
+		maxIdle: maxIdle,
+	}
+}
+
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, ErrClosed
+	}
+	if n := len(p.idle); n > 0 {
+		cn := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return cn, nil
+	}
+	p.mu.Unlock()
+
+	cn, err := p.dial(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return cn, nil
+}
+
+// put releases cn, broken connections are closed so the next get dials again
+func (p *pool) put(cn *conn) {
+	p.mu.Lock()
+	if cn.broken || p.closed || len(p.idle) >= p.maxIdle {
+		p.mu.Unlock()
+		cn.close()
+	} else {
+		p.idle = append(p.idle, cn)
+		p.mu.Unlock()
+	}
+
+	<-p.slots
+}
+
+func (p *pool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, cn := range p.idle {
+		cn.close()
+	}
+	p.idle = nil
+}
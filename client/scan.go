@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+// ScanIterator walks a SCAN or ZSCAN cursor, fetching pages as needed
+//
+//	it := c.ZScan(ctx, &commands.ZScanCmd{Key: "board", NoScores: true})
+//	for it.Next() {
+//		fmt.Println(it.Val())
+//	}
+//	if err := it.Err(); err != nil { ... }
+type ScanIterator struct {
+	c    *Client
+	ctx  context.Context
+	args func(cursor string) []string
+
+	cursor  string
+	fetched bool
+	page    []string
+	val     string
+	err     error
+}
+
+// Scan iterates the keyspace, the cursor of cmd is where the iteration starts
+func (c *Client) Scan(ctx context.Context, cmd *commands.ScanCmd) *ScanIterator {
+	scan := *cmd
+	return c.newScanIterator(ctx, scan.Cursor, func(cursor string) []string {
+		return scanArgs("SCAN", "", &scan, cursor)
+	})
+}
+
+// ZScan iterates the members of a sorted set, each member is followed by its score
+// unless NoScores is set
+func (c *Client) ZScan(ctx context.Context, cmd *commands.ZScanCmd) *ScanIterator {
+	key, scan, noScores := cmd.Key, cmd.ScanCmd, cmd.NoScores
+	return c.newScanIterator(ctx, scan.Cursor, func(cursor string) []string {
+		args := scanArgs("ZSCAN", key, &scan, cursor)
+		if noScores {
+			args = append(args, "NOSCORES")
+		}
+		return args
+	})
+}
+
+func (c *Client) newScanIterator(ctx context.Context, cursor string, args func(cursor string) []string) *ScanIterator {
+	if cursor == "" {
+		cursor = "0"
+	}
+
+	return &ScanIterator{c: c, ctx: ctx, args: args, cursor: cursor}
+}
+
+func (it *ScanIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.fetched && it.cursor == "0") {
+			return false
+		}
+
+		it.fetch()
+	}
+
+	it.val, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *ScanIterator) fetch() {
+	it.fetched = true
+	reply, err := it.c.do(it.ctx, it.args(it.cursor))
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if len(reply.Elems) != 2 {
+		it.err = unexpectedReply(reply)
+		return
+	}
+
+	it.cursor = reply.Elems[0].Str
+	for _, elem := range reply.Elems[1].Elems {
+		if elem.Type != miniresp3.RESPBulkString {
+			it.err = unexpectedReply(elem)
+			return
+		}
+		it.page = append(it.page, elem.Str)
+	}
+}
+
+func (it *ScanIterator) Val() string {
+	return it.val
+}
+
+func (it *ScanIterator) Err() error {
+	return it.err
+}
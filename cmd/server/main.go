@@ -2,17 +2,59 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/AdhityaRamadhanus/zdb"
 	"github.com/AdhityaRamadhanus/zdb/tcp"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
+	defaults := tcp.DefaultConfig()
+	addr := flag.String("addr", "localhost:9000", "address to listen on")
+	slowlogLogSlowerThan := flag.Duration("slowlog-log-slower-than", defaults.SlowlogLogSlowerThan, "log commands slower than this in the slowlog, negative disables it")
+	slowlogMaxLen := flag.Int("slowlog-max-len", defaults.SlowlogMaxLen, "maximum number of slowlog entries")
+	latencyMonitorThreshold := flag.Duration("latency-monitor-threshold", defaults.LatencyMonitorThreshold, "record latency events at least this long, 0 disables it")
+	maxClients := flag.Int("maxclients", defaults.MaxClients, "maximum number of connected clients, 0 means unlimited")
+	idleTimeout := flag.Duration("timeout", defaults.IdleTimeout, "close connections idle for this long, 0 disables it")
+	outputBufferHardLimit := flag.Int("client-output-buffer-hard-limit", defaults.ClientOutputBufferHardLimit, "disconnect clients whose pending reply is bigger than this many bytes, 0 disables it")
+	outputBufferSoftLimit := flag.Int("client-output-buffer-soft-limit", defaults.ClientOutputBufferSoftLimit, "disconnect clients whose pending reply stays bigger than this many bytes, 0 disables it")
+	outputBufferSoftSeconds := flag.Duration("client-output-buffer-soft-seconds", defaults.ClientOutputBufferSoftSeconds, "how long a client may stay over the soft output buffer limit")
+	clientWriteTimeout := flag.Duration("client-write-timeout", defaults.ClientWriteTimeout, "close connections when writing a reply makes no progress for this long, 0 disables it")
+	maxMemory := flag.Int("maxmemory", defaults.MaxMemory, "bytes the keys may use before evicting, 0 means no limit")
+	maxMemoryPolicy := flag.String("maxmemory-policy", string(defaults.MaxMemoryPolicy), "noeviction, allkeys-lru, allkeys-lfu, volatile-ttl or allkeys-random")
+	zsetBackend := flag.String("zset-backend", string(defaults.Backend), "avltree or skiplist, used by sorted sets too big for a listpack")
+	zsetMaxListpackEntries := flag.Int("zset-max-listpack-entries", defaults.ZSetMaxListpackEntries, "sorted sets with more members are converted from a listpack to the zset-backend, 0 disables listpacks")
+	zsetMaxListpackValue := flag.Int("zset-max-listpack-value", defaults.ZSetMaxListpackValue, "sorted sets with a longer member are converted from a listpack to the zset-backend")
+	threaded := flag.Bool("threaded", defaults.Threaded, "run commands on keys of different shards in parallel instead of one at a time")
+	flag.Parse()
+
+	evictionPolicy, err := zdb.ParseEvictionPolicy(*maxMemoryPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Str("maxmemory-policy", *maxMemoryPolicy).Msg("invalid flag")
+	}
+
+	backend, err := zdb.ParseBackend(*zsetBackend)
+	if err != nil {
+		log.Fatal().Err(err).Str("zset-backend", *zsetBackend).Msg("invalid flag")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	srv := tcp.NewServer("tcp", "localhost:9000")
+	srv := tcp.NewServer("tcp", *addr,
+		tcp.WithSlowlog(*slowlogLogSlowerThan, *slowlogMaxLen),
+		tcp.WithLatencyMonitorThreshold(*latencyMonitorThreshold),
+		tcp.WithMaxClients(*maxClients),
+		tcp.WithIdleTimeout(*idleTimeout),
+		tcp.WithClientOutputBufferLimit(*outputBufferHardLimit, *outputBufferSoftLimit, *outputBufferSoftSeconds),
+		tcp.WithClientWriteTimeout(*clientWriteTimeout),
+		tcp.WithMaxMemory(*maxMemory, evictionPolicy),
+		tcp.WithBackend(backend),
+		tcp.WithZSetMaxListpack(*zsetMaxListpackEntries, *zsetMaxListpackValue),
+		tcp.WithThreaded(*threaded),
+	)
 	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+// formatReply renders a reply the way redis-cli does in a terminal, nested
+// aggregates are indented under their index
+func formatReply(v miniresp3.Value) string {
+	sb := &strings.Builder{}
+	writeReply(sb, v, 0)
+	return sb.String()
+}
+
+func writeReply(sb *strings.Builder, v miniresp3.Value, indent int) {
+	if v.IsNull() {
+		sb.WriteString("(nil)\n")
+		return
+	}
+
+	switch v.Type {
+	case miniresp3.RESPSimpleString:
+		sb.WriteString(v.Str)
+		sb.WriteString("\n")
+	case miniresp3.RESPVerbatimString:
+		sb.WriteString(strings.ReplaceAll(v.Str, "\r\n", "\n"))
+	case miniresp3.RESPBulkString:
+		sb.WriteString(strconv.Quote(v.Str))
+		sb.WriteString("\n")
+	case miniresp3.RESPSimpleError, miniresp3.RESPBulkError:
+		fmt.Fprintf(sb, "(error) %s\n", v.Str)
+	case miniresp3.RESPNumber:
+		fmt.Fprintf(sb, "(integer) %d\n", v.Int)
+	case miniresp3.RESPDoubles:
+		fmt.Fprintf(sb, "(double) %s\n", strconv.FormatFloat(v.Float, 'g', -1, 64))
+	case miniresp3.RESPBoolean:
+		fmt.Fprintf(sb, "(%t)\n", v.Bool)
+	case miniresp3.RESPBigNumber:
+		fmt.Fprintf(sb, "(big number) %s\n", v.Big)
+	case miniresp3.RESPMap:
+		writeAggregate(sb, len(v.Elems)/2, "#", "(empty hash)", indent, func(i, indent int) {
+			key := formatReply(v.Elems[i*2])
+			sb.WriteString(strings.TrimSuffix(key, "\n"))
+			sb.WriteString(" => ")
+			writeReply(sb, v.Elems[i*2+1], indent)
+		})
+	default:
+		marker, empty := ")", "(empty array)"
+		if v.Type == miniresp3.RESPSet {
+			marker, empty = "~", "(empty set)"
+		}
+		writeAggregate(sb, len(v.Elems), marker, empty, indent, func(i, indent int) {
+			writeReply(sb, v.Elems[i], indent)
+		})
+	}
+}
+
+func writeAggregate(sb *strings.Builder, n int, marker, empty string, indent int, writeElem func(i, indent int)) {
+	if n == 0 {
+		sb.WriteString(empty)
+		sb.WriteString("\n")
+		return
+	}
+
+	width := len(strconv.Itoa(n))
+	for i := range n {
+		if i > 0 {
+			sb.WriteString(strings.Repeat(" ", indent))
+		}
+		prefix := fmt.Sprintf("%*d%s ", width, i+1, marker)
+		sb.WriteString(prefix)
+		writeElem(i, indent+len(prefix))
+	}
+}
+
+// formatRaw renders a reply without any decoration, one scalar per line, for
+// output going to a pipe or a file
+func formatRaw(v miniresp3.Value) string {
+	sb := &strings.Builder{}
+	writeRaw(sb, v)
+	return sb.String()
+}
+
+func writeRaw(sb *strings.Builder, v miniresp3.Value) {
+	switch {
+	case v.IsNull():
+		sb.WriteString("\n")
+	case len(v.Elems) > 0:
+		for _, elem := range v.Elems {
+			writeRaw(sb, elem)
+		}
+	case v.Type == miniresp3.RESPArray, v.Type == miniresp3.RESPSet, v.Type == miniresp3.RESPMap, v.Type == miniresp3.RESPPush:
+	default:
+		fmt.Fprintln(sb, v.Interface())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/client"
+	"golang.org/x/term"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "address of the zdb server")
+	proto := flag.Int("resp", 3, "protocol version to use, 2 or 3")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of every command")
+	pipe := flag.Bool("pipe", false, "send the inline commands read from stdin, one per line, and report the replies")
+	latency := flag.Bool("latency", false, "measure the latency of PING continuously")
+	stat := flag.Bool("stat", false, "print server stats continuously")
+	bigkeys := flag.Bool("bigkeys", false, "scan the keyspace for the biggest sorted sets")
+	interval := flag.Duration("i", time.Second, "interval between --stat samples")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: zdb-cli [flags] [command [arg ...]]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	c := client.New(*addr, client.WithProtocol(*proto), client.WithTimeouts(*timeout, *timeout), client.WithPoolSize(1, 1))
+	defer c.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var err error
+	switch {
+	case *pipe:
+		err = runPipe(ctx, c, os.Stdin, os.Stdout)
+	case *latency:
+		err = runLatency(ctx, c, os.Stdout)
+	case *stat:
+		err = runStat(ctx, c, *interval, os.Stdout)
+	case *bigkeys:
+		err = runBigKeys(ctx, c, os.Stdout)
+	case flag.NArg() > 0:
+		err = runCommand(ctx, c, flag.Args(), os.Stdout, term.IsTerminal(int(os.Stdout.Fd())))
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		err = runScript(ctx, c, os.Stdin, os.Stdout)
+	default:
+		err = runREPL(ctx, c, *addr)
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/client"
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+const (
+	pipeBatchSize   = 1000
+	latencyInterval = 10 * time.Millisecond
+	statHeaderEvery = 20
+	bigKeysBatch    = 100
+	bigKeysTop      = 10
+)
+
+// runPipe bulk loads inline commands from in, sending them in pipelined batches
+func runPipe(ctx context.Context, c *client.Client, in io.Reader, out io.Writer) error {
+	p := c.Pipeline()
+	replies, errs := 0, 0
+	flush := func() error {
+		results, err := p.Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if err, ok := result.(client.Error); ok {
+				fmt.Fprintln(os.Stderr, err)
+				errs++
+			}
+		}
+		replies += len(results)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 512*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		args, ok := miniresp3.SplitArgs(scanner.Text())
+		if !ok {
+			return fmt.Errorf("line %d: invalid argument(s)", lineNum)
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		p.Do(args...)
+		if p.Len() >= pipeBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "All data transferred. errors: %d, replies: %d\n", errs, replies)
+	return nil
+}
+
+// runLatency pings the server until interrupted and keeps the min, max and average
+// round trip on a single line
+func runLatency(ctx context.Context, c *client.Client, out io.Writer) error {
+	var minLatency, maxLatency, total time.Duration
+	samples := 0
+	for ctx.Err() == nil {
+		start := time.Now()
+		if err := c.Ping(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		latency := time.Since(start)
+
+		if samples == 0 || latency < minLatency {
+			minLatency = latency
+		}
+		maxLatency = max(maxLatency, latency)
+		total += latency
+		samples++
+
+		fmt.Fprintf(out, "\rmin: %.2f, max: %.2f, avg: %.2f (%d samples)", ms(minLatency), ms(maxLatency), ms(total)/float64(samples), samples)
+
+		select {
+		case <-time.After(latencyInterval):
+		case <-ctx.Done():
+		}
+	}
+
+	fmt.Fprintln(out)
+	return nil
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// runStat prints a line of server stats every interval until interrupted
+func runStat(ctx context.Context, c *client.Client, interval time.Duration, out io.Writer) error {
+	var prevCommands int64
+	for i := 0; ; i++ {
+		info, err := fetchInfo(ctx, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if i%statHeaderEvery == 0 {
+			fmt.Fprintln(out, "------- data ------ ------------ load ------------")
+			fmt.Fprintln(out, "keys       mem      clients requests            connections")
+		}
+
+		keys := strings.TrimPrefix(info["db0"], "keys=")
+		usedMemory, _ := strconv.Atoi(info["used_memory"])
+		totalCommands, _ := strconv.ParseInt(info["total_commands_processed"], 10, 64)
+		requests := fmt.Sprintf("%d (+%d)", totalCommands, totalCommands-prevCommands)
+		if i == 0 {
+			requests = strconv.FormatInt(totalCommands, 10)
+		}
+		prevCommands = totalCommands
+
+		fmt.Fprintf(out, "%-10s %-8s %-7s %-19s %s\n", keys, humanBytes(usedMemory), info["connected_clients"], requests, info["total_connections_received"])
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// fetchInfo returns the field:value lines of INFO
+func fetchInfo(ctx context.Context, c *client.Client) (map[string]string, error) {
+	reply, err := c.DoValue(ctx, "INFO")
+	if err != nil {
+		return nil, err
+	}
+
+	info := map[string]string{}
+	for _, line := range strings.Split(reply.Str, "\r\n") {
+		if name, value, found := strings.Cut(line, ":"); found && !strings.HasPrefix(line, "#") {
+			info[name] = value
+		}
+	}
+
+	return info, nil
+}
+
+func humanBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	value, suffix := float64(n), ""
+	for _, suffix = range []string{"K", "M", "G", "T"} {
+		value /= unit
+		if value < unit {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%.2f%s", value, suffix)
+}
+
+type bigKey struct {
+	key     string
+	members int
+	memory  int
+}
+
+// runBigKeys scans the whole keyspace and reports the biggest sorted sets
+func runBigKeys(ctx context.Context, c *client.Client, out io.Writer) error {
+	fmt.Fprintln(out, "# Scanning the entire keyspace to find the biggest sorted sets")
+	fmt.Fprintln(out)
+
+	keys := []string{}
+	seen := map[string]bool{}
+	it := c.Scan(ctx, &commands.ScanCmd{Cursor: "0", Count: bigKeysBatch})
+	for it.Next() {
+		if !seen[it.Val()] {
+			seen[it.Val()] = true
+			keys = append(keys, it.Val())
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	sizes := []bigKey{}
+	totalKeyLen, totalMembers := 0, 0
+	var biggest bigKey
+	for batch := range slices.Chunk(keys, bigKeysBatch) {
+		p := c.Pipeline()
+		for _, key := range batch {
+			p.Do("ZCARD", key)
+			p.Do("MEMORY", "USAGE", key)
+		}
+		results, err := p.Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i, key := range batch {
+			members, ok := results[i*2].(int64)
+			if !ok {
+				return fmt.Errorf("ZCARD %s: %v", key, results[i*2])
+			}
+			// keys expiring during the scan reply nil
+			memory, _ := results[i*2+1].(int64)
+
+			size := bigKey{key: key, members: int(members), memory: int(memory)}
+			sizes = append(sizes, size)
+			totalKeyLen += len(key)
+			totalMembers += size.members
+
+			if size.members > biggest.members {
+				biggest = size
+				fmt.Fprintf(out, "Biggest zset found so far '%s' with %d members\n", key, size.members)
+			}
+		}
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "-------- summary -------")
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Sampled %d keys in the keyspace!\n", len(sizes))
+	if len(sizes) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "Total key length in bytes is %d (avg len %.2f)\n", totalKeyLen, float64(totalKeyLen)/float64(len(sizes)))
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Biggest zset found '%s' has %d members\n", biggest.key, biggest.members)
+	fmt.Fprintf(out, "%d zsets with %d members (avg size %.2f)\n", len(sizes), totalMembers, float64(totalMembers)/float64(len(sizes)))
+
+	slices.SortFunc(sizes, func(x, y bigKey) int {
+		return cmp.Or(cmp.Compare(y.members, x.members), cmp.Compare(y.memory, x.memory))
+	})
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "-------- top %d --------\n", bigKeysTop)
+	fmt.Fprintln(out)
+	for i, size := range sizes[:min(bigKeysTop, len(sizes))] {
+		fmt.Fprintf(out, "%2d) '%s' %d members, %s\n", i+1, size.key, size.members, humanBytes(size.memory))
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AdhityaRamadhanus/zdb/client"
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+	"golang.org/x/term"
+)
+
+const (
+	historyFile   = ".zdbcli_history"
+	maxHistoryLen = 1000
+)
+
+// history keeps the last lines typed in the REPL and appends new ones to a file
+type history struct {
+	lines []string
+	file  *os.File
+}
+
+func loadHistory(path string) *history {
+	h := &history{}
+	if content, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			if line != "" {
+				h.lines = append(h.lines, line)
+			}
+		}
+		h.lines = h.lines[max(0, len(h.lines)-maxHistoryLen):]
+	}
+
+	// rewrite the file so it doesn't grow forever
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return h
+	}
+	for _, line := range h.lines {
+		fmt.Fprintln(file, line)
+	}
+	h.file = file
+
+	return h
+}
+
+func (h *history) Add(entry string) {
+	if entry == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == entry) {
+		return
+	}
+
+	h.lines = append(h.lines, entry)
+	if len(h.lines) > maxHistoryLen {
+		h.lines = h.lines[1:]
+	}
+	if h.file != nil {
+		fmt.Fprintln(h.file, entry)
+	}
+}
+
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+func (h *history) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+func (h *history) close() {
+	if h.file != nil {
+		h.file.Close()
+	}
+}
+
+func runREPL(ctx context.Context, c *client.Client, addr string) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, addr+"> ")
+
+	home, _ := os.UserHomeDir()
+	h := loadHistory(filepath.Join(home, historyFile))
+	defer h.close()
+	t.History = h
+	t.AutoCompleteCallback = completer(t)
+
+	fmt.Fprintln(t, "Type HELP for the list of commands, TAB completes a command or shows its syntax")
+	for {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+
+		args, ok := miniresp3.SplitArgs(line)
+		if !ok {
+			fmt.Fprintln(t, "Invalid argument(s)")
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "quit", "exit":
+			return nil
+		case "help":
+			fmt.Fprint(t, help(args[1:]))
+			continue
+		case "monitor":
+			fmt.Fprintln(t, "(error) MONITOR isn't supported by zdb-cli")
+			continue
+		}
+
+		reply, err := c.DoValue(ctx, args...)
+		if err != nil && !errors.As(err, new(client.Error)) {
+			fmt.Fprintf(t, "(error) %s\n", err)
+			continue
+		}
+		fmt.Fprint(t, formatReply(reply))
+	}
+}
+
+// completer completes command names on TAB, or shows the syntax of the command
+// already typed
+func completer(t *term.Terminal) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		fields := strings.Fields(line[:pos])
+		if len(fields) == 0 {
+			return line, pos, true
+		}
+
+		name := strings.ToLower(fields[0])
+		if len(fields) == 1 && !strings.HasSuffix(line[:pos], " ") {
+			matches := slices.DeleteFunc(commands.Names(), func(n string) bool {
+				return !strings.HasPrefix(n, name)
+			})
+			switch {
+			case len(matches) == 1:
+				completed := strings.ToUpper(matches[0]) + " "
+				return completed + strings.TrimLeft(line[pos:], " "), len(completed), true
+			case len(matches) > 1:
+				fmt.Fprintln(t, strings.ToUpper(strings.Join(matches, " ")))
+				return line, pos, true
+			}
+		}
+
+		if doc, ok := commands.LookupDoc(name); ok {
+			fmt.Fprintln(t, syntax(name, doc))
+		}
+		return line, pos, true
+	}
+}
+
+func syntax(name string, doc commands.Doc) string {
+	return strings.TrimSpace(strings.ToUpper(name) + " " + doc.Syntax)
+}
+
+func help(args []string) string {
+	sb := &strings.Builder{}
+	if len(args) > 0 {
+		doc, ok := commands.LookupDoc(args[0])
+		if !ok {
+			return fmt.Sprintf("Unknown command %q\n", args[0])
+		}
+		fmt.Fprintf(sb, "\n  %s\n  summary: %s\n  group: %s\n\n", syntax(args[0], doc), doc.Summary, doc.Group)
+		return sb.String()
+	}
+
+	groups := map[string][]string{}
+	for _, name := range commands.Names() {
+		doc, _ := commands.LookupDoc(name)
+		groups[doc.Group] = append(groups[doc.Group], strings.ToUpper(name))
+	}
+	for _, group := range []string{"sorted-set", "generic", "connection", "server"} {
+		fmt.Fprintf(sb, "%s: %s\n", group, strings.Join(groups[group], " "))
+	}
+	sb.WriteString("Type HELP <command> for its syntax, QUIT to exit\n")
+
+	return sb.String()
+}
+
+// runCommand runs a single command, pretty printed unless the output isn't a terminal
+func runCommand(ctx context.Context, c *client.Client, args []string, out io.Writer, pretty bool) error {
+	reply, err := c.DoValue(ctx, args...)
+	if err != nil && !errors.As(err, new(client.Error)) {
+		return err
+	}
+
+	if pretty {
+		fmt.Fprint(out, formatReply(reply))
+	} else {
+		fmt.Fprint(out, formatRaw(reply))
+	}
+
+	return nil
+}
+
+// runScript runs the inline commands read from in one at a time, printing every reply
+func runScript(ctx context.Context, c *client.Client, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 512*1024*1024)
+	for scanner.Scan() {
+		args, ok := miniresp3.SplitArgs(scanner.Text())
+		if !ok {
+			return fmt.Errorf("invalid argument(s) in %q", scanner.Text())
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		if err := runCommand(ctx, c, args, out, false); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -1,9 +1,21 @@
 package commands
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
 
 var (
-	errWrongNumberOfArgs = errors.New("wrong number of arguments")
+	errWrongNumberOfArgs = errors.New("ERR wrong number of arguments")
+	errSyntax            = errors.New("ERR syntax error")
+	errUnknownSubcommand = errors.New("ERR unknown subcommand")
+	errNotInteger        = errors.New("ERR value is not an integer or out of range")
+	errNotFloat          = errors.New("ERR value is not a valid float")
+	errMinMaxNotFloat    = errors.New("ERR min or max is not a float")
+	errMinMaxNotLex      = errors.New("ERR min or max not valid string range item")
 )
 
 type CmdAble interface {
@@ -11,3 +23,66 @@ type CmdAble interface {
 }
 
 type CmdArgs []string
+
+func unknownSubcommand(cmdName, subcommand string) error {
+	return fmt.Errorf("%w '%s'. Try %s HELP.", errUnknownSubcommand, subcommand, strings.ToUpper(cmdName))
+}
+
+func parseInt(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	return n, nil
+}
+
+// parseFloat accepts inf, +inf and -inf but not nan
+func parseFloat(arg string) (float64, error) {
+	f, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+// parseScoreRange parses the min and max of a score range, a bound starting with ( is exclusive
+func parseScoreRange(minArg, maxArg string) (minScore, maxScore float64, err error) {
+	minScore, err = parseScoreBound(minArg, math.Inf(1))
+	if err != nil {
+		return 0, 0, err
+	}
+	maxScore, err = parseScoreBound(maxArg, math.Inf(-1))
+	if err != nil {
+		return 0, 0, err
+	}
+	return minScore, maxScore, nil
+}
+
+// parseLexBound parses a bound of a BYLEX range, [ starts an inclusive and ( an exclusive
+// member, inf is -1 for - and 1 for +, the lowest and highest member
+func parseLexBound(arg string) (key string, exclusive bool, inf int, err error) {
+	switch {
+	case arg == "-":
+		return "", false, -1, nil
+	case arg == "+":
+		return "", false, 1, nil
+	case strings.HasPrefix(arg, "["):
+		return arg[1:], false, 0, nil
+	case strings.HasPrefix(arg, "("):
+		return arg[1:], true, 0, nil
+	}
+
+	return "", false, 0, errMinMaxNotLex
+}
+
+func parseScoreBound(arg string, towards float64) (float64, error) {
+	exclusive := strings.HasPrefix(arg, "(")
+	score, err := parseFloat(strings.TrimPrefix(arg, "("))
+	if err != nil {
+		return 0, errMinMaxNotFloat
+	}
+	if exclusive {
+		score = math.Nextafter(score, towards)
+	}
+	return score, nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import "strings"
+
+// DEBUG ZSETINFO key
+// Map reply: tree height, member count, hash map size, encoding and capacity of the sorted set.
+
+// DEBUG ZSETCHECK key
+// Simple string reply: OK when the balance factors, heights, counts and ordering of every node are valid.
+
+type DebugCmd struct {
+	Subcommand string
+	Key        string
+}
+
+func (cmd *DebugCmd) Build(args CmdArgs) error {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Subcommand = strings.ToLower(args[0])
+	switch cmd.Subcommand {
+	case "zsetinfo", "zsetcheck":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs
+		}
+		cmd.Key = args[1]
+	default:
+		return unknownSubcommand("debug", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"slices"
+	"strings"
+)
+
+// Doc describes a command for humans, Syntax doesn't include the command name
+type Doc struct {
+	Summary string
+	Syntax  string
+	Group   string
+}
+
+var docs = map[string]Doc{
+	"client": {
+		Summary: "Inspects and manages client connections",
+		Syntax:  "LIST | INFO | ID | GETNAME | SETNAME name | KILL <addr | [ID id] [ADDR addr] [LADDR addr] [SKIPME yes|no]> | PAUSE timeout [WRITE | ALL] | UNPAUSE",
+		Group:   "connection",
+	},
+	"command": {
+		Summary: "Returns information about the commands",
+		Syntax:  "[COUNT | INFO [command ...] | DOCS [command ...] | GETKEYS command [arg ...]]",
+		Group:   "server",
+	},
+	"debug": {
+		Summary: "Dumps and checks the internal structure of a sorted set",
+		Syntax:  "ZSETINFO key | ZSETCHECK key",
+		Group:   "server",
+	},
+	"echo": {
+		Summary: "Returns the given string",
+		Syntax:  "message",
+		Group:   "connection",
+	},
+	"expire": {
+		Summary: "Sets the expiration time of a key in seconds",
+		Syntax:  "key seconds",
+		Group:   "generic",
+	},
+	"hello": {
+		Summary: "Handshakes with the server and switches the protocol",
+		Syntax:  "[protover [AUTH username password] [SETNAME clientname]]",
+		Group:   "connection",
+	},
+	"info": {
+		Summary: "Returns information and statistics about the server",
+		Syntax:  "[section [section ...]]",
+		Group:   "server",
+	},
+	"latency": {
+		Summary: "Inspects the latency monitor",
+		Syntax:  "LATEST | HISTORY event | RESET [event [event ...]]",
+		Group:   "server",
+	},
+	"memory": {
+		Summary: "Estimates the memory used by a key",
+		Syntax:  "USAGE key",
+		Group:   "server",
+	},
+	"monitor": {
+		Summary: "Streams every command processed by the server",
+		Syntax:  "",
+		Group:   "server",
+	},
+	"object": {
+		Summary: "Inspects the internals of a key",
+		Syntax:  "ENCODING key | FREQ key | IDLETIME key",
+		Group:   "generic",
+	},
+	"persist": {
+		Summary: "Removes the expiration time of a key",
+		Syntax:  "key",
+		Group:   "generic",
+	},
+	"ping": {
+		Summary: "Checks the connection",
+		Syntax:  "[message]",
+		Group:   "connection",
+	},
+	"scan": {
+		Summary: "Iterates over the keys",
+		Syntax:  "cursor [MATCH pattern] [COUNT count] [TYPE type]",
+		Group:   "generic",
+	},
+	"shards": {
+		Summary: "Returns the number of keys of every shard",
+		Syntax:  "",
+		Group:   "server",
+	},
+	"slowlog": {
+		Summary: "Inspects the slow queries log",
+		Syntax:  "GET [count] | LEN | RESET",
+		Group:   "server",
+	},
+	"ttl": {
+		Summary: "Returns the remaining time to live of a key in seconds",
+		Syntax:  "key",
+		Group:   "generic",
+	},
+	"zadd": {
+		Summary: "Adds members to a sorted set or updates their scores",
+		Syntax:  "key [NX | XX] [GT | LT] [CH] [INCR] score member [score member ...]",
+		Group:   "sorted-set",
+	},
+	"zcard": {
+		Summary: "Returns the number of members of a sorted set",
+		Syntax:  "key",
+		Group:   "sorted-set",
+	},
+	"zcount": {
+		Summary: "Counts the members with a score within a range",
+		Syntax:  "key min max",
+		Group:   "sorted-set",
+	},
+	"zdiff": {
+		Summary: "Returns the members of the first sorted set missing from the others",
+		Syntax:  "numkeys key [key ...] [WITHSCORES]",
+		Group:   "sorted-set",
+	},
+	"zdiffstore": {
+		Summary: "Stores the difference of sorted sets in a key",
+		Syntax:  "destination numkeys key [key ...]",
+		Group:   "sorted-set",
+	},
+	"zinter": {
+		Summary: "Returns the intersection of sorted sets",
+		Syntax:  "numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX] [WITHSCORES]",
+		Group:   "sorted-set",
+	},
+	"zinterstore": {
+		Summary: "Stores the intersection of sorted sets in a key",
+		Syntax:  "destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX]",
+		Group:   "sorted-set",
+	},
+	"zrange": {
+		Summary: "Returns members in a range of indexes, scores or members",
+		Syntax:  "key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]",
+		Group:   "sorted-set",
+	},
+	"zrank": {
+		Summary: "Returns the index of a member ordered by ascending score",
+		Syntax:  "key member",
+		Group:   "sorted-set",
+	},
+	"zrem": {
+		Summary: "Removes members from a sorted set",
+		Syntax:  "key member [member ...]",
+		Group:   "sorted-set",
+	},
+	"zscan": {
+		Summary: "Iterates over the members of a sorted set",
+		Syntax:  "key cursor [MATCH pattern] [COUNT count] [NOSCORES | WITHSCORES]",
+		Group:   "sorted-set",
+	},
+	"zscore": {
+		Summary: "Returns the score of a member",
+		Syntax:  "key member",
+		Group:   "sorted-set",
+	},
+	"zsetcap": {
+		Summary: "Caps the number of members of a sorted set",
+		Syntax:  "key [maxlen [EVICT MIN | MAX]]",
+		Group:   "sorted-set",
+	},
+	"zunion": {
+		Summary: "Returns the union of sorted sets",
+		Syntax:  "numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX] [WITHSCORES]",
+		Group:   "sorted-set",
+	},
+	"zunionstore": {
+		Summary: "Stores the union of sorted sets in a key",
+		Syntax:  "destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX]",
+		Group:   "sorted-set",
+	},
+}
+
+// LookupDoc finds the doc of a command, name is case insensitive
+func LookupDoc(name string) (Doc, bool) {
+	doc, ok := docs[strings.ToLower(name)]
+	return doc, ok
+}
+
+// Names returns the lowercase names of every documented command, sorted
+func Names() []string {
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,23 @@
+package commands
+
+// EXPIRE key seconds
+// Integer reply: 1 if the timeout was set, 0 if the key doesn't exist.
+
+type ExpireCmd struct {
+	Key     string
+	Seconds int
+}
+
+func (cmd *ExpireCmd) Build(args CmdArgs) (err error) {
+	if len(args) < 2 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Key = args[0]
+	cmd.Seconds, err = parseInt(args[1])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build unit
+
+package commands
+
+import "testing"
+
+func TestExpire(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ExpireCmd
+		WantErr error
+	}{
+		{
+			Name: "Expire in seconds",
+			Args: []string{"zset1", "60"},
+			Want: ExpireCmd{
+				Key:     "zset1",
+				Seconds: 60,
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Seconds is not an integer",
+			Args:    []string{"zset1", "1.5"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "Missing seconds",
+			Args:    []string{"zset1"},
+			WantErr: errWrongNumberOfArgs,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ExpireCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil && got != test.Want {
+					t.Errorf("got %+v, want %+v", got, test.Want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoProto = errors.New("NOPROTO unsupported protocol version")
+
+	errHelloProtoVer = errors.New("ERR Protocol version is not an integer or out of range")
+)
+
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
+
+type HelloCmd struct {
+	ProtoVer   int
+	Username   string
+	Password   string
+	ClientName string
+}
+
+func (cmd *HelloCmd) Build(args CmdArgs) (err error) {
+	if len(args) == 0 {
+		return nil
+	}
+
+	cmd.ProtoVer, err = strconv.Atoi(args[0])
+	if err != nil {
+		return errHelloProtoVer
+	}
+
+	if cmd.ProtoVer < 2 || cmd.ProtoVer > 3 {
+		return ErrNoProto
+	}
+
+	args = args[1:]
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "auth":
+			if (i + 2) >= len(args) {
+				return errSyntax
+			}
+			cmd.Username = args[i+1]
+			cmd.Password = args[i+2]
+			i += 2
+		case "setname":
+			if (i + 1) >= len(args) {
+				return errSyntax
+			}
+			i++
+			cmd.ClientName = args[i]
+		default:
+			return errSyntax
+		}
+	}
+
+	return nil
+}
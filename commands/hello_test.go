@@ -0,0 +1,60 @@
+//go:build unit
+
+package commands
+
+import "testing"
+
+func TestHello(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    HelloCmd
+		WantErr error
+	}{
+		{
+			Name: "All options used and correct",
+			Args: []string{"3", "auth", "user", "pass", "SETNAME", "cli"},
+			Want: HelloCmd{
+				ProtoVer:   3,
+				Username:   "user",
+				Password:   "pass",
+				ClientName: "cli",
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Protocol version is not an integer",
+			Args:    []string{"three"},
+			WantErr: errHelloProtoVer,
+		},
+		{
+			Name:    "Unsupported protocol version",
+			Args:    []string{"4"},
+			WantErr: ErrNoProto,
+		},
+		{
+			Name:    "Auth without password",
+			Args:    []string{"3", "auth", "user"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Unknown option",
+			Args:    []string{"2", "lib-name", "zdb"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := HelloCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil && got != test.Want {
+					t.Errorf("got %+v, want %+v", got, test.Want)
+				}
+			}
+		})
+	}
+}
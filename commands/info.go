@@ -0,0 +1,24 @@
+package commands
+
+import "strings"
+
+// INFO [section [section ...]]
+// Verbatim string reply: "# Section" headers followed by field:value lines.
+// Sections are server, clients, memory, stats and keyspace, all of them by default.
+
+type InfoCmd struct {
+	Sections []string
+}
+
+func (cmd *InfoCmd) Build(args CmdArgs) error {
+	for _, arg := range args {
+		section := strings.ToLower(arg)
+		if section == "all" || section == "default" || section == "everything" {
+			cmd.Sections = nil
+			return nil
+		}
+		cmd.Sections = append(cmd.Sections, section)
+	}
+
+	return nil
+}
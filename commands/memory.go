@@ -0,0 +1,30 @@
+package commands
+
+import "strings"
+
+// MEMORY USAGE key
+// Integer reply: the estimated bytes used by the key and its value.
+
+type MemoryCmd struct {
+	Subcommand string
+	Key        string
+}
+
+func (cmd *MemoryCmd) Build(args CmdArgs) error {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Subcommand = strings.ToLower(args[0])
+	switch cmd.Subcommand {
+	case "usage":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs
+		}
+		cmd.Key = args[1]
+	default:
+		return unknownSubcommand("memory", args[0])
+	}
+
+	return nil
+}
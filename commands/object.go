@@ -0,0 +1,29 @@
+package commands
+
+import "strings"
+
+// OBJECT ENCODING key | OBJECT FREQ key | OBJECT IDLETIME key
+
+type ObjectCmd struct {
+	Subcommand string
+	Key        string
+}
+
+func (cmd *ObjectCmd) Build(args CmdArgs) error {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Subcommand = strings.ToLower(args[0])
+	switch cmd.Subcommand {
+	case "encoding", "freq", "idletime":
+		if len(args) != 2 {
+			return errWrongNumberOfArgs
+		}
+		cmd.Key = args[1]
+	default:
+		return unknownSubcommand("object", args[0])
+	}
+
+	return nil
+}
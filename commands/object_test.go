@@ -0,0 +1,87 @@
+//go:build unit
+
+package commands
+
+import "testing"
+
+// OBJECT, MEMORY and DEBUG share the subcommand key parsing
+func TestSubcommands(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Cmd     CmdAble
+		Args    []string
+		WantKey string
+		WantErr string
+	}{
+		{
+			Name:    "Object encoding",
+			Cmd:     &ObjectCmd{},
+			Args:    []string{"ENCODING", "zset1"},
+			WantKey: "zset1",
+		},
+		{
+			Name:    "Object unknown subcommand",
+			Cmd:     &ObjectCmd{},
+			Args:    []string{"refcount", "zset1"},
+			WantErr: "ERR unknown subcommand 'refcount'. Try OBJECT HELP.",
+		},
+		{
+			Name:    "Object encoding with extra args",
+			Cmd:     &ObjectCmd{},
+			Args:    []string{"encoding", "zset1", "zset2"},
+			WantErr: errWrongNumberOfArgs.Error(),
+		},
+		{
+			Name:    "Memory usage",
+			Cmd:     &MemoryCmd{},
+			Args:    []string{"usage", "zset1"},
+			WantKey: "zset1",
+		},
+		{
+			Name:    "Memory unknown subcommand",
+			Cmd:     &MemoryCmd{},
+			Args:    []string{"doctor"},
+			WantErr: "ERR unknown subcommand 'doctor'. Try MEMORY HELP.",
+		},
+		{
+			Name:    "Debug zsetcheck",
+			Cmd:     &DebugCmd{},
+			Args:    []string{"ZSETCHECK", "zset1"},
+			WantKey: "zset1",
+		},
+		{
+			Name:    "Debug without key",
+			Cmd:     &DebugCmd{},
+			Args:    []string{"zsetinfo"},
+			WantErr: errWrongNumberOfArgs.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Cmd.Build(test.Args)
+			if test.WantErr != "" {
+				if err == nil || err.Error() != test.WantErr {
+					t.Errorf("got err %v, want err %v", err, test.WantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got err %v, want nil", err)
+			}
+
+			var key string
+			switch cmd := test.Cmd.(type) {
+			case *ObjectCmd:
+				key = cmd.Key
+			case *MemoryCmd:
+				key = cmd.Key
+			case *DebugCmd:
+				key = cmd.Key
+			}
+			if key != test.WantKey {
+				t.Errorf("got key %s, want %s", key, test.WantKey)
+			}
+		})
+	}
+}
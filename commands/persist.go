@@ -0,0 +1,17 @@
+package commands
+
+// PERSIST key
+// Integer reply: 1 if the timeout was removed, 0 if the key doesn't exist or has no timeout.
+
+type PersistCmd struct {
+	Key string
+}
+
+func (cmd *PersistCmd) Build(args CmdArgs) error {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Key = args[0]
+	return nil
+}
@@ -1,19 +1,39 @@
 package commands
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 )
 
-// SCAN cursor [MATCH pattern] [COUNT count]
+var (
+	errInvalidCursor = errors.New("ERR invalid cursor")
+)
+
+// SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]
+// The cursor is opaque, 0 starts a new iteration and is returned once it's complete.
+// Keys present during the whole iteration are returned at least once.
 
 type ScanCmd struct {
 	Cursor       string
 	MatchPattern string
 	Count        int
+	Type         string
 }
 
 func (cmd *ScanCmd) Build(args CmdArgs) error {
+	return cmd.build(args, func(args CmdArgs) int {
+		if strings.ToLower(args[0]) != "type" || len(args) < 2 {
+			return 0
+		}
+		cmd.Type = strings.ToLower(args[1])
+		return 2
+	})
+}
+
+// build parses the options shared by SCAN and ZSCAN, option parses the others and
+// returns the number of args it used, 0 if it doesn't know the option
+func (cmd *ScanCmd) build(args CmdArgs, option func(args CmdArgs) int) (err error) {
 	if len(args) < 1 {
 		return errWrongNumberOfArgs
 	}
@@ -21,25 +41,37 @@ func (cmd *ScanCmd) Build(args CmdArgs) error {
 	cmd.Count = 10
 	cmd.MatchPattern = ""
 	cmd.Cursor = args[0]
+	if _, err := strconv.ParseUint(cmd.Cursor, 10, 64); err != nil {
+		return errInvalidCursor
+	}
 
 	args = args[1:]
-
-	for i := 0; i < len(args); {
-		switch strings.ToLower(args[i]) {
+	for len(args) > 0 {
+		switch strings.ToLower(args[0]) {
 		case "match":
-			if (i + 1) >= len(args) {
-				return errWrongNumberOfArgs
+			if len(args) < 2 {
+				return errSyntax
 			}
-			i++
-			cmd.MatchPattern = args[i]
+			cmd.MatchPattern = args[1]
+			args = args[2:]
 		case "count":
-			if (i + 1) >= len(args) {
-				return errWrongNumberOfArgs
+			if len(args) < 2 {
+				return errSyntax
+			}
+			if cmd.Count, err = parseInt(args[1]); err != nil {
+				return err
+			}
+			if cmd.Count < 1 {
+				return errSyntax
+			}
+			args = args[2:]
+		default:
+			n := option(args)
+			if n == 0 {
+				return errSyntax
 			}
-			i++
-			cmd.Count, _ = strconv.Atoi(args[i])
+			args = args[n:]
 		}
-		i++
 	}
 
 	return nil
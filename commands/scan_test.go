@@ -23,6 +23,60 @@ func TestScan(t *testing.T) {
 			},
 			WantErr: nil,
 		},
+		{
+			Name: "Default count",
+			Args: []string{"0"},
+			Want: ScanCmd{
+				Cursor: "0",
+				Count:  10,
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Count is not an integer",
+			Args:    []string{"0", "count", "ten"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "Count is not positive",
+			Args:    []string{"0", "count", "0"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Match without pattern",
+			Args:    []string{"0", "match"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Unknown option",
+			Args:    []string{"0", "limit", "10"},
+			WantErr: errSyntax,
+		},
+		{
+			Name: "Type",
+			Args: []string{"20", "TYPE", "ZSet"},
+			Want: ScanCmd{
+				Cursor: "20",
+				Count:  10,
+				Type:   "zset",
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Cursor is not an integer",
+			Args:    []string{"user:1"},
+			WantErr: errInvalidCursor,
+		},
+		{
+			Name:    "Negative cursor",
+			Args:    []string{"-1"},
+			WantErr: errInvalidCursor,
+		},
+		{
+			Name:    "Noscores is only for zscan",
+			Args:    []string{"0", "noscores"},
+			WantErr: errSyntax,
+		},
 	}
 
 	for _, test := range tests {
@@ -41,3 +95,51 @@ func TestScan(t *testing.T) {
 		})
 	}
 }
+
+func TestZScanCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZScanCmd
+		WantErr error
+	}{
+		{
+			Name: "Without scores",
+			Args: []string{"zset1", "0", "match", "a*", "NOSCORES"},
+			Want: ZScanCmd{
+				Key:      "zset1",
+				ScanCmd:  ScanCmd{Cursor: "0", MatchPattern: "a*", Count: 10},
+				NoScores: true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "With scores",
+			Args: []string{"zset1", "5", "withscores", "count", "2"},
+			Want: ZScanCmd{
+				Key:     "zset1",
+				ScanCmd: ScanCmd{Cursor: "5", Count: 2},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Type is only for scan",
+			Args:    []string{"zset1", "0", "type", "zset"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZScanCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil && got != test.Want {
+					t.Errorf("got %v, want %v", got, test.Want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package commands
+
+// TTL key
+// Integer reply: the remaining seconds, -1 if the key has no expire, -2 if the key doesn't exist.
+
+type TTLCmd struct {
+	Key string
+}
+
+func (cmd *TTLCmd) Build(args CmdArgs) error {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Key = args[0]
+	return nil
+}
@@ -1,10 +1,16 @@
 package commands
 
 import (
-	"strconv"
+	"errors"
 	"strings"
 )
 
+var (
+	errZAddXXAndNX   = errors.New("ERR XX and NX options at the same time are not compatible")
+	errZAddGTLTAndNX = errors.New("ERR GT, LT, and/or NX options at the same time are not compatible")
+	errZAddIncrPair  = errors.New("ERR INCR option supports a single increment-element pair")
+)
+
 // ZADD key [NX | XX] [GT | LT] [CH] [INCR] score member [score member...]
 
 // ZADD supports a list of options, specified after the name of the key and before the first score argument. Options are:
@@ -41,57 +47,51 @@ func (z *ZADDCmd) Build(args CmdArgs) error {
 	}
 
 	z.Key = args[0]
-	cutOff := 0
-	for i := 1; i < len(args); {
-		isArgs := true
-		switch strings.ToLower(args[i]) {
+	args = args[1:]
+
+flags:
+	for ; len(args) > 0; args = args[1:] {
+		switch strings.ToLower(args[0]) {
 		case "xx":
 			z.XX = true
 		case "nx":
 			z.NX = true
 		case "lt":
 			z.LT = true
-			z.NX = false
 		case "gt":
 			z.GT = true
-			z.NX = false
 		case "ch":
 			z.CH = true
 		case "incr":
 			z.INCR = true
 		default:
-			isArgs = false
+			break flags
 		}
-
-		if !isArgs {
-			cutOff = i
-			break
-		}
-		i++
 	}
 
-	args = args[cutOff:]
-
-	if len(args)%2 != 0 {
-		return errWrongNumberOfArgs
+	if len(args) == 0 || len(args)%2 != 0 {
+		return errSyntax
+	}
+	if z.XX && z.NX {
+		return errZAddXXAndNX
+	}
+	if (z.GT && z.NX) || (z.LT && z.NX) || (z.GT && z.LT) {
+		return errZAddGTLTAndNX
+	}
+	if z.INCR && len(args) > 2 {
+		return errZAddIncrPair
 	}
 
-	// get Z members
-	for i := 0; i < len(args)/2; i++ {
-		score, err := strconv.ParseFloat(args[i*2], 64)
+	for i := 0; i < len(args); i += 2 {
+		score, err := parseFloat(args[i])
 		if err != nil {
-			continue
+			return err
 		}
-		member := args[i*2+1]
 		z.Members = append(z.Members, ZMember{
 			Score: score,
-			Key:   member,
+			Key:   args[i+1],
 		})
 	}
 
-	if len(z.Members) == 0 {
-		return errWrongNumberOfArgs
-	}
-
 	return nil
 }
@@ -3,6 +3,7 @@
 package commands
 
 import (
+	"math"
 	"slices"
 	"testing"
 )
@@ -45,7 +46,55 @@ func TestZAdd(t *testing.T) {
 				INCR:    false,
 				Members: []ZMember{},
 			},
-			WantErr: errWrongNumberOfArgs,
+			WantErr: errSyntax,
+		},
+		{
+			Name: "Infinite scores",
+			Args: []string{"zset1", "ch", "-inf", "MemberA", "+inf", "MemberB"},
+			Want: ZADDCmd{
+				Key: "zset1",
+				CH:  true,
+				Members: []ZMember{
+					{Score: math.Inf(-1), Key: "MemberA"},
+					{Score: math.Inf(1), Key: "MemberB"},
+				},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Score is not a float",
+			Args:    []string{"zset1", "85", "MemberA", "abc", "MemberB"},
+			WantErr: errNotFloat,
+		},
+		{
+			Name:    "Score is nan",
+			Args:    []string{"zset1", "nan", "MemberA"},
+			WantErr: errNotFloat,
+		},
+		{
+			Name:    "Only options without members",
+			Args:    []string{"zset1", "nx", "ch"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "XX and NX",
+			Args:    []string{"zset1", "xx", "nx", "85", "MemberA"},
+			WantErr: errZAddXXAndNX,
+		},
+		{
+			Name:    "GT and LT",
+			Args:    []string{"zset1", "gt", "lt", "85", "MemberA"},
+			WantErr: errZAddGTLTAndNX,
+		},
+		{
+			Name:    "LT and NX",
+			Args:    []string{"zset1", "lt", "nx", "85", "MemberA"},
+			WantErr: errZAddGTLTAndNX,
+		},
+		{
+			Name:    "INCR with more than one pair",
+			Args:    []string{"zset1", "incr", "85", "MemberA", "86", "MemberB"},
+			WantErr: errZAddIncrPair,
 		},
 	}
 
@@ -1,6 +1,6 @@
 package commands
 
-import "strconv"
+// ZCOUNT key min max
 
 type ZCountCmd struct {
 	Key string
@@ -14,14 +14,6 @@ func (cmd *ZCountCmd) Build(args CmdArgs) (err error) {
 	}
 
 	cmd.Key = args[0]
-	cmd.Min, err = strconv.ParseFloat(args[1], 64)
-	if err != nil {
-		return err
-	}
-	cmd.Max, err = strconv.ParseFloat(args[2], 64)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	cmd.Min, cmd.Max, err = parseScoreRange(args[1], args[2])
+	return err
 }
@@ -0,0 +1,62 @@
+//go:build unit
+
+package commands
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZCount(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZCountCmd
+		WantErr error
+	}{
+		{
+			Name: "Inclusive range",
+			Args: []string{"zset1", "1.5", "10"},
+			Want: ZCountCmd{
+				Key: "zset1",
+				Min: 1.5,
+				Max: 10,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Exclusive and infinite range",
+			Args: []string{"zset1", "(1", "+inf"},
+			Want: ZCountCmd{
+				Key: "zset1",
+				Min: math.Nextafter(1, math.Inf(1)),
+				Max: math.Inf(1),
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Min is not a float",
+			Args:    []string{"zset1", "one", "10"},
+			WantErr: errMinMaxNotFloat,
+		},
+		{
+			Name:    "Max is not a float",
+			Args:    []string{"zset1", "1", "(ten"},
+			WantErr: errMinMaxNotFloat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZCountCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil && got != test.Want {
+					t.Errorf("got %+v, want %+v", got, test.Want)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,12 @@ package commands
 
 import (
 	"errors"
-	"strconv"
 	"strings"
 )
 
 var (
-	errZDiffNeed2Keys = errors.New("zdiff command need at least 2 keys")
+	errZDiffNeedKey      = errors.New("ERR at least 1 input key is needed for 'zdiff' command")
+	errZDiffStoreNeedKey = errors.New("ERR at least 1 input key is needed for 'zdiffstore' command")
 )
 
 type ZDiffCmd struct {
@@ -19,18 +19,18 @@ type ZDiffCmd struct {
 // ZDIFF numkeys key [key ...] [WITHSCORES]
 
 func (cmd *ZDiffCmd) Build(args CmdArgs) (err error) {
-	// at least 3 args, numkeys and 2 keys
-	if len(args) < 3 {
+	// at least 2 args, numkeys and a key
+	if len(args) < 2 {
 		return errWrongNumberOfArgs
 	}
 
-	cmd.NumKeys, err = strconv.Atoi(args[0])
+	cmd.NumKeys, err = parseInt(args[0])
 	if err != nil {
 		return err
 	}
 
-	if cmd.NumKeys < 2 {
-		return errZDiffNeed2Keys
+	if cmd.NumKeys < 1 {
+		return errZDiffNeedKey
 	}
 
 	if (1 + cmd.NumKeys) > len(args) {
@@ -39,13 +39,12 @@ func (cmd *ZDiffCmd) Build(args CmdArgs) (err error) {
 
 	cmd.Keys = append(cmd.Keys, args[1:(1+cmd.NumKeys)]...)
 	args = args[(1 + cmd.NumKeys):]
-	for i := 0; i < len(args); {
-		switch strings.ToLower(args[i]) {
-		case "withscores":
-			cmd.WithScores = true
+	for _, arg := range args {
+		if strings.ToLower(arg) != "withscores" {
+			return errSyntax
 		}
-
-		i++
+		cmd.WithScores = true
 	}
+
 	return nil
 }
@@ -0,0 +1,134 @@
+//go:build unit
+
+package commands
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestZDiffCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZDiffCmd
+		WantErr error
+	}{
+		{
+			Name: "Diff with scores",
+			Args: []string{"2", "zset1", "zset2", "WITHSCORES"},
+			Want: ZDiffCmd{
+				NumKeys:    2,
+				Keys:       []string{"zset1", "zset2"},
+				WithScores: true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Single key",
+			Args: []string{"1", "zset1"},
+			Want: ZDiffCmd{
+				NumKeys: 1,
+				Keys:    []string{"zset1"},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Numkeys is 0",
+			Args:    []string{"0", "zset1"},
+			WantErr: errZDiffNeedKey,
+		},
+		{
+			Name:    "Negative numkeys",
+			Args:    []string{"-1", "zset1", "zset2"},
+			WantErr: errZDiffNeedKey,
+		},
+		{
+			Name:    "Numkeys is not an integer",
+			Args:    []string{"x", "zset1", "zset2"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "Incorrect number of keys",
+			Args:    []string{"3", "zset1", "zset2"},
+			WantErr: errKeysDoesntMatchNumKeys,
+		},
+		{
+			Name:    "Weights aren't supported",
+			Args:    []string{"2", "zset1", "zset2", "weights", "1", "2"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZDiffCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil {
+					checkZDiffCmd(t, got, test.Want)
+				}
+			}
+		})
+	}
+}
+
+func TestZDiffStoreCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZDiffStoreCmd
+		WantErr error
+	}{
+		{
+			Name: "Store keeps the scores",
+			Args: []string{"dst", "2", "zset1", "zset2"},
+			Want: ZDiffStoreCmd{
+				DstKey: "dst",
+				ZDiffCmd: ZDiffCmd{
+					NumKeys:    2,
+					Keys:       []string{"zset1", "zset2"},
+					WithScores: true,
+				},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Store with numkeys 0",
+			Args:    []string{"dst", "0", "zset1"},
+			WantErr: errZDiffStoreNeedKey,
+		},
+		{
+			Name:    "Store doesn't take withscores",
+			Args:    []string{"dst", "2", "zset1", "zset2", "withscores"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZDiffStoreCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil {
+					if got.DstKey != test.Want.DstKey {
+						t.Errorf("got dst key %s, want %s", got.DstKey, test.Want.DstKey)
+					}
+					checkZDiffCmd(t, got.ZDiffCmd, test.Want.ZDiffCmd)
+				}
+			}
+		})
+	}
+}
+
+func checkZDiffCmd(t *testing.T, got, want ZDiffCmd) {
+	t.Helper()
+
+	if got.NumKeys != want.NumKeys || !slices.Equal(got.Keys, want.Keys) || got.WithScores != want.WithScores {
+		t.Errorf("got %v, want %v\n", got, want)
+	}
+}
@@ -6,13 +6,22 @@ type ZDiffStoreCmd struct {
 }
 
 func (cmd *ZDiffStoreCmd) Build(args CmdArgs) error {
-	if len(args) < 4 {
+	if len(args) < 3 {
 		return errWrongNumberOfArgs
 	}
 
 	cmd.DstKey = args[0]
 	args = args[1:]
 
+	if err := cmd.ZDiffCmd.Build(args); err == errZDiffNeedKey {
+		return errZDiffStoreNeedKey
+	} else if err != nil {
+		return err
+	}
+	if cmd.ZDiffCmd.WithScores {
+		return errSyntax
+	}
+
 	cmd.ZDiffCmd.WithScores = true
-	return cmd.ZDiffCmd.Build(args)
+	return nil
 }
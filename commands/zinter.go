@@ -1,15 +1,10 @@
 package commands
 
-import (
-	"errors"
-	"fmt"
-	"slices"
-	"strconv"
-	"strings"
-)
+import "errors"
 
 var (
-	errZInterNeed2Keys = errors.New("zinter command need at least 2 keys")
+	errZInterNeedKey      = errors.New("ERR at least 1 input key is needed for 'zinter' command")
+	errZInterStoreNeedKey = errors.New("ERR at least 1 input key is needed for 'zinterstore' command")
 )
 
 type ZInterCmd struct {
@@ -23,18 +18,18 @@ type ZInterCmd struct {
 // ZINTER numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE <SUM | MIN | MAX>] [WITHSCORES]
 
 func (cmd *ZInterCmd) Build(args CmdArgs) (err error) {
-	// at least 3 args, numkeys and 2 keys
-	if len(args) < 3 {
+	// at least 2 args, numkeys and a key
+	if len(args) < 2 {
 		return errWrongNumberOfArgs
 	}
 
-	cmd.NumKeys, err = strconv.Atoi(args[0])
+	cmd.NumKeys, err = parseInt(args[0])
 	if err != nil {
 		return err
 	}
 
-	if cmd.NumKeys < 2 {
-		return errZInterNeed2Keys
+	if cmd.NumKeys < 1 {
+		return errZInterNeedKey
 	}
 
 	if (1 + cmd.NumKeys) > len(args) {
@@ -42,44 +37,6 @@ func (cmd *ZInterCmd) Build(args CmdArgs) (err error) {
 	}
 
 	cmd.Keys = append(cmd.Keys, args[1:(1+cmd.NumKeys)]...)
-
-	args = args[(1 + cmd.NumKeys):]
-	for i := 0; i < len(args); {
-		switch strings.ToLower(args[i]) {
-		case "weights":
-			if i+cmd.NumKeys >= len(args) {
-				return errWeightsDoesntMatchKeys
-			}
-			for i := i + 1; i <= cmd.NumKeys; i++ {
-				weight, err := strconv.ParseFloat(args[i], 64)
-				if err != nil {
-					return errWrongNumberOfArgs
-				}
-				cmd.Weights = append(cmd.Weights, weight)
-			}
-		case "aggregate":
-			i++
-			if i >= len(args) {
-				return errWeightsDoesntMatchKeys
-			}
-
-			aggFunc := strings.ToLower(args[i])
-			fmt.Println("Agg func is ", aggFunc)
-			if slices.Index([]string{"sum", "min", "max"}, aggFunc) == -1 {
-				return errUnsupportedAggFunc
-			}
-
-			cmd.Aggregate = aggFunc
-		case "withscores":
-			cmd.WithScores = true
-		}
-
-		i++
-	}
-
-	if len(cmd.Weights) == 0 {
-		cmd.Weights = slices.Repeat([]float64{1}, cmd.NumKeys)
-	}
-
-	return nil
+	cmd.Weights, cmd.Aggregate, cmd.WithScores, err = parseSetOpOptions(args[(1+cmd.NumKeys):], cmd.NumKeys)
+	return err
 }
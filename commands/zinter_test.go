@@ -0,0 +1,150 @@
+//go:build unit
+
+package commands
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestZInterCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZInterCmd
+		WantErr error
+	}{
+		{
+			Name: "All options used and correct",
+			Args: []string{"2", "zset1", "zset2", "WEIGHTS", "2", "-1", "AGGREGATE", "Sum", "withscores"},
+			Want: ZInterCmd{
+				NumKeys:    2,
+				Keys:       []string{"zset1", "zset2"},
+				Weights:    []float64{2, -1},
+				Aggregate:  "sum",
+				WithScores: true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Single key",
+			Args: []string{"1", "zset1", "weights", "2"},
+			Want: ZInterCmd{
+				NumKeys: 1,
+				Keys:    []string{"zset1"},
+				Weights: []float64{2},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Numkeys is 0",
+			Args:    []string{"0", "zset1", "zset2"},
+			WantErr: errZInterNeedKey,
+		},
+		{
+			Name:    "Numkeys is not an integer",
+			Args:    []string{"2.5", "zset1", "zset2"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "Incorrect number of keys",
+			Args:    []string{"4", "zset1", "zset2", "zset3"},
+			WantErr: errKeysDoesntMatchNumKeys,
+		},
+		{
+			Name:    "Incorrect number of weights",
+			Args:    []string{"3", "zset1", "zset2", "zset3", "weights", "1", "2"},
+			WantErr: errWeightsDoesntMatchKeys,
+		},
+		{
+			Name:    "Weight is nan",
+			Args:    []string{"2", "zset1", "zset2", "weights", "nan", "1"},
+			WantErr: errWeightNotFloat,
+		},
+		{
+			Name:    "Unknown option",
+			Args:    []string{"2", "zset1", "zset2", "rev"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZInterCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil {
+					checkZInterCmd(t, got, test.Want)
+				}
+			}
+		})
+	}
+}
+
+func TestZInterStoreCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZInterStoreCmd
+		WantErr error
+	}{
+		{
+			Name: "Store with aggregate",
+			Args: []string{"dst", "2", "zset1", "zset2", "aggregate", "max"},
+			Want: ZInterStoreCmd{
+				DstKey: "dst",
+				ZInterCmd: ZInterCmd{
+					NumKeys:    2,
+					Keys:       []string{"zset1", "zset2"},
+					Weights:    []float64{1, 1},
+					Aggregate:  "max",
+					WithScores: true,
+				},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Store with numkeys 0",
+			Args:    []string{"dst", "0", "zset1"},
+			WantErr: errZInterStoreNeedKey,
+		},
+		{
+			Name:    "Store doesn't take withscores",
+			Args:    []string{"dst", "2", "zset1", "zset2", "withscores"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZInterStoreCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil {
+					if got.DstKey != test.Want.DstKey {
+						t.Errorf("got dst key %s, want %s", got.DstKey, test.Want.DstKey)
+					}
+					checkZInterCmd(t, got.ZInterCmd, test.Want.ZInterCmd)
+				}
+			}
+		})
+	}
+}
+
+func checkZInterCmd(t *testing.T, got, want ZInterCmd) {
+	t.Helper()
+
+	isAllSame := (got.NumKeys == want.NumKeys &&
+		got.Aggregate == want.Aggregate &&
+		slices.Equal(got.Keys, want.Keys) &&
+		slices.Equal(got.Weights, want.Weights) &&
+		got.WithScores == want.WithScores)
+
+	if !isAllSame {
+		t.Errorf("got %v, want %v\n", got, want)
+	}
+}
@@ -6,13 +6,22 @@ type ZInterStoreCmd struct {
 }
 
 func (cmd *ZInterStoreCmd) Build(args CmdArgs) error {
-	if len(args) < 4 {
+	if len(args) < 3 {
 		return errWrongNumberOfArgs
 	}
 
 	cmd.DstKey = args[0]
 	args = args[1:]
 
+	if err := cmd.ZInterCmd.Build(args); err == errZInterNeedKey {
+		return errZInterStoreNeedKey
+	} else if err != nil {
+		return err
+	}
+	if cmd.ZInterCmd.WithScores {
+		return errSyntax
+	}
+
 	cmd.ZInterCmd.WithScores = true
-	return cmd.ZInterCmd.Build(args)
+	return nil
 }
@@ -1,12 +1,18 @@
 package commands
 
 import (
-	"fmt"
+	"errors"
 	"math"
 	"strings"
 )
 
-// ZRANGE key start stop [BYSCORE | BYLEX] [REV] [WITHSCORES]
+var (
+	errLimitWithoutBy = errors.New("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+)
+
+// ZRANGE key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
+
+// With REV, start and stop of BYSCORE and BYLEX are the max and the min of the range.
 
 type ZRangeCmd struct {
 	Key string
@@ -19,6 +25,11 @@ type ZRangeCmd struct {
 
 	MinKey string
 	MaxKey string
+	// BYLEX bounds starting with ( are exclusive, - and + set the Inf of a bound to -1 and 1
+	MinKeyExclusive bool
+	MaxKeyExclusive bool
+	MinKeyInf       int
+	MaxKeyInf       int
 
 	ByIndex bool
 	ByScore bool
@@ -26,70 +37,81 @@ type ZRangeCmd struct {
 
 	Reverse bool
 
+	// LIMIT offset count, a negative count returns every member past offset
+	Limit  bool
+	Offset int
+	Count  int
+
 	WithScores bool
 }
 
-func (cmd *ZRangeCmd) Build(args CmdArgs) error {
+func (cmd *ZRangeCmd) Build(args CmdArgs) (err error) {
 	if len(args) < 3 {
 		return errWrongNumberOfArgs
 	}
 
 	cmd.Key = args[0]
-
-	if len(args) > 3 {
-		for _, arg := range args[2:] {
-			switch strings.ToLower(arg) {
-			case "byscore":
-				cmd.ByScore = true
-			case "bylex":
-				cmd.ByLex = true
-			case "rev":
-				cmd.Reverse = true
-			case "withscores":
-				cmd.WithScores = true
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "byscore":
+			cmd.ByScore = true
+		case "bylex":
+			cmd.ByLex = true
+		case "rev":
+			cmd.Reverse = true
+		case "limit":
+			if i+2 >= len(args) {
+				return errSyntax
 			}
+			if cmd.Offset, err = parseInt(args[i+1]); err != nil {
+				return err
+			}
+			if cmd.Count, err = parseInt(args[i+2]); err != nil {
+				return err
+			}
+			cmd.Limit = true
+			i += 2
+		case "withscores":
+			cmd.WithScores = true
+		default:
+			return errSyntax
 		}
 	}
 
-	if cmd.ByScore {
-		minscore, maxscore := cmd.parseFloatScoreRange(args[1], args[2])
-		cmd.MinScore, cmd.MaxScore = minscore, maxscore
-		return nil
+	minArg, maxArg := args[1], args[2]
+	if cmd.Reverse {
+		minArg, maxArg = maxArg, minArg
 	}
 
-	if cmd.ByLex {
-		cmd.MinKey = args[1]
-		cmd.MaxKey = args[2]
-		return nil
-	}
-
-	cmd.ByIndex = true
-	fmt.Sscanf(args[1], "%d", &cmd.StartIndex)
-	fmt.Sscanf(args[2], "%d", &cmd.StopIndex)
-
-	return nil
-}
-
-func (cmd *ZRangeCmd) parseFloatScoreRange(arg1, arg2 string) (minScore float64, maxScore float64) {
-	minScore = -math.MaxFloat64
-	maxScore = math.MaxFloat64
-	if arg1 != "-inf" && arg1 != "+inf" {
-		if arg1[0] == '(' {
-			fmt.Sscanf(arg1, "(%f", &minScore)
-			minScore = math.Nextafter(minScore, math.Inf(1))
-		} else {
-			fmt.Sscanf(arg1, "%f", &minScore)
+	switch {
+	case cmd.ByScore && cmd.ByLex:
+		return errSyntax
+	case cmd.Limit && !cmd.ByScore && !cmd.ByLex:
+		return errLimitWithoutBy
+	case cmd.ByScore:
+		cmd.MinScore, cmd.MaxScore, err = parseScoreRange(minArg, maxArg)
+		if err != nil {
+			return err
 		}
-	}
-
-	if arg2 != "-inf" && arg2 != "+inf" {
-		if arg2[0] == '(' {
-			fmt.Sscanf(arg2, "(%f", &maxScore)
-			maxScore = math.Nextafter(maxScore, math.Inf(-1))
-		} else {
-			fmt.Sscanf(arg2, "%f", &maxScore)
+		// -inf and +inf are kept as the lowest and highest float
+		cmd.MinScore = max(cmd.MinScore, -math.MaxFloat64)
+		cmd.MaxScore = min(cmd.MaxScore, math.MaxFloat64)
+	case cmd.ByLex:
+		if cmd.MinKey, cmd.MinKeyExclusive, cmd.MinKeyInf, err = parseLexBound(minArg); err != nil {
+			return err
+		}
+		if cmd.MaxKey, cmd.MaxKeyExclusive, cmd.MaxKeyInf, err = parseLexBound(maxArg); err != nil {
+			return err
+		}
+	default:
+		cmd.ByIndex = true
+		if cmd.StartIndex, err = parseInt(args[1]); err != nil {
+			return err
+		}
+		if cmd.StopIndex, err = parseInt(args[2]); err != nil {
+			return err
 		}
 	}
 
-	return minScore, maxScore
+	return nil
 }
@@ -7,7 +7,7 @@ import (
 	"testing"
 )
 
-// ZRANGE key start stop [BYSCORE | BYLEX] [REV] [WITHSCORES]
+// ZRANGE key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
 
 func TestZRange(t *testing.T) {
 	tests := []struct {
@@ -60,18 +60,125 @@ func TestZRange(t *testing.T) {
 		},
 		{
 			Name: "zrange by lex with scores",
-			Args: []string{"zset1", "A", "Z", "bylex", "withscores"},
+			Args: []string{"zset1", "[A", "(Z", "bylex", "withscores"},
 			Want: ZRangeCmd{
-				Key:        "zset1",
-				MinKey:     "A",
-				MaxKey:     "Z",
-				ByLex:      true,
-				ByScore:    false,
-				ByIndex:    false,
-				WithScores: true,
+				Key:             "zset1",
+				MinKey:          "A",
+				MaxKey:          "Z",
+				MaxKeyExclusive: true,
+				ByLex:           true,
+				ByScore:         false,
+				ByIndex:         false,
+				WithScores:      true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "zrange by lex with infinite range",
+			Args: []string{"zset1", "-", "+", "bylex"},
+			Want: ZRangeCmd{
+				Key:       "zset1",
+				MinKeyInf: -1,
+				MaxKeyInf: 1,
+				ByLex:     true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "zrange by lex reversed takes the max first",
+			Args: []string{"zset1", "+", "(b", "bylex", "rev"},
+			Want: ZRangeCmd{
+				Key:             "zset1",
+				MinKey:          "b",
+				MinKeyExclusive: true,
+				MaxKeyInf:       1,
+				ByLex:           true,
+				Reverse:         true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "zrange by score reversed takes the max first",
+			Args: []string{"zset1", "10", "(5", "byscore", "rev", "limit", "1", "-1"},
+			Want: ZRangeCmd{
+				Key:      "zset1",
+				MinScore: math.Nextafter(5, math.Inf(1)),
+				MaxScore: 10,
+				ByScore:  true,
+				Reverse:  true,
+				Limit:    true,
+				Offset:   1,
+				Count:    -1,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "zrange by score with infinite range",
+			Args: []string{"zset1", "+inf", "-inf", "byscore", "rev"},
+			Want: ZRangeCmd{
+				Key:      "zset1",
+				MinScore: -math.MaxFloat64,
+				MaxScore: math.MaxFloat64,
+				ByScore:  true,
+				Reverse:  true,
 			},
 			WantErr: nil,
 		},
+		{
+			Name:    "zrange by index with invalid start",
+			Args:    []string{"zset1", "a", "-1"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "zrange by index with float stop",
+			Args:    []string{"zset1", "0", "1.5"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name:    "zrange by score with invalid min",
+			Args:    []string{"zset1", "(abc", "10", "byscore"},
+			WantErr: errMinMaxNotFloat,
+		},
+		{
+			Name:    "zrange by score with nan max",
+			Args:    []string{"zset1", "0", "nan", "byscore"},
+			WantErr: errMinMaxNotFloat,
+		},
+		{
+			Name:    "zrange by score and by lex",
+			Args:    []string{"zset1", "0", "10", "byscore", "bylex"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "zrange with unknown option",
+			Args:    []string{"zset1", "0", "-1", "nolimit"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "zrange by lex without a bound prefix",
+			Args:    []string{"zset1", "a", "[z", "bylex"},
+			WantErr: errMinMaxNotLex,
+		},
+		{
+			Name:    "zrange by lex with a longer infinite bound",
+			Args:    []string{"zset1", "[a", "+z", "bylex"},
+			WantErr: errMinMaxNotLex,
+		},
+		{
+			Name:    "zrange by index with limit",
+			Args:    []string{"zset1", "0", "-1", "limit", "0", "10"},
+			WantErr: errLimitWithoutBy,
+		},
+		{
+			Name:    "zrange with limit missing count",
+			Args:    []string{"zset1", "0", "10", "byscore", "limit", "0"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "zrange with non integer limit",
+			Args:    []string{"zset1", "0", "10", "byscore", "limit", "a", "1"},
+			WantErr: errNotInteger,
+		},
 	}
 
 	for _, test := range tests {
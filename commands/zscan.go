@@ -1,14 +1,32 @@
 package commands
 
+import "strings"
+
+// ZSCAN key cursor [MATCH pattern] [COUNT count] [NOSCORES | WITHSCORES]
+// Replies with the members followed by their score unless NOSCORES is given.
+// The cursor is the number of members already visited.
+
 type ZScanCmd struct {
-	Key     string
-	ScanCmd ScanCmd
+	Key      string
+	ScanCmd  ScanCmd
+	NoScores bool
 }
 
 func (cmd *ZScanCmd) Build(args CmdArgs) error {
 	if len(args) < 2 {
 		return errWrongNumberOfArgs
 	}
+
 	cmd.Key = args[0]
-	return cmd.ScanCmd.Build(args[1:])
+	return cmd.ScanCmd.build(args[1:], func(args CmdArgs) int {
+		switch strings.ToLower(args[0]) {
+		case "noscores":
+			cmd.NoScores = true
+		case "withscores":
+			cmd.NoScores = false
+		default:
+			return 0
+		}
+		return 1
+	})
 }
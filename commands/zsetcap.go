@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidMaxLen = errors.New("ERR maxlen must be a non negative integer")
+)
+
+// ZSETCAP key [maxlen [EVICT MIN | MAX]]
+
+// Caps the number of members of a sorted set, ZADD then drops the lowest (MIN, the default)
+// or the highest (MAX) scored members once the set grows past maxlen. A maxlen of 0 removes the cap.
+// The members evicted by ZADD are counted in the evicted field of the Map reply, sets stored
+// over a capped key by ZUNIONSTORE, ZINTERSTORE or ZDIFFSTORE keep the cap.
+// Integer reply: the number of members evicted to fit the new cap.
+// Without maxlen, Map reply: the current cap of the sorted set.
+
+type ZSetCapCmd struct {
+	Key      string
+	Query    bool
+	MaxLen   int
+	EvictMax bool
+}
+
+func (cmd *ZSetCapCmd) Build(args CmdArgs) (err error) {
+	if len(args) < 1 {
+		return errWrongNumberOfArgs
+	}
+
+	cmd.Key = args[0]
+	if len(args) == 1 {
+		cmd.Query = true
+		return nil
+	}
+
+	cmd.MaxLen, err = strconv.Atoi(args[1])
+	if err != nil || cmd.MaxLen < 0 {
+		return errInvalidMaxLen
+	}
+
+	args = args[2:]
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "evict":
+			if (i + 1) >= len(args) {
+				return errSyntax
+			}
+			i++
+			switch strings.ToLower(args[i]) {
+			case "min":
+				cmd.EvictMax = false
+			case "max":
+				cmd.EvictMax = true
+			default:
+				return errSyntax
+			}
+		default:
+			return errSyntax
+		}
+	}
+
+	return nil
+}
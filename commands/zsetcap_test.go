@@ -0,0 +1,63 @@
+//go:build unit
+
+package commands
+
+import "testing"
+
+func TestZSetCap(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZSetCapCmd
+		WantErr error
+	}{
+		{
+			Name: "Query the cap",
+			Args: []string{"zset1"},
+			Want: ZSetCapCmd{
+				Key:   "zset1",
+				Query: true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Cap evicting the highest scores",
+			Args: []string{"zset1", "100", "EVICT", "max"},
+			Want: ZSetCapCmd{
+				Key:      "zset1",
+				MaxLen:   100,
+				EvictMax: true,
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Negative maxlen",
+			Args:    []string{"zset1", "-1"},
+			WantErr: errInvalidMaxLen,
+		},
+		{
+			Name:    "Evict without side",
+			Args:    []string{"zset1", "100", "evict"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Unknown evict side",
+			Args:    []string{"zset1", "100", "evict", "random"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZSetCapCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil && got != test.Want {
+					t.Errorf("got %+v, want %+v", got, test.Want)
+				}
+			}
+		})
+	}
+}
@@ -2,17 +2,18 @@ package commands
 
 import (
 	"errors"
-	"fmt"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
 )
 
 var (
-	errZUnionNeed2Keys        = errors.New("zunion command need at least 2 keys")
-	errKeysDoesntMatchNumKeys = errors.New("number of keys doesnt match numkeys")
-	errWeightsDoesntMatchKeys = errors.New("number of weights doesnt match number of keys")
-	errUnsupportedAggFunc     = errors.New("unsupported aggregate function")
+	errZUnionNeedKey          = errors.New("ERR at least 1 input key is needed for 'zunion' command")
+	errZUnionStoreNeedKey     = errors.New("ERR at least 1 input key is needed for 'zunionstore' command")
+	errKeysDoesntMatchNumKeys = errors.New("ERR syntax error, numkeys is greater than the number of keys given")
+	errWeightsDoesntMatchKeys = errors.New("ERR syntax error, the number of weights doesn't match numkeys")
+	errWeightNotFloat         = errors.New("ERR weight value is not a float")
 )
 
 type ZUnionCmd struct {
@@ -26,18 +27,18 @@ type ZUnionCmd struct {
 // ZUNION numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE <SUM | MIN | MAX>] [WITHSCORES]
 
 func (cmd *ZUnionCmd) Build(args CmdArgs) (err error) {
-	// at least 3 args, numkeys and 2 keys
-	if len(args) < 3 {
+	// at least 2 args, numkeys and a key
+	if len(args) < 2 {
 		return errWrongNumberOfArgs
 	}
 
-	cmd.NumKeys, err = strconv.Atoi(args[0])
+	cmd.NumKeys, err = parseInt(args[0])
 	if err != nil {
 		return err
 	}
 
-	if cmd.NumKeys < 2 {
-		return errZUnionNeed2Keys
+	if cmd.NumKeys < 1 {
+		return errZUnionNeedKey
 	}
 
 	if (1 + cmd.NumKeys) > len(args) {
@@ -45,43 +46,46 @@ func (cmd *ZUnionCmd) Build(args CmdArgs) (err error) {
 	}
 
 	cmd.Keys = append(cmd.Keys, args[1:(1+cmd.NumKeys)]...)
-	args = args[(1 + cmd.NumKeys):]
-	for i := 0; i < len(args); {
+	cmd.Weights, cmd.Aggregate, cmd.WithScores, err = parseSetOpOptions(args[(1+cmd.NumKeys):], cmd.NumKeys)
+	return err
+}
+
+// parseSetOpOptions parses the options of ZUNION and ZINTER, weights default to 1
+func parseSetOpOptions(args CmdArgs, numKeys int) (weights []float64, aggregate string, withScores bool, err error) {
+	for i := 0; i < len(args); i++ {
 		switch strings.ToLower(args[i]) {
 		case "weights":
-			if i+cmd.NumKeys >= len(args) {
-				return errWeightsDoesntMatchKeys
+			if i+numKeys >= len(args) {
+				return nil, "", false, errWeightsDoesntMatchKeys
 			}
-			for i := i + 1; i <= cmd.NumKeys; i++ {
-				weight, err := strconv.ParseFloat(args[i], 64)
-				if err != nil {
-					return errWrongNumberOfArgs
+			weights = weights[:0]
+			for _, arg := range args[i+1 : i+1+numKeys] {
+				weight, err := strconv.ParseFloat(arg, 64)
+				if err != nil || math.IsNaN(weight) {
+					return nil, "", false, errWeightNotFloat
 				}
-				cmd.Weights = append(cmd.Weights, weight)
+				weights = append(weights, weight)
 			}
+			i += numKeys
 		case "aggregate":
-			i++
-			if i >= len(args) {
-				return errWeightsDoesntMatchKeys
+			if i+1 >= len(args) {
+				return nil, "", false, errSyntax
 			}
-
-			aggFunc := strings.ToLower(args[i])
-			fmt.Println("Agg func is ", aggFunc)
-			if slices.Index([]string{"sum", "min", "max"}, aggFunc) == -1 {
-				return errUnsupportedAggFunc
+			i++
+			aggregate = strings.ToLower(args[i])
+			if !slices.Contains([]string{"sum", "min", "max"}, aggregate) {
+				return nil, "", false, errSyntax
 			}
-
-			cmd.Aggregate = aggFunc
 		case "withscores":
-			cmd.WithScores = true
+			withScores = true
+		default:
+			return nil, "", false, errSyntax
 		}
-
-		i++
 	}
 
-	if len(cmd.Weights) == 0 {
-		cmd.Weights = slices.Repeat([]float64{1}, cmd.NumKeys)
+	if len(weights) == 0 {
+		weights = slices.Repeat([]float64{1}, numKeys)
 	}
 
-	return nil
+	return weights, aggregate, withScores, nil
 }
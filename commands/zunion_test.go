@@ -34,7 +34,7 @@ func TestZUnionCmd(t *testing.T) {
 		},
 		{
 			Name:    "Insufficient number of args",
-			Args:    []string{"2", "zset1"},
+			Args:    []string{"1"},
 			Want:    ZUnionCmd{},
 			WantErr: errWrongNumberOfArgs,
 		},
@@ -44,6 +44,72 @@ func TestZUnionCmd(t *testing.T) {
 			Want:    ZUnionCmd{},
 			WantErr: errKeysDoesntMatchNumKeys,
 		},
+		{
+			Name: "Weights after aggregate",
+			Args: []string{"2", "zset1", "zset2", "aggregate", "MIN", "weights", "2", "3"},
+			Want: ZUnionCmd{
+				NumKeys:   2,
+				Keys:      []string{"zset1", "zset2"},
+				Weights:   []float64{2, 3},
+				Aggregate: "min",
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Default weights",
+			Args: []string{"3", "zset1", "zset2", "zset3"},
+			Want: ZUnionCmd{
+				NumKeys: 3,
+				Keys:    []string{"zset1", "zset2", "zset3"},
+				Weights: []float64{1, 1, 1},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Numkeys is not an integer",
+			Args:    []string{"two", "zset1", "zset2"},
+			WantErr: errNotInteger,
+		},
+		{
+			Name: "Single key",
+			Args: []string{"1", "zset1"},
+			Want: ZUnionCmd{
+				NumKeys: 1,
+				Keys:    []string{"zset1"},
+				Weights: []float64{1},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Numkeys is 0",
+			Args:    []string{"0", "zset1"},
+			WantErr: errZUnionNeedKey,
+		},
+		{
+			Name:    "Weight is not a float",
+			Args:    []string{"2", "zset1", "zset2", "weights", "1", "abc"},
+			WantErr: errWeightNotFloat,
+		},
+		{
+			Name:    "More weights than numkeys",
+			Args:    []string{"2", "zset1", "zset2", "weights", "1", "2", "3"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Unsupported aggregate function",
+			Args:    []string{"2", "zset1", "zset2", "aggregate", "avg"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Aggregate without function",
+			Args:    []string{"2", "zset1", "zset2", "aggregate"},
+			WantErr: errSyntax,
+		},
+		{
+			Name:    "Unknown option",
+			Args:    []string{"2", "zset1", "zset2", "limit"},
+			WantErr: errSyntax,
+		},
 	}
 
 	for _, test := range tests {
@@ -61,6 +127,71 @@ func TestZUnionCmd(t *testing.T) {
 	}
 }
 
+func TestZUnionStoreCmd(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Args    []string
+		Want    ZUnionStoreCmd
+		WantErr error
+	}{
+		{
+			Name: "Store with weights",
+			Args: []string{"dst", "2", "zset1", "zset2", "weights", "1", "2"},
+			Want: ZUnionStoreCmd{
+				DstKey: "dst",
+				ZUnionCmd: ZUnionCmd{
+					NumKeys:    2,
+					Keys:       []string{"zset1", "zset2"},
+					Weights:    []float64{1, 2},
+					WithScores: true,
+				},
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Store a single key",
+			Args: []string{"dst", "1", "zset1"},
+			Want: ZUnionStoreCmd{
+				DstKey: "dst",
+				ZUnionCmd: ZUnionCmd{
+					NumKeys:    1,
+					Keys:       []string{"zset1"},
+					Weights:    []float64{1},
+					WithScores: true,
+				},
+			},
+			WantErr: nil,
+		},
+		{
+			Name:    "Store with numkeys 0",
+			Args:    []string{"dst", "0", "zset1"},
+			WantErr: errZUnionStoreNeedKey,
+		},
+		{
+			Name:    "Store doesn't take withscores",
+			Args:    []string{"dst", "2", "zset1", "zset2", "withscores"},
+			WantErr: errSyntax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := ZUnionStoreCmd{}
+			err := got.Build(test.Args)
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			} else {
+				if test.WantErr == nil {
+					if got.DstKey != test.Want.DstKey {
+						t.Errorf("got dst key %s, want %s", got.DstKey, test.Want.DstKey)
+					}
+					checkZUnionCmd(t, got.ZUnionCmd, test.Want.ZUnionCmd)
+				}
+			}
+		})
+	}
+}
+
 func checkZUnionCmd(t *testing.T, got, want ZUnionCmd) {
 	t.Helper()
 
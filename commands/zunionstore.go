@@ -6,13 +6,23 @@ type ZUnionStoreCmd struct {
 }
 
 func (cmd *ZUnionStoreCmd) Build(args CmdArgs) error {
-	if len(args) < 4 {
+	if len(args) < 3 {
 		return errWrongNumberOfArgs
 	}
 
 	cmd.DstKey = args[0]
 	args = args[1:]
 
+	if err := cmd.ZUnionCmd.Build(args); err == errZUnionNeedKey {
+		return errZUnionStoreNeedKey
+	} else if err != nil {
+		return err
+	}
+	// the destination always gets the scores, WITHSCORES isn't an option here
+	if cmd.ZUnionCmd.WithScores {
+		return errSyntax
+	}
+
 	cmd.ZUnionCmd.WithScores = true
-	return cmd.ZUnionCmd.Build(args)
+	return nil
 }
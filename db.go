@@ -0,0 +1,309 @@
+package zdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+var (
+	ErrNoSuchMember = errors.New("no such member")
+	ErrNoExpire     = errors.New("key has no expire")
+)
+
+// DB is the API for embedding zdb in a Go program, it is safe for concurrent use.
+// Commands lock the shards of their keys, shared with the other reads when they don't
+// modify them. Replies are copied before the locks are released, and the -1 and nil
+// replies of ZDB are reported as errors
+type DB struct {
+	zdb *ZDB
+}
+
+func NewDB(shards uint) *DB {
+	return &DB{zdb: NewZDB(shards)}
+}
+
+func (db *DB) SetMaxMemory(maxMemory int, policy EvictionPolicy) {
+	db.zdb.SetMaxMemory(maxMemory, policy)
+}
+
+func (db *DB) SetBackend(backend Backend) {
+	db.zdb.SetBackend(backend)
+}
+
+func (db *DB) SetListpackLimits(maxEntries, maxValue int) {
+	db.zdb.SetListpackLimits(maxEntries, maxValue)
+}
+
+func (db *DB) UsedMemory() int {
+	return db.zdb.UsedMemory()
+}
+
+// Scan returns the keys of one iteration step and the cursor of the next one, "0" once the
+// iteration is over
+func (db *DB) Scan(ctx context.Context, cmd *commands.ScanCmd) (keys []string, nextCursor string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	keys, nextCursor = db.zdb.Scan(cmd)
+	return keys, nextCursor, nil
+}
+
+// ObjectEncoding returns ErrNoSuchKey when the key doesn't exist
+func (db *DB) ObjectEncoding(ctx context.Context, cmd *commands.ObjectCmd) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return db.zdb.ObjectEncoding(cmd)
+}
+
+// Expire returns ErrNoSuchKey when the key doesn't exist
+func (db *DB) Expire(ctx context.Context, cmd *commands.ExpireCmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if db.zdb.Expire(cmd) == 0 {
+		return ErrNoSuchKey
+	}
+	return nil
+}
+
+// TTL returns ErrNoSuchKey when the key doesn't exist and ErrNoExpire when it never expires
+func (db *DB) TTL(ctx context.Context, cmd *commands.TTLCmd) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := db.zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return 0, err
+	}
+	if entry.expireAt == 0 {
+		return 0, ErrNoExpire
+	}
+
+	return time.Until(time.UnixMilli(entry.expireAt)), nil
+}
+
+// Persist returns ErrNoSuchKey when the key doesn't exist and ErrNoExpire when it never expires
+func (db *DB) Persist(ctx context.Context, cmd *commands.PersistCmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	unlock := db.zdb.shards.lock(cmd.Key)
+	defer unlock()
+
+	entry := db.zdb.shards.getEntry(cmd.Key)
+	if entry == nil {
+		return ErrNoSuchKey
+	}
+	if entry.expireAt == 0 {
+		return ErrNoExpire
+	}
+
+	entry.expireAt = 0
+	return nil
+}
+
+// ZAdd returns ErrOOM when maxmemory is reached and no key can be evicted
+func (db *DB) ZAdd(ctx context.Context, cmd *commands.ZADDCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := db.zdb.FreeMemoryIfNeeded(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZAdd(cmd), nil
+}
+
+func (db *DB) ZCard(ctx context.Context, cmd *commands.ZCardCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZCard(cmd), nil
+}
+
+func (db *DB) ZCount(ctx context.Context, cmd *commands.ZCountCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZCount(cmd), nil
+}
+
+func (db *DB) ZDiff(ctx context.Context, cmd *commands.ZDiffCmd) ([]commands.ZMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
+
+	return treeMembers(db.zdb.zdiff(cmd)), nil
+}
+
+func (db *DB) ZDiffStore(ctx context.Context, cmd *commands.ZDiffStoreCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := db.zdb.FreeMemoryIfNeeded(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZDiffStore(cmd), nil
+}
+
+func (db *DB) ZInter(ctx context.Context, cmd *commands.ZInterCmd) ([]commands.ZMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
+
+	return treeMembers(db.zdb.zinter(cmd)), nil
+}
+
+func (db *DB) ZInterStore(ctx context.Context, cmd *commands.ZInterStoreCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := db.zdb.FreeMemoryIfNeeded(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZInterStore(cmd), nil
+}
+
+func (db *DB) ZUnion(ctx context.Context, cmd *commands.ZUnionCmd) ([]commands.ZMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
+
+	return treeMembers(db.zdb.zunion(cmd)), nil
+}
+
+func (db *DB) ZUnionStore(ctx context.Context, cmd *commands.ZUnionStoreCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := db.zdb.FreeMemoryIfNeeded(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZUnionStore(cmd), nil
+}
+
+func (db *DB) ZRange(ctx context.Context, cmd *commands.ZRangeCmd) ([]commands.ZMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return nodeMembers(db.zdb.ZRange(cmd)), nil
+}
+
+// ZRank returns ErrNoSuchKey when the key doesn't exist and ErrNoSuchMember when the member doesn't
+func (db *DB) ZRank(ctx context.Context, cmd *commands.ZRankCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := db.zdb.shards.readTree(cmd.Key)
+	if tree == nil {
+		return 0, ErrNoSuchKey
+	}
+
+	rank := tree.Rank(cmd.Member)
+	if rank < 0 {
+		return 0, ErrNoSuchMember
+	}
+	return rank, nil
+}
+
+func (db *DB) ZRem(ctx context.Context, cmd *commands.ZRemCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZRem(cmd), nil
+}
+
+// ZScan returns the members of one iteration step and the cursor of the next one
+func (db *DB) ZScan(ctx context.Context, cmd *commands.ZScanCmd) (members []commands.ZMember, nextCursor string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nodes, nextCursor := db.zdb.ZScan(cmd)
+	return nodeMembers(nodes), nextCursor, nil
+}
+
+// ZScore returns ErrNoSuchKey when the key doesn't exist and ErrNoSuchMember when the member doesn't
+func (db *DB) ZScore(ctx context.Context, cmd *commands.ZScoreCmd) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	unlock := db.zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := db.zdb.shards.readTree(cmd.Key)
+	if tree == nil {
+		return 0, ErrNoSuchKey
+	}
+
+	score, err := tree.GetScore(cmd.Member)
+	if err != nil {
+		return 0, ErrNoSuchMember
+	}
+	return score, nil
+}
+
+// ZSetCap returns the number of members evicted to fit the new cap
+func (db *DB) ZSetCap(ctx context.Context, cmd *commands.ZSetCapCmd) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return db.zdb.ZSetCap(cmd)
+}
+
+func (db *DB) ZSetCapInfo(ctx context.Context, cmd *commands.ZSetCapCmd) (ZSetCapInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return ZSetCapInfo{}, err
+	}
+
+	return db.zdb.ZSetCapInfo(cmd)
+}
+
+// treeMembers copies the members of tree before the keys are unlocked
+func treeMembers(tree OrderStatisticTree) []commands.ZMember {
+	if tree == nil || tree.IsEmpty() {
+		return []commands.ZMember{}
+	}
+
+	return nodeMembers(tree.RangeByIndex(0, tree.Len()-1))
+}
+
+func nodeMembers(nodes []Node) []commands.ZMember {
+	return Map(nodes, func(n Node) commands.ZMember {
+		return commands.ZMember{Key: n.Key(), Score: n.Score()}
+	})
+}
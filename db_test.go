@@ -0,0 +1,280 @@
+//go:build unit
+
+package zdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+func TestDBErrors(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(4)
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "zset", Members: []commands.ZMember{{Key: "A", Score: 1}}})
+
+	tests := []struct {
+		Name    string
+		Exec    func() error
+		WantErr error
+	}{
+		{
+			Name: "ZScore of missing key",
+			Exec: func() error {
+				_, err := db.ZScore(ctx, &commands.ZScoreCmd{Key: "missing", Member: "A"})
+				return err
+			},
+			WantErr: ErrNoSuchKey,
+		},
+		{
+			Name: "ZScore of missing member",
+			Exec: func() error {
+				_, err := db.ZScore(ctx, &commands.ZScoreCmd{Key: "zset", Member: "B"})
+				return err
+			},
+			WantErr: ErrNoSuchMember,
+		},
+		{
+			Name: "ZRank of missing key",
+			Exec: func() error {
+				_, err := db.ZRank(ctx, &commands.ZRankCmd{Key: "missing", Member: "A"})
+				return err
+			},
+			WantErr: ErrNoSuchKey,
+		},
+		{
+			Name: "ZRank of missing member",
+			Exec: func() error {
+				_, err := db.ZRank(ctx, &commands.ZRankCmd{Key: "zset", Member: "B"})
+				return err
+			},
+			WantErr: ErrNoSuchMember,
+		},
+		{
+			Name: "TTL of missing key",
+			Exec: func() error {
+				_, err := db.TTL(ctx, &commands.TTLCmd{Key: "missing"})
+				return err
+			},
+			WantErr: ErrNoSuchKey,
+		},
+		{
+			Name: "TTL of persistent key",
+			Exec: func() error {
+				_, err := db.TTL(ctx, &commands.TTLCmd{Key: "zset"})
+				return err
+			},
+			WantErr: ErrNoExpire,
+		},
+		{
+			Name: "Persist of persistent key",
+			Exec: func() error {
+				return db.Persist(ctx, &commands.PersistCmd{Key: "zset"})
+			},
+			WantErr: ErrNoExpire,
+		},
+		{
+			Name: "Expire of missing key",
+			Exec: func() error {
+				return db.Expire(ctx, &commands.ExpireCmd{Key: "missing", Seconds: 10})
+			},
+			WantErr: ErrNoSuchKey,
+		},
+		{
+			Name: "ZUnionStore of missing keys",
+			Exec: func() error {
+				_, err := db.ZUnionStore(ctx, &commands.ZUnionStoreCmd{DstKey: "dst", ZUnionCmd: commands.ZUnionCmd{Keys: []string{"missing", "other"}, Weights: []float64{1, 1}}})
+				return err
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "Canceled context",
+			Exec: func() error {
+				canceled, cancel := context.WithCancel(ctx)
+				cancel()
+				_, err := db.ZAdd(canceled, &commands.ZADDCmd{Key: "zset", Members: []commands.ZMember{{Key: "B", Score: 2}}})
+				return err
+			},
+			WantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if err := test.Exec(); !errors.Is(err, test.WantErr) {
+				t.Errorf("got err %v, want %v", err, test.WantErr)
+			}
+		})
+	}
+}
+
+func TestDBSetOpCopiesMembers(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(4)
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "zset", Members: []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}}})
+
+	got, err := db.ZUnion(ctx, &commands.ZUnionCmd{Keys: []string{"zset"}, Weights: []float64{1}})
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	db.ZRem(ctx, &commands.ZRemCmd{Key: "zset", Members: []string{"A"}})
+
+	want := []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// run with -race, the keys overlap so readers and writers share shards
+func TestDBConcurrent(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(4)
+	keys := []string{"a", "b", "c"}
+
+	wg := sync.WaitGroup{}
+	for i := range 6 {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := range 300 {
+				member := commands.ZMember{Key: fmt.Sprintf("%d-%d", i, j), Score: float64(j)}
+				if _, err := db.ZAdd(ctx, &commands.ZADDCmd{Key: keys[j%3], Members: []commands.ZMember{member}}); err != nil {
+					t.Errorf("ZAdd got err %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := range 300 {
+				members, err := db.ZRange(ctx, &commands.ZRangeCmd{Key: keys[j%3], ByIndex: true, StopIndex: -1, WithScores: true})
+				if err != nil {
+					t.Errorf("ZRange got err %v", err)
+					return
+				}
+				if !slices.IsSortedFunc(members, func(x, y commands.ZMember) int {
+					return compareNode(NewNode(x.Key, x.Score), NewNode(y.Key, y.Score))
+				}) {
+					t.Errorf("ZRange got unsorted members %v", members)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			dst := fmt.Sprintf("dst%d", i)
+			for j := range 300 {
+				cmd := &commands.ZUnionStoreCmd{DstKey: dst, ZUnionCmd: commands.ZUnionCmd{Keys: []string{keys[j%3], keys[(j+1)%3]}, Weights: []float64{1, 1}}}
+				if _, err := db.ZUnionStore(ctx, cmd); err != nil {
+					t.Errorf("ZUnionStore got err %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, key := range keys {
+		card, _ := db.ZCard(ctx, &commands.ZCardCmd{Key: key})
+		total += card
+	}
+	if total != 6*300 {
+		t.Errorf("got %d members, want %d", total, 6*300)
+	}
+}
+
+func TestDBSetOps(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(4)
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "a", Members: []commands.ZMember{{Key: "x", Score: 1}, {Key: "y", Score: 2}}})
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "b", Members: []commands.ZMember{{Key: "y", Score: 3}, {Key: "z", Score: 4}}})
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "c", Members: []commands.ZMember{{Key: "y", Score: 5}, {Key: "x", Score: 6}}})
+
+	tests := []struct {
+		Name string
+		Exec func() ([]commands.ZMember, error)
+		Want []commands.ZMember
+	}{
+		{
+			Name: "ZUnion weights every key",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZUnion(ctx, &commands.ZUnionCmd{Keys: []string{"a", "b", "c"}, Weights: []float64{1, 10, 100}})
+			},
+			Want: []commands.ZMember{{Key: "z", Score: 40}, {Key: "y", Score: 532}, {Key: "x", Score: 601}},
+		},
+		{
+			Name: "ZUnion max across keys",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZUnion(ctx, &commands.ZUnionCmd{Keys: []string{"a", "b", "c"}, Weights: []float64{1, 1, 1}, Aggregate: "max"})
+			},
+			Want: []commands.ZMember{{Key: "z", Score: 4}, {Key: "y", Score: 5}, {Key: "x", Score: 6}},
+		},
+		{
+			Name: "ZUnion skips missing keys",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZUnion(ctx, &commands.ZUnionCmd{Keys: []string{"missing", "b"}, Weights: []float64{1, 2}})
+			},
+			Want: []commands.ZMember{{Key: "y", Score: 6}, {Key: "z", Score: 8}},
+		},
+		{
+			Name: "ZInter min across keys",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZInter(ctx, &commands.ZInterCmd{Keys: []string{"a", "b", "c"}, Weights: []float64{1, 1, 1}, Aggregate: "min"})
+			},
+			Want: []commands.ZMember{{Key: "y", Score: 2}},
+		},
+		{
+			Name: "ZInter with a missing key is empty",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZInter(ctx, &commands.ZInterCmd{Keys: []string{"a", "missing"}, Weights: []float64{1, 1}})
+			},
+			Want: []commands.ZMember{},
+		},
+		{
+			Name: "ZDiff skips missing keys after the first",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZDiff(ctx, &commands.ZDiffCmd{Keys: []string{"a", "missing", "b"}})
+			},
+			Want: []commands.ZMember{{Key: "x", Score: 1}},
+		},
+		{
+			Name: "ZDiff of a missing first key is empty",
+			Exec: func() ([]commands.ZMember, error) {
+				return db.ZDiff(ctx, &commands.ZDiffCmd{Keys: []string{"missing", "a"}})
+			},
+			Want: []commands.ZMember{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Exec()
+			if err != nil {
+				t.Fatalf("got err %v, want nil", err)
+			}
+			if !slices.Equal(got, test.Want) {
+				t.Errorf("got %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestDBStoreDoesntShareSource(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB(4)
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "src", Members: []commands.ZMember{{Key: "A", Score: 1}}})
+
+	db.ZUnionStore(ctx, &commands.ZUnionStoreCmd{DstKey: "dst", ZUnionCmd: commands.ZUnionCmd{Keys: []string{"src"}, Weights: []float64{1}}})
+	db.ZAdd(ctx, &commands.ZADDCmd{Key: "src", Members: []commands.ZMember{{Key: "B", Score: 2}}})
+
+	if card, _ := db.ZCard(ctx, &commands.ZCardCmd{Key: "dst"}); card != 1 {
+		t.Errorf("got dst card %d after adding to src, want 1", card)
+	}
+}
@@ -8,15 +8,14 @@ import (
 )
 
 func SerializeTree(writer *miniresp3.Writer, tree zdb.OrderStatisticTree, withScores bool) {
-	if tree == nil || tree.Root() == nil {
+	if tree == nil || tree.IsEmpty() {
 		writer.AppendArrHeader(0)
 		return
 	}
 
-	it := zdb.NewTreeIterator(tree)
-	it.Seek(nil)
+	it := tree.Iterator(0)
 
-	arrLength := tree.Root().Count()
+	arrLength := tree.Len()
 	if withScores {
 		arrLength *= 2
 	}
@@ -0,0 +1,162 @@
+package zdb
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+var (
+	ErrOOM                   = errors.New("OOM command not allowed when used memory > 'maxmemory'.")
+	errUnknownEvictionPolicy = errors.New("unknown eviction policy")
+)
+
+const (
+	maxMemorySamples     = 5
+	maxEvictionsPerWrite = 1000
+)
+
+type EvictionPolicy string
+
+const (
+	NoEviction    EvictionPolicy = "noeviction"
+	AllKeysLRU    EvictionPolicy = "allkeys-lru"
+	AllKeysLFU    EvictionPolicy = "allkeys-lfu"
+	VolatileTTL   EvictionPolicy = "volatile-ttl"
+	AllKeysRandom EvictionPolicy = "allkeys-random"
+)
+
+func ParseEvictionPolicy(policy string) (EvictionPolicy, error) {
+	switch p := EvictionPolicy(policy); p {
+	case NoEviction, AllKeysLRU, AllKeysLFU, VolatileTTL, AllKeysRandom:
+		return p, nil
+	}
+
+	return "", errUnknownEvictionPolicy
+}
+
+// SetMaxMemory limits the memory used by keys to maxMemory bytes, zero means no limit
+func (zdb *ZDB) SetMaxMemory(maxMemory int, policy EvictionPolicy) {
+	zdb.maxMemory = maxMemory
+	zdb.evictionPolicy = policy
+}
+
+func (zdb *ZDB) UsedMemory() int {
+	return zdb.shards.UsedMemory()
+}
+
+// FreeMemoryIfNeeded evicts keys according to the eviction policy until the
+// used memory is back under maxmemory. It has to be called before every write,
+// ErrOOM means the write must be refused
+func (zdb *ZDB) FreeMemoryIfNeeded() error {
+	if zdb.maxMemory <= 0 || zdb.shards.UsedMemory() <= zdb.maxMemory {
+		return nil
+	}
+
+	if zdb.evictionPolicy == NoEviction {
+		return ErrOOM
+	}
+
+	zdb.evictMu.Lock()
+	defer zdb.evictMu.Unlock()
+	for range maxEvictionsPerWrite {
+		if zdb.shards.UsedMemory() <= zdb.maxMemory {
+			return nil
+		}
+
+		victim, found := zdb.shards.evictionCandidate(zdb.evictionPolicy)
+		if !found {
+			return ErrOOM
+		}
+		unlock := zdb.shards.lock(victim)
+		zdb.shards.RemoveDB(victim)
+		unlock()
+	}
+
+	if zdb.shards.UsedMemory() > zdb.maxMemory {
+		return ErrOOM
+	}
+
+	return nil
+}
+
+// evictionSample is the part of a keyEntry the policies compare, it is copied under the
+// map lock since the maps are unlocked between samples
+type evictionSample struct {
+	lru      int64
+	lfu      uint8
+	expireAt int64
+}
+
+func (e *keyEntry) sample() evictionSample {
+	return evictionSample{lru: e.lru.Load(), lfu: uint8(e.lfu.Load()), expireAt: e.expireAt}
+}
+
+// evictionCandidate samples a few keys and returns the best one to evict
+func (s *Shard) evictionCandidate(policy EvictionPolicy) (key string, found bool) {
+	now := time.Now()
+	var best *evictionSample
+	for range maxMemorySamples {
+		candidate, sample, ok := s.randomEntry()
+		if !ok {
+			break
+		}
+
+		if policy == AllKeysRandom {
+			return candidate, true
+		}
+
+		if policy == VolatileTTL && sample.expireAt == 0 {
+			continue
+		}
+
+		if best == nil || evictsBefore(policy, sample, *best, now) {
+			key, best = candidate, &sample
+		}
+	}
+
+	if best == nil && policy == VolatileTTL {
+		// sampling can miss the few volatile keys, fall back to a full search
+		for i, db := range s.DB {
+			s.locks[i].RLock()
+			for candidate, entry := range db {
+				if sample := entry.sample(); sample.expireAt != 0 && (best == nil || evictsBefore(policy, sample, *best, now)) {
+					key, best = candidate, &sample
+				}
+			}
+			s.locks[i].RUnlock()
+		}
+	}
+
+	return key, best != nil
+}
+
+func evictsBefore(policy EvictionPolicy, x, y evictionSample, now time.Time) bool {
+	switch policy {
+	case AllKeysLRU:
+		return x.lru < y.lru
+	case AllKeysLFU:
+		return lfuDecr(x.lfu, x.lru, now) < lfuDecr(y.lfu, y.lru, now)
+	case VolatileTTL:
+		return x.expireAt < y.expireAt
+	}
+
+	return false
+}
+
+func (s *Shard) randomEntry() (key string, sample evictionSample, found bool) {
+	start := rand.IntN(len(s.DB))
+	for i := range s.DB {
+		idx := (start + i) % len(s.DB)
+		s.locks[idx].RLock()
+		// map iteration starts at a random position
+		for key, entry := range s.DB[idx] {
+			sample := entry.sample()
+			s.locks[idx].RUnlock()
+			return key, sample, true
+		}
+		s.locks[idx].RUnlock()
+	}
+
+	return "", evictionSample{}, false
+}
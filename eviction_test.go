@@ -0,0 +1,108 @@
+//go:build unit
+
+package zdb
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+func TestFreeMemoryIfNeeded(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Policy        EvictionPolicy
+		VolatileKeys  []string
+		WantErr       error
+		WantRemaining []string
+	}{
+		{
+			Name:          "noeviction refuses writes",
+			Policy:        NoEviction,
+			WantErr:       ErrOOM,
+			WantRemaining: []string{"zset0", "zset1", "zset2", "zset3"},
+		},
+		{
+			Name:    "allkeys-random frees memory",
+			Policy:  AllKeysRandom,
+			WantErr: nil,
+		},
+		{
+			Name:          "volatile-ttl only evicts keys with an expire",
+			Policy:        VolatileTTL,
+			VolatileKeys:  []string{"zset1", "zset2"},
+			WantErr:       ErrOOM,
+			WantRemaining: []string{"zset0", "zset3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			db := NewZDB(4)
+			for i := range 4 {
+				key := "zset" + strconv.Itoa(i)
+				db.ZAdd(&commands.ZADDCmd{Key: key, Members: []commands.ZMember{{Key: "member", Score: 1}}})
+			}
+			for _, key := range test.VolatileKeys {
+				db.Expire(&commands.ExpireCmd{Key: key, Seconds: 100})
+			}
+
+			db.SetMaxMemory(db.UsedMemory()/3, test.Policy)
+			err := db.FreeMemoryIfNeeded()
+			if err != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
+			}
+
+			if test.WantErr == nil && db.UsedMemory() > db.maxMemory {
+				t.Errorf("got used memory %d, want at most %d", db.UsedMemory(), db.maxMemory)
+			}
+
+			for _, key := range test.WantRemaining {
+				if db.ZCard(&commands.ZCardCmd{Key: key}) != 1 {
+					t.Errorf("want key %s to be kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestUsedMemory(t *testing.T) {
+	db := NewZDB(4)
+	db.ZAdd(&commands.ZADDCmd{Key: "zset", Members: []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}}})
+	withTwoMembers := db.UsedMemory()
+
+	db.ZRem(&commands.ZRemCmd{Key: "zset", Members: []string{"A"}})
+	if db.UsedMemory() >= withTwoMembers {
+		t.Errorf("got used memory %d, want less than %d", db.UsedMemory(), withTwoMembers)
+	}
+
+	db.ZRem(&commands.ZRemCmd{Key: "zset", Members: []string{"B"}})
+	if db.UsedMemory() != 0 {
+		t.Errorf("got used memory %d, want 0", db.UsedMemory())
+	}
+}
+
+func TestExpire(t *testing.T) {
+	db := NewZDB(4)
+	db.ZAdd(&commands.ZADDCmd{Key: "zset", Members: []commands.ZMember{{Key: "A", Score: 1}}})
+
+	if got := db.TTL(&commands.TTLCmd{Key: "zset"}); got != -1 {
+		t.Errorf("got ttl %d, want -1", got)
+	}
+
+	db.Expire(&commands.ExpireCmd{Key: "zset", Seconds: 10})
+	if got := db.TTL(&commands.TTLCmd{Key: "zset"}); got != 10 {
+		t.Errorf("got ttl %d, want 10", got)
+	}
+
+	db.Persist(&commands.PersistCmd{Key: "zset"})
+	if got := db.TTL(&commands.TTLCmd{Key: "zset"}); got != -1 {
+		t.Errorf("got ttl %d, want -1", got)
+	}
+
+	db.Expire(&commands.ExpireCmd{Key: "zset", Seconds: 0})
+	if got := db.TTL(&commands.TTLCmd{Key: "zset"}); got != -2 {
+		t.Errorf("got ttl %d, want -2", got)
+	}
+}
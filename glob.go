@@ -0,0 +1,90 @@
+package zdb
+
+// matchGlob reports whether str matches a Redis glob pattern, * matches any sequence,
+// ? any single byte, [abc], [a-z] and [^abc] a byte in or not in the set, \ escapes
+// the next byte
+func matchGlob(pattern, str string) bool {
+	// a mismatch retries the pattern after the last * with it swallowing one more byte,
+	// a later * makes backtracking into the earlier ones useless so the match stays
+	// O(len(pattern) * len(str)) instead of growing with the number of stars
+	star, starPattern, starStr := false, "", ""
+	for len(pattern) > 0 || len(str) > 0 {
+		if len(pattern) > 0 {
+			switch pattern[0] {
+			case '*':
+				for len(pattern) > 1 && pattern[1] == '*' {
+					pattern = pattern[1:]
+				}
+				if len(pattern) == 1 {
+					return true
+				}
+				pattern = pattern[1:]
+				star, starPattern, starStr = true, pattern, str
+				continue
+			case '?':
+				if len(str) > 0 {
+					pattern, str = pattern[1:], str[1:]
+					continue
+				}
+			case '[':
+				if len(str) > 0 {
+					if matched, rest := matchClass(pattern[1:], str[0]); matched {
+						pattern, str = rest, str[1:]
+						continue
+					}
+				}
+			default:
+				literal := pattern
+				if literal[0] == '\\' && len(literal) > 1 {
+					literal = literal[1:]
+				}
+				if len(str) > 0 && literal[0] == str[0] {
+					pattern, str = literal[1:], str[1:]
+					continue
+				}
+			}
+		}
+
+		if !star || len(starStr) == 0 {
+			return false
+		}
+		starStr = starStr[1:]
+		pattern, str = starPattern, starStr
+	}
+
+	return true
+}
+
+// matchClass matches c against the set at the start of pattern, right after the [,
+// and returns the pattern following the closing ]
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (lo <= c && c <= hi)
+			pattern = pattern[3:]
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	// an unterminated set is closed at the end of the pattern
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+
+	return matched != negate, pattern
+}
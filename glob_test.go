@@ -0,0 +1,71 @@
+//go:build unit
+
+package zdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Pattern string
+		Str     string
+		Want    bool
+	}{
+		{Name: "Empty pattern and string", Pattern: "", Str: "", Want: true},
+		{Name: "Literal", Pattern: "user:1", Str: "user:1", Want: true},
+		{Name: "Literal mismatch", Pattern: "user:1", Str: "user:10", Want: false},
+		{Name: "Star matches everything", Pattern: "*", Str: "anything", Want: true},
+		{Name: "Star matches empty", Pattern: "user:*", Str: "user:", Want: true},
+		{Name: "Star in the middle", Pattern: "h*llo", Str: "heeeello", Want: true},
+		{Name: "Consecutive stars", Pattern: "a**b", Str: "axxb", Want: true},
+		{Name: "Star with mismatched suffix", Pattern: "*:score", Str: "user:name", Want: false},
+		{Name: "Question mark", Pattern: "h?llo", Str: "hallo", Want: true},
+		{Name: "Question mark needs a byte", Pattern: "h?llo", Str: "hllo", Want: false},
+		{Name: "Set", Pattern: "h[ae]llo", Str: "hello", Want: true},
+		{Name: "Set mismatch", Pattern: "h[ae]llo", Str: "hillo", Want: false},
+		{Name: "Negated set", Pattern: "h[^e]llo", Str: "hallo", Want: true},
+		{Name: "Negated set mismatch", Pattern: "h[^e]llo", Str: "hello", Want: false},
+		{Name: "Range", Pattern: "key[0-9]", Str: "key7", Want: true},
+		{Name: "Reversed range", Pattern: "key[9-0]", Str: "key7", Want: true},
+		{Name: "Range mismatch", Pattern: "key[0-9]", Str: "keya", Want: false},
+		{Name: "Escaped star", Pattern: `a\*b`, Str: "a*b", Want: true},
+		{Name: "Escaped star is literal", Pattern: `a\*b`, Str: "axxb", Want: false},
+		{Name: "Escaped bracket in set", Pattern: `[\]]`, Str: "]", Want: true},
+		{Name: "Unterminated set", Pattern: "a[bc", Str: "ab", Want: true},
+		{Name: "Trailing backslash", Pattern: `a\`, Str: `a\`, Want: true},
+		{Name: "Star retries after a partial match", Pattern: "*ab", Str: "aab", Want: true},
+		{Name: "Later star", Pattern: "a*b*c", Str: "abxbyc", Want: true},
+		{Name: "Later star mismatch", Pattern: "a*b*c", Str: "abxbyd", Want: false},
+		{Name: "Star before a set", Pattern: "*[0-9]x", Str: "k1y2x", Want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := matchGlob(test.Pattern, test.Str); got != test.Want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", test.Pattern, test.Str, got, test.Want)
+			}
+		})
+	}
+}
+
+func TestMatchGlobBacktracking(t *testing.T) {
+	pattern, str := strings.Repeat("*a", 12)+"b", strings.Repeat("a", 40)
+
+	done := make(chan bool, 1)
+	go func() {
+		done <- matchGlob(pattern, str)
+	}()
+
+	select {
+	case got := <-done:
+		if got {
+			t.Errorf("matchGlob(%q, %q) = true, want false", pattern, str)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("matchGlob(%q, %q) still running after a second", pattern, str)
+	}
+}
@@ -5,10 +5,11 @@ go 1.24.1
 require (
 	github.com/pkg/errors v0.9.1
 	github.com/rs/zerolog v1.34.0
+	golang.org/x/term v0.40.0
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	golang.org/x/sys v0.12.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
 )
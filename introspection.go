@@ -0,0 +1,111 @@
+package zdb
+
+import (
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+type ZSetDebugInfo struct {
+	Height      int
+	Count       int
+	HashMapSize int
+	Encoding    string
+	MaxLen      int
+}
+
+// peekLiveEntry returns the entry of a non expired key without counting it as an access
+func (zdb *ZDB) peekLiveEntry(key string) (*keyEntry, error) {
+	entry := zdb.shards.peekEntry(key)
+	if entry == nil || entry.isExpired(time.Now()) {
+		return nil, ErrNoSuchKey
+	}
+
+	return entry, nil
+}
+
+func (zdb *ZDB) MemoryUsage(cmd *commands.MemoryCmd) (int, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	return keyMemoryUsage(cmd.Key, entry.tree), nil
+}
+
+func (zdb *ZDB) ObjectEncoding(cmd *commands.ObjectCmd) (string, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.tree.Encoding(), nil
+}
+
+func (zdb *ZDB) ObjectFreq(cmd *commands.ObjectCmd) (int, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(entry.lfuDecr(time.Now())), nil
+}
+
+func (zdb *ZDB) ObjectIdleTime(cmd *commands.ObjectCmd) (int, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(time.Since(time.Unix(0, entry.lru.Load())).Seconds()), nil
+}
+
+func (zdb *ZDB) DebugZSetInfo(cmd *commands.DebugCmd) (ZSetDebugInfo, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return ZSetDebugInfo{}, err
+	}
+
+	info := ZSetDebugInfo{
+		Count:    entry.tree.Len(),
+		Encoding: entry.tree.Encoding(),
+		MaxLen:   entry.capacity,
+	}
+	switch tree := unwrapTree(entry.tree).(type) {
+	case *Tree:
+		info.Height = tree.Height()
+		info.HashMapSize = tree.members.size()
+	case *SkipList:
+		info.Height = tree.Level()
+		info.HashMapSize = tree.members.size()
+	}
+
+	return info, nil
+}
+
+func (zdb *ZDB) DebugZSetCheck(cmd *commands.DebugCmd) error {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return err
+	}
+
+	return entry.tree.Validate()
+}
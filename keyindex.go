@@ -0,0 +1,97 @@
+package zdb
+
+import (
+	"math/bits"
+	"slices"
+)
+
+const keyIndexMinBuckets = 4
+
+// keyIndex is a chained hash table of every key scanned with the reverse binary cursor of
+// Redis. The cursor increments its high bits first, so a bucket split by a grow or merged
+// by a shrink is never visited past, and a full iteration returns every key present from
+// its start to its end, some maybe more than once
+type keyIndex struct {
+	buckets [][]string
+	count   int
+	hash    fnv64a
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{
+		buckets: make([][]string, keyIndexMinBuckets),
+		hash:    fnv64a{},
+	}
+}
+
+func (idx *keyIndex) mask() uint64 {
+	return uint64(len(idx.buckets) - 1)
+}
+
+func (idx *keyIndex) Len() int {
+	return idx.count
+}
+
+func (idx *keyIndex) Add(key string) {
+	b := idx.hash.Sum64(key) & idx.mask()
+	if slices.Contains(idx.buckets[b], key) {
+		return
+	}
+
+	idx.buckets[b] = append(idx.buckets[b], key)
+	idx.count++
+	if idx.count > len(idx.buckets) {
+		idx.resize(len(idx.buckets) * 2)
+	}
+}
+
+func (idx *keyIndex) Remove(key string) {
+	b := idx.hash.Sum64(key) & idx.mask()
+	i := slices.Index(idx.buckets[b], key)
+	if i < 0 {
+		return
+	}
+
+	idx.buckets[b] = slices.Delete(idx.buckets[b], i, i+1)
+	idx.count--
+	if len(idx.buckets) > keyIndexMinBuckets && idx.count < len(idx.buckets)/8 {
+		idx.resize(len(idx.buckets) / 2)
+	}
+}
+
+func (idx *keyIndex) resize(size int) {
+	buckets := make([][]string, size)
+	mask := uint64(size - 1)
+	for _, bucket := range idx.buckets {
+		for _, key := range bucket {
+			b := idx.hash.Sum64(key) & mask
+			buckets[b] = append(buckets[b], key)
+		}
+	}
+	idx.buckets = buckets
+}
+
+// Scan visits whole buckets from cursor until count keys were visited, or count*10 buckets
+// so a sparse table still returns quickly, and returns the cursor to continue from, 0 once
+// every bucket was visited
+func (idx *keyIndex) Scan(cursor uint64, count int, visit func(key string)) (nextCursor uint64) {
+	mask := idx.mask()
+	visited := 0
+	for maxIterations := max(count, 1) * 10; maxIterations > 0; maxIterations-- {
+		bucket := idx.buckets[cursor&mask]
+		for _, key := range bucket {
+			visit(key)
+		}
+		visited += len(bucket)
+
+		// with the bits above the mask set, incrementing the reversed cursor carries
+		// into the bits the mask keeps
+		cursor |= ^mask
+		cursor = bits.Reverse64(bits.Reverse64(cursor) + 1)
+		if cursor == 0 || visited >= count {
+			break
+		}
+	}
+
+	return cursor
+}
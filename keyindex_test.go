@@ -0,0 +1,101 @@
+//go:build unit
+
+package zdb
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKeyIndexScan(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Keys  int
+		Count int
+		// called after every page
+		Mutate func(idx *keyIndex, page int)
+	}{
+		{
+			Name:  "Stable table",
+			Keys:  100,
+			Count: 7,
+		},
+		{
+			Name:  "Table grows during the iteration",
+			Keys:  50,
+			Count: 5,
+			Mutate: func(idx *keyIndex, page int) {
+				for i := range 20 {
+					idx.Add("new:" + strconv.Itoa(page*20+i))
+				}
+			},
+		},
+		{
+			Name:  "Table shrinks during the iteration",
+			Keys:  200,
+			Count: 5,
+			Mutate: func(idx *keyIndex, page int) {
+				for i := range 20 {
+					idx.Remove("extra:" + strconv.Itoa(page*20+i))
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			idx := newKeyIndex()
+			for i := range test.Keys {
+				idx.Add("key:" + strconv.Itoa(i))
+				idx.Add("extra:" + strconv.Itoa(i))
+			}
+
+			seen := map[string]bool{}
+			cursor := uint64(0)
+			for page := 0; ; page++ {
+				if page > 10*test.Keys {
+					t.Fatalf("scan didn't finish after %d pages", page)
+				}
+
+				cursor = idx.Scan(cursor, test.Count, func(key string) {
+					seen[key] = true
+				})
+				if cursor == 0 {
+					break
+				}
+				if test.Mutate != nil {
+					test.Mutate(idx, page)
+				}
+			}
+
+			for i := range test.Keys {
+				if key := "key:" + strconv.Itoa(i); !seen[key] {
+					t.Errorf("scan missed %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyIndexAddRemove(t *testing.T) {
+	idx := newKeyIndex()
+	for i := range 1000 {
+		idx.Add(strconv.Itoa(i))
+	}
+	// adding a key twice doesn't index it twice
+	idx.Add("0")
+	if idx.Len() != 1000 {
+		t.Errorf("got len %d, want 1000", idx.Len())
+	}
+
+	for i := range 990 {
+		idx.Remove(strconv.Itoa(i))
+	}
+	idx.Remove("missing")
+	if idx.Len() != 10 {
+		t.Errorf("got len %d, want 10", idx.Len())
+	}
+	if len(idx.buckets) > 10*8 {
+		t.Errorf("got %d buckets after removing most keys, want the table to shrink", len(idx.buckets))
+	}
+}
@@ -0,0 +1,351 @@
+package zdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Listpack packs a small set in a single byte slice, every entry is the score bits,
+// the uvarint length of the member and the member, sorted by score then member.
+// Every operation walks the entries, which for a few dozen members is cheaper than
+// hashing and saves the node and map entry per member of the other backends
+type Listpack struct {
+	data   []byte
+	length int
+}
+
+func NewListpack() OrderStatisticTree {
+	return &Listpack{}
+}
+
+// decode returns the entry at offset off and the offset of the next one
+func (lp *Listpack) decode(off int) (key []byte, score float64, next int) {
+	score = math.Float64frombits(binary.LittleEndian.Uint64(lp.data[off:]))
+	n, size := binary.Uvarint(lp.data[off+8:])
+	start := off + 8 + size
+	next = start + int(n)
+	return lp.data[start:next], score, next
+}
+
+func (lp *Listpack) node(off int) (node Node, next int) {
+	key, score, next := lp.decode(off)
+	return Node{key: string(key), score: score}, next
+}
+
+// find returns the rank, offset and score of key, rank is 0 when it isn't a member
+func (lp *Listpack) find(key string) (rank, off int, score float64) {
+	for off < len(lp.data) {
+		k, s, next := lp.decode(off)
+		rank++
+		if string(k) == key {
+			return rank, off, s
+		}
+		off = next
+	}
+
+	return 0, 0, 0
+}
+
+// offset returns the offset of the entry at the 0 based rank, len(data) past the end
+func (lp *Listpack) offset(rank int) int {
+	off := 0
+	for ; rank > 0 && off < len(lp.data); rank-- {
+		_, _, off = lp.decode(off)
+	}
+
+	return off
+}
+
+func (lp *Listpack) IsEmpty() bool {
+	return lp == nil || lp.length == 0
+}
+
+func (lp *Listpack) Len() int {
+	return lp.length
+}
+
+func (lp *Listpack) GetScore(key string) (float64, error) {
+	rank, _, score := lp.find(key)
+	if rank == 0 {
+		return 0, errNotFound
+	}
+
+	return score, nil
+}
+
+func (lp *Listpack) Add(key string, score float64) {
+	lp.Remove(key)
+
+	off := 0
+	for off < len(lp.data) {
+		k, s, next := lp.decode(off)
+		if s > score || (s == score && string(k) > key) {
+			break
+		}
+		off = next
+	}
+
+	entry := appendEntry(make([]byte, 0, 8+binary.MaxVarintLen64+len(key)), key, score)
+	lp.data = slices.Insert(lp.data, off, entry...)
+	lp.length++
+}
+
+func appendEntry(data []byte, key string, score float64) []byte {
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(score))
+	data = binary.AppendUvarint(data, uint64(len(key)))
+	return append(data, key...)
+}
+
+// AddBatch appends the entries of an empty listpack in one pass
+func (lp *Listpack) AddBatch(nodes []Node) {
+	nodes = sortedBatch(nodes)
+	if lp.length > 0 {
+		for _, node := range nodes {
+			lp.Add(node.key, node.score)
+		}
+		return
+	}
+
+	for _, node := range nodes {
+		lp.data = appendEntry(lp.data, node.key, node.score)
+	}
+	lp.length = len(nodes)
+}
+
+func (lp *Listpack) Remove(key string) {
+	rank, off, _ := lp.find(key)
+	if rank == 0 {
+		return
+	}
+
+	_, _, next := lp.decode(off)
+	lp.data = slices.Delete(lp.data, off, next)
+	lp.length--
+}
+
+func (lp *Listpack) Rank(key string) int {
+	rank, _, _ := lp.find(key)
+	if rank == 0 {
+		return -1
+	}
+
+	return rank
+}
+
+func (lp *Listpack) RankReverse(key string) int {
+	rank, _, _ := lp.find(key)
+	if rank == 0 {
+		return -1
+	}
+
+	return lp.length - rank + 1
+}
+
+func (lp *Listpack) Select(idx int) *Node {
+	if idx < 1 || idx > lp.length {
+		return nil
+	}
+
+	node, _ := lp.node(lp.offset(idx - 1))
+	return &node
+}
+
+func (lp *Listpack) SelectReverse(idx int) *Node {
+	return lp.Select(lp.length - idx + 1)
+}
+
+type listpackIterator struct {
+	lp  *Listpack
+	off int
+}
+
+func (it *listpackIterator) Next() *Node {
+	if it.off >= len(it.lp.data) {
+		return nil
+	}
+
+	node, next := it.lp.node(it.off)
+	it.off = next
+	return &node
+}
+
+func (lp *Listpack) Iterator(start int) Iterator {
+	return &listpackIterator{lp: lp, off: lp.offset(max(start, 0))}
+}
+
+// rangeFunc returns the members from the 0 based rank start for which in is true,
+// stopping at the first one past the range
+func (lp *Listpack) rangeFunc(start int, in func(key []byte, score float64) (ok, past bool)) (nodes []Node) {
+	for off := lp.offset(start); off < len(lp.data); {
+		key, score, next := lp.decode(off)
+		ok, past := in(key, score)
+		if past {
+			break
+		}
+		if ok {
+			nodes = append(nodes, Node{key: string(key), score: score})
+		}
+		off = next
+	}
+
+	return nodes
+}
+
+func (lp *Listpack) RangeByIndex(start, stop int) []Node {
+	start, stop = max(start, 0), min(stop, lp.length-1)
+	if start > stop {
+		return nil
+	}
+
+	rank := start
+	return lp.rangeFunc(start, func([]byte, float64) (bool, bool) {
+		rank++
+		return true, rank > stop+1
+	})
+}
+
+func (lp *Listpack) RangeByIndexReverse(start, stop int) []Node {
+	start, stop = max(start, 0), min(stop, lp.length-1)
+	if start > stop {
+		return nil
+	}
+
+	nodes := lp.RangeByIndex(lp.length-1-stop, lp.length-1-start)
+	slices.Reverse(nodes)
+	return nodes
+}
+
+func (lp *Listpack) byScore(min, max float64) []Node {
+	return lp.rangeFunc(0, func(_ []byte, score float64) (bool, bool) {
+		return score >= min, score > max
+	})
+}
+
+func (lp *Listpack) CountByScore(min, max float64) int {
+	return len(lp.byScore(min, max))
+}
+
+func (lp *Listpack) RangeByScore(min, max float64) []Node {
+	return lp.byScore(min, max)
+}
+
+func (lp *Listpack) RangeByScoreReverse(min, max float64) []Node {
+	nodes := lp.byScore(min, max)
+	slices.Reverse(nodes)
+	return nodes
+}
+
+// the lex ranges expect every member to have the same score, so members are ordered by key
+
+func (lp *Listpack) RangeByLex(minKey, maxKey string) []Node {
+	return lp.rangeFunc(0, func(key []byte, _ float64) (bool, bool) {
+		return string(key) >= minKey && string(key) <= maxKey, false
+	})
+}
+
+func (lp *Listpack) RangeByLexReverse(minKey, maxKey string) []Node {
+	nodes := lp.RangeByLex(minKey, maxKey)
+	slices.Reverse(nodes)
+	return nodes
+}
+
+func (lp *Listpack) Diff(other OrderStatisticTree) OrderStatisticTree {
+	return diff(lp, other, NewListpack())
+}
+
+func (lp *Listpack) Inter(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return inter(lp, other, aggFunc, NewListpack())
+}
+
+func (lp *Listpack) Union(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return union(lp, other, aggFunc, NewListpack())
+}
+
+func (lp *Listpack) SplitByRank(rank int) OrderStatisticTree {
+	return lp.splitAt(min(max(rank, 1), lp.length+1) - 1)
+}
+
+func (lp *Listpack) SplitByScore(score float64) OrderStatisticTree {
+	return lp.splitAt(scoreRank(lp, score))
+}
+
+// splitAt moves the entries from the 0 based rank onwards to a new listpack
+func (lp *Listpack) splitAt(rank int) OrderStatisticTree {
+	off := lp.offset(rank)
+	split := &Listpack{data: slices.Clone(lp.data[off:]), length: lp.length - rank}
+	lp.data = lp.data[:off]
+	lp.length = rank
+	return split
+}
+
+// Join appends the entries of another listpack as they are
+func (lp *Listpack) Join(other OrderStatisticTree) error {
+	o, ok := other.(*Listpack)
+	if !ok {
+		return joinFrom(lp, other)
+	}
+	if err := checkJoin(lp, o); err != nil {
+		return err
+	}
+
+	members := make(map[string]bool, o.length)
+	for off := 0; off < len(o.data); {
+		key, _, next := o.decode(off)
+		members[string(key)] = true
+		off = next
+	}
+	for off := 0; off < len(lp.data); {
+		key, _, next := lp.decode(off)
+		if members[string(key)] {
+			return errJoinMember
+		}
+		off = next
+	}
+
+	lp.data = append(lp.data, o.data...)
+	lp.length += o.length
+	*o = Listpack{}
+	return nil
+}
+
+func (lp *Listpack) MemoryUsage() int {
+	return listpackOverhead + len(lp.data)
+}
+
+func (lp *Listpack) Encoding() string {
+	return "listpack"
+}
+
+// Validate checks the entries are well formed, sorted and unique
+func (lp *Listpack) Validate() error {
+	seen := map[string]bool{}
+	var prev *Node
+	off := 0
+	for off < len(lp.data) {
+		if len(lp.data)-off < 9 {
+			return fmt.Errorf("entry at offset %d is truncated", off)
+		}
+		if n, size := binary.Uvarint(lp.data[off+8:]); size <= 0 || uint64(len(lp.data)-off-8-size) < n {
+			return fmt.Errorf("entry at offset %d is truncated", off)
+		}
+
+		node, next := lp.node(off)
+		if prev != nil && compareNode(prev, &node) >= 0 {
+			return fmt.Errorf("member %q is ordered after %q", prev.key, node.key)
+		}
+		if seen[node.key] {
+			return fmt.Errorf("member %q is stored twice", node.key)
+		}
+		seen[node.key] = true
+		prev = &node
+		off = next
+	}
+
+	if len(seen) != lp.length {
+		return fmt.Errorf("listpack has %d members, want %d", len(seen), lp.length)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package zdb
+
+import (
+	"fmt"
+	"slices"
+)
+
+// memberLayer is a member map frozen by a split and read by the sets on both sides
+type memberLayer struct {
+	scores map[string]float64
+}
+
+// memberMap maps the members of a set to their scores, keyed by the member itself
+// since distinct members may share a hash. A split shares the map with the other side
+// instead of copying it: the map freezes into a layer and each side writes to an own
+// map from then on. A member found in a layer may have moved to the other side or
+// changed its score since, so the hit only counts when the set holds the member at that
+// score, which contains looks up in the order of the set
+type memberMap struct {
+	own map[string]float64
+	// base is the map frozen by the first split, it held every member once, so sets
+	// split from the same base can only share a member written to one of them since.
+	// layers are the own maps frozen by later splits and joins, newest first
+	base    *memberLayer
+	layers  []*memberLayer
+	layered int
+}
+
+func newMemberMap(size int) memberMap {
+	return memberMap{own: make(map[string]float64, size)}
+}
+
+func (m *memberMap) get(key string, contains func(key string, score float64) bool) (float64, bool) {
+	if score, exists := m.own[key]; exists {
+		return score, true
+	}
+	if m.base == nil {
+		return 0, false
+	}
+
+	for _, layer := range m.layers {
+		if score, exists := layer.scores[key]; exists && contains(key, score) {
+			return score, true
+		}
+	}
+	if score, exists := m.base.scores[key]; exists && contains(key, score) {
+		return score, true
+	}
+	return 0, false
+}
+
+func (m *memberMap) set(key string, score float64) {
+	m.own[key] = score
+}
+
+func (m *memberMap) delete(key string) {
+	delete(m.own, key)
+}
+
+// shared reports whether m reads layers shared with other sets
+func (m *memberMap) shared() bool {
+	return m.base != nil
+}
+
+// sameBase reports whether m and other were split from the same set
+func (m *memberMap) sameBase(other *memberMap) bool {
+	return m.base != nil && m.base == other.base
+}
+
+// outgrown reports whether the map is shared and holds more entries written since the
+// split than half the count members of the set. The set then rebuilds its own in O(n),
+// amortized over those writes
+func (m *memberMap) outgrown(count int) bool {
+	return m.base != nil && 2*(len(m.own)+m.layered) > count
+}
+
+// size is the number of entries in every map read by m, including the entries of
+// members of other sets sharing its layers
+func (m *memberMap) size() int {
+	if m.base == nil {
+		return len(m.own)
+	}
+
+	return len(m.own) + m.layered + len(m.base.scores)
+}
+
+// split freezes the own map into a layer and returns the map of the other side, both
+// sides read the same layers
+func (m *memberMap) split() memberMap {
+	if m.base == nil {
+		m.base = &memberLayer{scores: m.own}
+	} else if len(m.own) > 0 {
+		m.layers = append([]*memberLayer{{scores: m.own}}, m.layers...)
+		m.layered += len(m.own)
+	}
+	m.own = make(map[string]float64)
+
+	other := *m
+	other.own = make(map[string]float64)
+	return other
+}
+
+// join takes the members of other, split from the same base. The layers of both are
+// read, the slice is copied since it may be shared with other sets
+func (m *memberMap) join(other *memberMap) {
+	for key, score := range other.own {
+		m.own[key] = score
+	}
+
+	layers := slices.Clone(m.layers)
+	for _, layer := range other.layers {
+		if !slices.Contains(layers, layer) {
+			layers = append(layers, layer)
+			m.layered += len(layer.scores)
+		}
+	}
+	m.layers = layers
+}
+
+// merge folds the newest layer into the next one while it holds at least half as many
+// entries, keeping only the members the set still holds, so the layers at least double
+// in size from the newest to the base and a lookup reads O(log n) of them
+func (m *memberMap) merge(contains func(key string, score float64) bool) {
+	for len(m.layers) > 1 && 2*len(m.layers[0].scores) >= len(m.layers[1].scores) {
+		scores := make(map[string]float64)
+		for _, layer := range m.layers[:2] {
+			for key, score := range layer.scores {
+				if contains(key, score) {
+					scores[key] = score
+				}
+			}
+		}
+
+		m.layered -= len(m.layers[0].scores) + len(m.layers[1].scores) - len(scores)
+		m.layers = append([]*memberLayer{{scores: scores}}, m.layers[2:]...)
+	}
+
+	if len(m.layers) > 0 && len(m.layers[0].scores) == 0 {
+		m.layers = m.layers[1:]
+	}
+}
+
+// shares reports whether m and other, split from the same base, hold a common member,
+// it looks up every member written to either of them
+func (m *memberMap) shares(other *memberMap, contains, otherContains func(key string, score float64) bool) bool {
+	return m.writtenIn(other, contains, otherContains) || other.writtenIn(m, otherContains, contains)
+}
+
+// writtenIn reports whether a member written to m is also a member of other
+func (m *memberMap) writtenIn(other *memberMap, contains, otherContains func(key string, score float64) bool) bool {
+	held := func(key string) bool {
+		if _, exists := other.get(key, otherContains); !exists {
+			return false
+		}
+		_, exists := m.get(key, contains)
+		return exists
+	}
+
+	for key := range m.own {
+		if held(key) {
+			return true
+		}
+	}
+	for _, layer := range m.layers {
+		for key := range layer.scores {
+			if held(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks that own only maps members of the set to their score and, unless the
+// map is shared, that it maps all count members of the set
+func (m *memberMap) validate(count int, contains func(key string, score float64) bool) error {
+	for key, score := range m.own {
+		if !contains(key, score) {
+			return fmt.Errorf("member %q has score %v in the hash map but not in the set", key, score)
+		}
+	}
+
+	if m.base == nil && len(m.own) != count {
+		return fmt.Errorf("set has %d members but hash map has %d", count, len(m.own))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package zdb
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// rough per allocation sizes on 64 bit platforms, they only need to be
+// good enough to compare keys against each other and against maxmemory
+const (
+	treeOverhead     = 64  // Tree struct and its map header
+	treeNodeSize     = 40  // treeNode in its slab
+	treeMapEntrySize = 40  // HashMap entry including bucket overhead
+	skipListOverhead = 640 // SkipList struct, its header node and map header
+	skipListNodeSize = 96  // node and its levels, 1.33 levels on average
+	listpackOverhead = 80  // SortedSet and Listpack structs and the data header
+	keyEntryOverhead = 64  // keyEntry struct, its map entry and pointer
+	keyIndexOverhead = 40  // key header in its keyIndex bucket and the bucket header
+)
+
+// keyMemoryUsage estimates the bytes used by a key and its sorted set
+func keyMemoryUsage(key string, tree OrderStatisticTree) int {
+	memory := keyEntryOverhead + keyIndexOverhead + 2*len(key)
+	if tree != nil {
+		memory += tree.MemoryUsage()
+	}
+
+	return memory
+}
+
+const (
+	lfuInitVal   = 5
+	lfuLogFactor = 10
+	lfuDecayTime = time.Minute
+)
+
+// touch only uses atomics, so readers sharing the map lock of the key can call it
+func (e *keyEntry) touch(now time.Time) {
+	e.lfu.Store(uint32(lfuLogIncr(e.lfuDecr(now))))
+	e.lru.Store(now.UnixNano())
+}
+
+func (e *keyEntry) lfuDecr(now time.Time) uint8 {
+	return lfuDecr(uint8(e.lfu.Load()), e.lru.Load(), now)
+}
+
+// lfuDecr returns the counter minus one point per decay period since the last access
+func lfuDecr(lfu uint8, lru int64, now time.Time) uint8 {
+	if lru == 0 {
+		return lfu
+	}
+
+	periods := int(time.Duration(now.UnixNano()-lru) / lfuDecayTime)
+	if periods >= int(lfu) {
+		return 0
+	}
+
+	return lfu - uint8(periods)
+}
+
+// lfuLogIncr increments the counter with a probability that gets lower the
+// higher the counter is, so 255 is only reached after about a million hits
+func lfuLogIncr(counter uint8) uint8 {
+	if counter == math.MaxUint8 {
+		return counter
+	}
+
+	baseVal := max(float64(counter)-lfuInitVal, 0)
+	p := 1.0 / (baseVal*lfuLogFactor + 1)
+	if rand.Float64() < p {
+		counter++
+	}
+
+	return counter
+}
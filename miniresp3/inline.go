@@ -0,0 +1,114 @@
+package miniresp3
+
+// SplitArgs splits an inline command into arguments following the redis quoting rules.
+// Double quoted arguments support \n, \r, \t, \b, \a and \xHH escapes, single quoted
+// arguments only support \'. ok is false on unbalanced quotes or when a closing quote
+// isn't followed by a space
+func SplitArgs(line string) (args []string, ok bool) {
+	args = []string{}
+	p := 0
+	for {
+		for p < len(line) && isSpace(line[p]) {
+			p++
+		}
+		if p >= len(line) {
+			return args, true
+		}
+
+		var (
+			inDoubleQuotes bool
+			inSingleQuotes bool
+			done           bool
+			current        = []byte{}
+		)
+		for !done {
+			if inDoubleQuotes {
+				switch {
+				case p >= len(line):
+					// unterminated quotes
+					return nil, false
+				case p+3 < len(line) && line[p] == '\\' && line[p+1] == 'x' && isHexDigit(line[p+2]) && isHexDigit(line[p+3]):
+					current = append(current, hexDigitToInt(line[p+2])*16+hexDigitToInt(line[p+3]))
+					p += 3
+				case p+1 < len(line) && line[p] == '\\':
+					p++
+					switch c := line[p]; c {
+					case 'n':
+						current = append(current, '\n')
+					case 'r':
+						current = append(current, '\r')
+					case 't':
+						current = append(current, '\t')
+					case 'b':
+						current = append(current, '\b')
+					case 'a':
+						current = append(current, '\a')
+					default:
+						current = append(current, c)
+					}
+				case line[p] == '"':
+					// closing quote must be followed by a space or nothing at all
+					if p+1 < len(line) && !isSpace(line[p+1]) {
+						return nil, false
+					}
+					done = true
+				default:
+					current = append(current, line[p])
+				}
+			} else if inSingleQuotes {
+				switch {
+				case p >= len(line):
+					return nil, false
+				case p+1 < len(line) && line[p] == '\\' && line[p+1] == '\'':
+					current = append(current, '\'')
+					p++
+				case line[p] == '\'':
+					if p+1 < len(line) && !isSpace(line[p+1]) {
+						return nil, false
+					}
+					done = true
+				default:
+					current = append(current, line[p])
+				}
+			} else {
+				switch {
+				case p >= len(line):
+					done = true
+				case isSpace(line[p]) || line[p] == 0:
+					done = true
+				case line[p] == '"':
+					inDoubleQuotes = true
+				case line[p] == '\'':
+					inSingleQuotes = true
+				default:
+					current = append(current, line[p])
+				}
+			}
+
+			if p < len(line) {
+				p++
+			}
+		}
+
+		args = append(args, string(current))
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexDigitToInt(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
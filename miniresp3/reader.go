@@ -2,12 +2,36 @@ package miniresp3
 
 import (
 	"bufio"
-	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	maxInlineSize   = 64 * 1024
+	maxMultiBulkLen = 1024 * 1024
+	maxBulkLen      = 512 * 1024 * 1024
+	// bulk strings up to this size are read in a buffer of their declared length
+	bulkChunk = 64 * 1024
+)
+
+// ProtocolError is returned for malformed input, the stream can't be read any further
+type ProtocolError struct {
+	msg string
+}
+
+func (e *ProtocolError) Error() string {
+	return "Protocol error: " + e.msg
+}
+
+func protocolError(msg string) error {
+	return &ProtocolError{msg: msg}
+}
+
 type Reader struct {
 	br *bufio.Reader
 }
@@ -22,59 +46,342 @@ func (r *Reader) IsAllRead() bool {
 	return r.br.Buffered() == 0
 }
 
-func (r *Reader) ReadArrayHeader() (count int, err error) {
-	line, err := r.br.ReadString('\n')
+func (r *Reader) Buffered() int {
+	return r.br.Buffered()
+}
+
+// ReadCommand reads either a multibulk array of bulk strings or an inline command
+// like "ZCARD board\r\n". Empty commands return no arguments and should be skipped
+func (r *Reader) ReadCommand() (args []string, err error) {
+	b, err := r.br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if TypeRESP(b[0]) != RESPArray {
+		return r.readInlineCommand()
+	}
+
+	count, err := r.ReadArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	args = make([]string, 0, max(count, 0))
+	for i := 0; i < count; i++ {
+		bulkStr, err := r.ReadBulkString()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, bulkStr)
+	}
+
+	return args, nil
+}
+
+func (r *Reader) readInlineCommand() ([]string, error) {
+	line, err := r.readLine(maxInlineSize)
 	if err != nil {
+		if err == bufio.ErrBufferFull {
+			return nil, protocolError("too big inline request")
+		}
+		return nil, err
+	}
+
+	args, ok := SplitArgs(line)
+	if !ok {
+		return nil, protocolError("unbalanced quotes in request")
+	}
+
+	return args, nil
+}
+
+// readLine reads up to limit bytes until \n and strips the line ending
+func (r *Reader) readLine(limit int) (string, error) {
+	line := []byte{}
+	for {
+		chunk, err := r.br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return "", bufio.ErrBufferFull
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+
+	return string(line), nil
+}
+
+func (r *Reader) readHeader(typ TypeRESP) (int, error) {
+	line, err := r.readLine(maxInlineSize)
+	if err != nil {
+		if err == bufio.ErrBufferFull {
+			return -1, protocolError("too big header")
+		}
 		return -1, err
 	}
 
-	if TypeRESP(line[0]) != RESPArray {
-		return -1, errors.Wrap(err, "expected array header")
+	if len(line) == 0 || TypeRESP(line[0]) != typ {
+		got := "EOL"
+		if len(line) > 0 {
+			got = strconv.Quote(line[:1])
+		}
+		return -1, protocolError("expected '" + string(typ) + "', got " + got)
+	}
+
+	length, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return -1, protocolError("invalid length " + strconv.Quote(line[1:]))
+	}
+
+	return length, nil
+}
+
+func (r *Reader) ReadArrayHeader() (count int, err error) {
+	count, err = r.readHeader(RESPArray)
+	if err != nil {
+		return -1, err
 	}
 
-	// escaped string equal to "*%d"
-	scanStr := string(RESPArray) + "%d"
-	if _, err := fmt.Sscanf(line, scanStr, &count); err != nil {
-		return -1, errors.Wrap(err, "failed to parse array length")
+	if count > maxMultiBulkLen {
+		return -1, protocolError("invalid multibulk length")
 	}
 
-	return count, nil
+	// *0 and *-1 are empty commands
+	return max(count, 0), nil
 }
 
 func (r *Reader) ReadBulkString() (bulkStr string, err error) {
-	line, err := r.br.ReadString('\n')
+	bulkStrSize, err := r.readHeader(RESPBulkString)
 	if err != nil {
 		return "", err
 	}
 
-	if TypeRESP(line[0]) != RESPBulkString {
-		return "", errors.Wrap(err, "expected bulk string header")
+	if bulkStrSize < 0 || bulkStrSize > maxBulkLen {
+		return "", protocolError("invalid bulk length")
 	}
 
-	var bulkStrSize int
-	// escaped string equal to "$%d"
-	scanStr := string(RESPBulkString) + "%d"
-	if _, err := fmt.Sscanf(line, scanStr, &bulkStrSize); err != nil {
-		return "", errors.Wrap(err, "failed to parse bulk string size")
-	}
+	return r.readBulk(bulkStrSize)
+}
 
-	bulkBytes, err := io.ReadAll(io.LimitReader(r.br, int64(bulkStrSize)))
+// ReadValue reads a single reply of any RESP2 or RESP3 type. Attributes are
+// attached to the value following them and streamed aggregates and strings are
+// collected into regular ones
+func (r *Reader) ReadValue() (Value, error) {
+	line, err := r.readLine(maxInlineSize)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to read bulk string")
+		if err == bufio.ErrBufferFull {
+			return Value{}, protocolError("too big header")
+		}
+		return Value{}, err
+	}
+
+	if len(line) == 0 {
+		return Value{}, protocolError("empty reply line")
+	}
+
+	typ, payload := TypeRESP(line[0]), line[1:]
+	v := Value{Type: typ}
+	switch typ {
+	case RESPSimpleString, RESPSimpleError:
+		v.Str = payload
+	case RESPNumber:
+		if v.Int, err = strconv.ParseInt(payload, 10, 64); err != nil {
+			return Value{}, protocolError("invalid number " + strconv.Quote(payload))
+		}
+	case RESPDoubles:
+		if v.Float, err = parseDouble(payload); err != nil {
+			return Value{}, protocolError("invalid double " + strconv.Quote(payload))
+		}
+	case RESPBoolean:
+		switch payload {
+		case "t":
+			v.Bool = true
+		case "f":
+		default:
+			return Value{}, protocolError("invalid boolean " + strconv.Quote(payload))
+		}
+	case RESPBigNumber:
+		var ok bool
+		if v.Big, ok = new(big.Int).SetString(payload, 10); !ok {
+			return Value{}, protocolError("invalid big number " + strconv.Quote(payload))
+		}
+	case RESPNull:
+	case RESPBulkString, RESPBulkError, RESPVerbatimString:
+		if typ == RESPBulkString && payload == string(RESPStreamedMarker) {
+			v.Str, err = r.readStreamedString()
+			return v, err
+		}
+
+		length, err := parseLength(payload, maxBulkLen)
+		if err != nil {
+			return Value{}, err
+		}
+		if length < 0 {
+			v.Null = true
+			return v, nil
+		}
+		if v.Str, err = r.readBulk(length); err != nil {
+			return Value{}, err
+		}
+
+		if typ == RESPVerbatimString {
+			if len(v.Str) < 4 || v.Str[3] != ':' {
+				return Value{}, protocolError("invalid verbatim string")
+			}
+			v.Format, v.Str = v.Str[:3], v.Str[4:]
+		}
+	case RESPArray, RESPSet, RESPPush, RESPMap, RESPAttribute:
+		if payload == string(RESPStreamedMarker) && typ != RESPPush && typ != RESPAttribute {
+			v.Elems, err = r.readStreamedElems()
+			return v, err
+		}
+
+		count, err := parseLength(payload, maxMultiBulkLen)
+		if err != nil {
+			return Value{}, err
+		}
+		if count < 0 {
+			v.Null = true
+			return v, nil
+		}
+		if typ == RESPMap || typ == RESPAttribute {
+			count *= 2
+		}
+
+		v.Elems = make([]Value, 0, count)
+		for range count {
+			elem, err := r.ReadValue()
+			if err != nil {
+				return Value{}, err
+			}
+			v.Elems = append(v.Elems, elem)
+		}
+
+		if typ == RESPAttribute {
+			next, err := r.ReadValue()
+			if err != nil {
+				return Value{}, err
+			}
+			next.Attrs = append(v.Elems, next.Attrs...)
+			return next, nil
+		}
+	default:
+		return Value{}, protocolError("unknown reply type " + strconv.Quote(line[:1]))
+	}
+
+	return v, nil
+}
+
+func parseLength(payload string, limit int) (int, error) {
+	length, err := strconv.Atoi(payload)
+	if err != nil || length < -1 || length > limit {
+		return 0, protocolError("invalid length " + strconv.Quote(payload))
 	}
 
-	bulkStr = string(bulkBytes)
-	if len(bulkStr) != bulkStrSize {
-		return "", errors.New("Length of actual data not same as in meta data")
+	return length, nil
+}
+
+func parseDouble(payload string) (float64, error) {
+	switch payload {
+	case "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan":
+		return math.NaN(), nil
 	}
 
-	// Find the resp \r\n bytes
-	if b, err := r.br.ReadByte(); err != nil || b != '\r' {
-		return "", errors.New("Missing CR")
+	return strconv.ParseFloat(payload, 64)
+}
+
+func (r *Reader) readBulk(length int) (string, error) {
+	if length+2 <= bulkChunk {
+		bulkBytes := make([]byte, length+2)
+		if _, err := io.ReadFull(r.br, bulkBytes); err != nil {
+			return "", errors.Wrap(err, "failed to read bulk string")
+		}
+
+		return checkBulk(string(bulkBytes), length)
 	}
-	if b, err := r.br.ReadByte(); err != nil || b != '\n' {
-		return "", errors.New("Missing LF")
+
+	// bigger bulk strings grow as their bytes arrive instead of trusting the length
+	sb := strings.Builder{}
+	sb.Grow(bulkChunk)
+	if _, err := io.CopyN(&sb, r.br, int64(length+2)); err != nil {
+		return "", errors.Wrap(err, "failed to read bulk string")
+	}
+
+	return checkBulk(sb.String(), length)
+}
+
+// checkBulk strips the CRLF terminating a bulk string of length bytes
+func checkBulk(bulk string, length int) (string, error) {
+	if bulk[length] != '\r' || bulk[length+1] != '\n' {
+		return "", protocolError("bulk string is not terminated by CRLF")
 	}
 
-	return bulkStr, nil
+	return bulk[:length], nil
+}
+
+// readStreamedString reads ;<len> chunks until the empty ;0 chunk
+func (r *Reader) readStreamedString() (string, error) {
+	var sb strings.Builder
+	for {
+		length, err := r.readHeader(RESPStreamedChunk)
+		if err != nil {
+			return "", err
+		}
+		if length < 0 || length > maxBulkLen || sb.Len()+length > maxBulkLen {
+			return "", protocolError("invalid chunk length")
+		}
+		if length == 0 {
+			return sb.String(), nil
+		}
+
+		chunk, err := r.readBulk(length)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(chunk)
+	}
+}
+
+// readStreamedElems reads values until the . end marker
+func (r *Reader) readStreamedElems() ([]Value, error) {
+	elems := []Value{}
+	for {
+		b, err := r.br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if TypeRESP(b[0]) == RESPStreamEnd {
+			if _, err := r.readLine(maxInlineSize); err != nil {
+				return nil, err
+			}
+			return elems, nil
+		}
+
+		if len(elems) >= maxMultiBulkLen*2 {
+			return nil, protocolError("invalid multibulk length")
+		}
+
+		elem, err := r.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
 }
@@ -0,0 +1,219 @@
+//go:build unit
+
+package miniresp3
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Input        string
+		Want         []string
+		WantProtoErr bool
+	}{
+		{
+			Name:  "Multibulk",
+			Input: "*2\r\n$5\r\nZCARD\r\n$5\r\nboard\r\n",
+			Want:  []string{"ZCARD", "board"},
+		},
+		{
+			Name:  "Bulk bigger than a chunk",
+			Input: "*2\r\n$4\r\nECHO\r\n$200000\r\n" + strings.Repeat("a", 200000) + "\r\n",
+			Want:  []string{"ECHO", strings.Repeat("a", 200000)},
+		},
+		{
+			Name:         "Bulk bigger than a chunk without CRLF",
+			Input:        "*2\r\n$4\r\nECHO\r\n$200000\r\n" + strings.Repeat("a", 200002),
+			WantProtoErr: true,
+		},
+		{
+			Name:  "Inline",
+			Input: "ZCARD board\r\n",
+			Want:  []string{"ZCARD", "board"},
+		},
+		{
+			Name:  "Inline without CR",
+			Input: "PING\n",
+			Want:  []string{"PING"},
+		},
+		{
+			Name:  "Inline with quotes",
+			Input: "ZADD board 1 \"a b\\x41\\n\" 2 'c\\'d'\r\n",
+			Want:  []string{"ZADD", "board", "1", "a bA\n", "2", "c'd"},
+		},
+		{
+			Name:  "Empty line",
+			Input: "\r\n",
+			Want:  []string{},
+		},
+		{
+			Name:  "Empty multibulk",
+			Input: "*0\r\n",
+			Want:  []string{},
+		},
+		{
+			Name:         "Unbalanced quotes",
+			Input:        "ZADD \"board\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Closing quote followed by a character",
+			Input:        "ZADD \"board\"x\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Too big inline request",
+			Input:        strings.Repeat("a", maxInlineSize+1) + "\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Huge multibulk length",
+			Input:        "*1048577\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Invalid multibulk length",
+			Input:        "*abc\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Negative bulk length",
+			Input:        "*1\r\n$-1\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Missing bulk string header",
+			Input:        "*1\r\n\r\n",
+			WantProtoErr: true,
+		},
+		{
+			Name:         "Bulk string longer than its length",
+			Input:        "*1\r\n$2\r\nabc\r\n",
+			WantProtoErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(test.Input))
+			got, err := r.ReadCommand()
+
+			var protoErr *ProtocolError
+			if isProtoErr := errors.As(err, &protoErr); isProtoErr != test.WantProtoErr {
+				t.Fatalf("got err %v, want protocol error %v", err, test.WantProtoErr)
+			}
+
+			if !test.WantProtoErr && slices.Compare(got, test.Want) != 0 {
+				t.Errorf("got %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestReadBulkAllocation(t *testing.T) {
+	// a header alone must not allocate the declared length
+	r := NewReader(strings.NewReader("*1\r\n$536870912\r\nshort"))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := r.ReadCommand(); err == nil {
+		t.Fatal("got no err reading a truncated bulk string")
+	}
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Errorf("got %d bytes allocated, want at most 1MB", allocated)
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Input        string
+		Want         interface{}
+		WantAttrs    int
+		WantProtoErr bool
+	}{
+		{Name: "Simple string", Input: "+OK\r\n", Want: "OK"},
+		{Name: "Simple error", Input: "-ERR bad\r\n", Want: errors.New("ERR bad")},
+		{Name: "Number", Input: ":-42\r\n", Want: int64(-42)},
+		{Name: "Double", Input: ",1.5\r\n", Want: 1.5},
+		{Name: "Infinite double", Input: ",-inf\r\n", Want: math.Inf(-1)},
+		{Name: "Boolean", Input: "#t\r\n", Want: true},
+		{Name: "Big number", Input: "(3492890328409238509324850943850943825024385\r\n", Want: bigInt("3492890328409238509324850943850943825024385")},
+		{Name: "Null", Input: "_\r\n", Want: nil},
+		{Name: "RESP2 null bulk string", Input: "$-1\r\n", Want: nil},
+		{Name: "RESP2 null array", Input: "*-1\r\n", Want: nil},
+		{Name: "Bulk string", Input: "$5\r\nhe\r\no\r\n", Want: "he\r\no"},
+		{Name: "Bulk error", Input: "!7\r\nERR a\nb\r\n", Want: errors.New("ERR a\nb")},
+		{Name: "Verbatim string", Input: "=15\r\ntxt:Some string\r\n", Want: "Some string"},
+		{
+			Name:  "Nested array",
+			Input: "*3\r\n:1\r\n*1\r\n+a\r\n_\r\n",
+			Want:  []interface{}{int64(1), []interface{}{"a"}, nil},
+		},
+		{
+			Name:  "Map",
+			Input: "%2\r\n+first\r\n:1\r\n+second\r\n#f\r\n",
+			Want:  map[string]interface{}{"first": int64(1), "second": false},
+		},
+		{Name: "Set", Input: "~2\r\n+a\r\n+b\r\n", Want: []interface{}{"a", "b"}},
+		{Name: "Push", Input: ">2\r\n+message\r\n+hi\r\n", Want: []interface{}{"message", "hi"}},
+		{
+			Name:      "Attribute",
+			Input:     "|1\r\n+ttl\r\n:3600\r\n*1\r\n:2\r\n",
+			Want:      []interface{}{int64(2)},
+			WantAttrs: 2,
+		},
+		{Name: "Streamed string", Input: "$?\r\n;4\r\nHell\r\n;1\r\no\r\n;0\r\n", Want: "Hello"},
+		{Name: "Streamed array", Input: "*?\r\n:1\r\n:2\r\n.\r\n", Want: []interface{}{int64(1), int64(2)}},
+		{
+			Name:  "Streamed map",
+			Input: "%?\r\n+a\r\n:1\r\n.\r\n",
+			Want:  map[string]interface{}{"a": int64(1)},
+		},
+		{Name: "Unknown type", Input: "@1\r\n", WantProtoErr: true},
+		{Name: "Invalid boolean", Input: "#x\r\n", WantProtoErr: true},
+		{Name: "Invalid verbatim string", Input: "=3\r\ntxt\r\n", WantProtoErr: true},
+		{Name: "Invalid length", Input: "*-2\r\n", WantProtoErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(test.Input))
+			got, err := r.ReadValue()
+
+			var protoErr *ProtocolError
+			if isProtoErr := errors.As(err, &protoErr); isProtoErr != test.WantProtoErr {
+				t.Fatalf("got err %v, want protocol error %v", err, test.WantProtoErr)
+			}
+			if test.WantProtoErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Interface(), test.Want) {
+				t.Errorf("got %#v, want %#v", got.Interface(), test.Want)
+			}
+			if len(got.Attrs) != test.WantAttrs {
+				t.Errorf("got %d attributes, want %d", len(got.Attrs), test.WantAttrs)
+			}
+			if !r.IsAllRead() {
+				t.Errorf("got %d unread bytes, want 0", r.Buffered())
+			}
+		})
+	}
+}
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
@@ -3,13 +3,22 @@ package miniresp3
 type TypeRESP byte
 
 var (
-	RESPMap          TypeRESP = '%'
-	RESPArray        TypeRESP = '*'
-	RESPBulkString   TypeRESP = '$'
-	RESPSimpleString TypeRESP = '+'
-	RESPNumber       TypeRESP = ':'
-	RESPDoubles      TypeRESP = ','
-	RESPNull         TypeRESP = '_'
-	RESPBulkError    TypeRESP = '!'
-	RESPSimpleError  TypeRESP = '-'
+	RESPMap            TypeRESP = '%'
+	RESPArray          TypeRESP = '*'
+	RESPBulkString     TypeRESP = '$'
+	RESPSimpleString   TypeRESP = '+'
+	RESPNumber         TypeRESP = ':'
+	RESPDoubles        TypeRESP = ','
+	RESPNull           TypeRESP = '_'
+	RESPBulkError      TypeRESP = '!'
+	RESPSimpleError    TypeRESP = '-'
+	RESPBoolean        TypeRESP = '#'
+	RESPBigNumber      TypeRESP = '('
+	RESPVerbatimString TypeRESP = '='
+	RESPSet            TypeRESP = '~'
+	RESPPush           TypeRESP = '>'
+	RESPAttribute      TypeRESP = '|'
+	RESPStreamedChunk  TypeRESP = ';'
+	RESPStreamEnd      TypeRESP = '.'
+	RESPStreamedMarker byte     = '?'
 )
@@ -0,0 +1,83 @@
+package miniresp3
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Value is any RESP2 or RESP3 reply. Only the fields matching Type are set:
+// Str for strings, errors and verbatim strings (Format holds the three letters
+// format), Int for numbers, Float for doubles, Bool for booleans, Big for big numbers
+// and Elems for aggregates. Maps and attributes are flattened to key value pairs
+type Value struct {
+	Type   TypeRESP
+	Str    string
+	Format string
+	Int    int64
+	Float  float64
+	Bool   bool
+	Big    *big.Int
+	Elems  []Value
+	// attributes sent right before the value, flattened to key value pairs
+	Attrs []Value
+	// RESP2 null bulk string and null array, the RESP3 null has its own type
+	Null bool
+}
+
+func (v Value) IsNull() bool {
+	return v.Null || v.Type == RESPNull
+}
+
+func (v Value) IsError() bool {
+	return v.Type == RESPSimpleError || v.Type == RESPBulkError
+}
+
+// Err returns the error carried by the value, nil for non error replies
+func (v Value) Err() error {
+	if !v.IsError() {
+		return nil
+	}
+
+	return errors.New(v.Str)
+}
+
+func (v Value) IsPush() bool {
+	return v.Type == RESPPush
+}
+
+// Interface converts v to plain Go types: nil, string, int64, float64, bool, *big.Int,
+// []interface{} for arrays, sets and pushes, map[string]interface{} for maps and
+// error for error replies. Map keys that aren't strings are formatted with %v
+func (v Value) Interface() interface{} {
+	if v.IsNull() {
+		return nil
+	}
+
+	switch v.Type {
+	case RESPSimpleString, RESPBulkString, RESPVerbatimString:
+		return v.Str
+	case RESPSimpleError, RESPBulkError:
+		return v.Err()
+	case RESPNumber:
+		return v.Int
+	case RESPDoubles:
+		return v.Float
+	case RESPBoolean:
+		return v.Bool
+	case RESPBigNumber:
+		return v.Big
+	case RESPMap:
+		m := make(map[string]interface{}, len(v.Elems)/2)
+		for i := 0; i+1 < len(v.Elems); i += 2 {
+			m[fmt.Sprint(v.Elems[i].Interface())] = v.Elems[i+1].Interface()
+		}
+		return m
+	}
+
+	arr := make([]interface{}, 0, len(v.Elems))
+	for _, elem := range v.Elems {
+		arr = append(arr, elem.Interface())
+	}
+	return arr
+}
@@ -2,28 +2,107 @@ package miniresp3
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"strconv"
 	"strings"
 )
 
+var (
+	ErrBufferLimitReached = errors.New("output buffer limit reached")
+)
+
+// limitedBuilder drops every write once its length goes past limit
+type limitedBuilder struct {
+	strings.Builder
+	limit    int
+	overflow bool
+}
+
+func (lb *limitedBuilder) WriteByte(c byte) error {
+	if lb.reached(1) {
+		return ErrBufferLimitReached
+	}
+
+	return lb.Builder.WriteByte(c)
+}
+
+func (lb *limitedBuilder) WriteString(s string) (int, error) {
+	if lb.reached(len(s)) {
+		return 0, ErrBufferLimitReached
+	}
+
+	return lb.Builder.WriteString(s)
+}
+
+func (lb *limitedBuilder) reached(n int) bool {
+	if lb.limit > 0 && lb.Len()+n > lb.limit {
+		lb.overflow = true
+	}
+
+	return lb.overflow
+}
+
+func (lb *limitedBuilder) Reset() {
+	lb.Builder.Reset()
+	lb.overflow = false
+}
+
+const (
+	RESP2 = 2
+	RESP3 = 3
+)
+
 type Writer struct {
-	bw *bufio.Writer
-	sb strings.Builder
+	bw    *bufio.Writer
+	sb    limitedBuilder
+	proto int
 }
 
 func NewWriter(w io.Writer) *Writer {
 	bw := bufio.NewWriter(w)
 	bw.Reset(w)
-	return &Writer{bw: bw, sb: strings.Builder{}}
+	return &Writer{bw: bw, sb: limitedBuilder{}, proto: RESP3}
+}
+
+// SetProtocol switches the encoding of the following replies, RESP2 replies
+// downgrade maps to flat arrays, doubles to bulk strings and nulls to $-1
+func (w *Writer) SetProtocol(proto int) {
+	w.proto = proto
+}
+
+func (w *Writer) Protocol() int {
+	return w.proto
 }
 
 func (w *Writer) Reset() {
 	w.sb.Reset()
 }
 
+// SetLimit caps the size of the pending reply, zero means no limit
+func (w *Writer) SetLimit(limit int) {
+	w.sb.limit = limit
+}
+
+// Overflowed reports whether part of the pending reply was dropped because of the limit
+func (w *Writer) Overflowed() bool {
+	return w.sb.overflow
+}
+
+// Len returns the number of bytes not yet written to the underlying writer
+func (w *Writer) Len() int {
+	return w.sb.Len() + w.bw.Buffered()
+}
+
 func (w *Writer) Write() error {
+	if w.sb.overflow {
+		w.Reset()
+		return ErrBufferLimitReached
+	}
+
 	if _, err := w.bw.Write([]byte(w.sb.String())); err != nil {
 		return err
 	}
@@ -33,6 +112,11 @@ func (w *Writer) Write() error {
 }
 
 func (w *Writer) AppendNil() {
+	if w.proto == RESP2 {
+		w.sb.WriteString("$-1\r\n")
+		return
+	}
+
 	w.sb.WriteByte(byte(RESPNull))
 	w.sb.WriteByte('\r')
 	w.sb.WriteByte('\n')
@@ -46,6 +130,11 @@ func (w *Writer) AppendInt(num int) {
 }
 
 func (w *Writer) AppendFloat64(num float64) {
+	if w.proto == RESP2 {
+		w.AppendBulkStr(fmt.Sprintf("%.2f", num))
+		return
+	}
+
 	w.sb.WriteByte(byte(RESPDoubles))
 	w.sb.WriteString(fmt.Sprintf("%.2f", num))
 	w.sb.WriteByte('\r')
@@ -127,6 +216,11 @@ func (w *Writer) AppendSimpleError(errMsg string) {
 }
 
 func (w *Writer) AppendBulkErr(errMsg string) {
+	if w.proto == RESP2 {
+		w.AppendSimpleError(strings.NewReplacer("\r", " ", "\n", " ").Replace(errMsg))
+		return
+	}
+
 	w.sb.WriteByte(byte(RESPBulkError))
 	w.sb.WriteString(strconv.Itoa(len(errMsg)))
 	w.sb.WriteByte('\r')
@@ -138,10 +232,7 @@ func (w *Writer) AppendBulkErr(errMsg string) {
 }
 
 func (w *Writer) AppendMap(m map[string]interface{}) {
-	w.sb.WriteByte(byte(RESPMap))
-	w.sb.WriteString(strconv.Itoa(len(m)))
-	w.sb.WriteByte('\r')
-	w.sb.WriteByte('\n')
+	w.AppendMapHeader(len(m))
 
 	for key, val := range m {
 		w.AppendSimpleStr(key)
@@ -157,3 +248,220 @@ func (w *Writer) AppendMap(m map[string]interface{}) {
 		}
 	}
 }
+
+// AppendMapHeader starts a map of len key value pairs, a flat array of 2*len elements in RESP2
+func (w *Writer) AppendMapHeader(len int) {
+	if w.proto == RESP2 {
+		w.AppendArrHeader(len * 2)
+		return
+	}
+
+	w.sb.WriteByte(byte(RESPMap))
+	w.sb.WriteString(strconv.Itoa(len))
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+func (w *Writer) appendHeader(typ TypeRESP, len int) {
+	w.sb.WriteByte(byte(typ))
+	w.sb.WriteString(strconv.Itoa(len))
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendDouble writes num with the shortest exact representation, unlike AppendFloat64
+func (w *Writer) AppendDouble(num float64) {
+	str := formatDouble(num)
+	if w.proto == RESP2 {
+		w.AppendBulkStr(str)
+		return
+	}
+
+	w.sb.WriteByte(byte(RESPDoubles))
+	w.sb.WriteString(str)
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+func formatDouble(num float64) string {
+	switch {
+	case math.IsInf(num, 1):
+		return "inf"
+	case math.IsInf(num, -1):
+		return "-inf"
+	case math.IsNaN(num):
+		return "nan"
+	}
+
+	return strconv.FormatFloat(num, 'g', -1, 64)
+}
+
+// AppendBool writes a boolean, :1 or :0 in RESP2
+func (w *Writer) AppendBool(b bool) {
+	if w.proto == RESP2 {
+		if b {
+			w.AppendInt(1)
+		} else {
+			w.AppendInt(0)
+		}
+		return
+	}
+
+	w.sb.WriteByte(byte(RESPBoolean))
+	if b {
+		w.sb.WriteByte('t')
+	} else {
+		w.sb.WriteByte('f')
+	}
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendBigNumber writes an arbitrary precision integer, a bulk string in RESP2
+func (w *Writer) AppendBigNumber(num *big.Int) {
+	if w.proto == RESP2 {
+		w.AppendBulkStr(num.String())
+		return
+	}
+
+	w.sb.WriteByte(byte(RESPBigNumber))
+	w.sb.WriteString(num.String())
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendVerbatimStr writes str tagged with a three letters format like "txt" or "mkd",
+// a plain bulk string in RESP2
+func (w *Writer) AppendVerbatimStr(format, str string) {
+	if w.proto == RESP2 {
+		w.AppendBulkStr(str)
+		return
+	}
+
+	w.appendHeader(RESPVerbatimString, len(format)+1+len(str))
+	w.sb.WriteString(format)
+	w.sb.WriteByte(':')
+	w.sb.WriteString(str)
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendSetHeader starts a set of len elements, an array in RESP2
+func (w *Writer) AppendSetHeader(len int) {
+	if w.proto == RESP2 {
+		w.AppendArrHeader(len)
+		return
+	}
+
+	w.appendHeader(RESPSet, len)
+}
+
+// AppendPushHeader starts an out of band push message of len elements, an array in RESP2
+func (w *Writer) AppendPushHeader(len int) {
+	if w.proto == RESP2 {
+		w.AppendArrHeader(len)
+		return
+	}
+
+	w.appendHeader(RESPPush, len)
+}
+
+// AppendAttributeHeader starts an attribute of len key value pairs describing the
+// next reply. RESP2 has no attributes, use AppendValue to drop them for RESP2 clients
+func (w *Writer) AppendAttributeHeader(len int) {
+	w.appendHeader(RESPAttribute, len)
+}
+
+// AppendStreamedHeader starts an aggregate of unknown length, typ is one of
+// RESPArray, RESPMap, RESPSet or RESPBulkString. Elements (or chunks) follow and
+// AppendStreamEnd terminates it. Streamed types only exist in RESP3
+func (w *Writer) AppendStreamedHeader(typ TypeRESP) {
+	w.sb.WriteByte(byte(typ))
+	w.sb.WriteByte(RESPStreamedMarker)
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendStreamedChunk writes a chunk of a streamed string, it must not be empty
+func (w *Writer) AppendStreamedChunk(chunk string) {
+	w.appendHeader(RESPStreamedChunk, len(chunk))
+	w.sb.WriteString(chunk)
+	w.sb.WriteByte('\r')
+	w.sb.WriteByte('\n')
+}
+
+// AppendStreamEnd terminates a streamed aggregate or string started with typ
+func (w *Writer) AppendStreamEnd(typ TypeRESP) {
+	if typ == RESPBulkString {
+		w.sb.WriteString(";0\r\n")
+		return
+	}
+
+	w.sb.WriteString(".\r\n")
+}
+
+// AppendValue writes v and its attributes, downgrading it for RESP2 clients
+func (w *Writer) AppendValue(v Value) {
+	if len(v.Attrs) > 0 && w.proto == RESP3 {
+		w.AppendAttributeHeader(len(v.Attrs) / 2)
+		for _, attr := range v.Attrs {
+			w.AppendValue(attr)
+		}
+	}
+
+	switch v.Type {
+	case RESPSimpleString:
+		w.AppendSimpleStr(v.Str)
+	case RESPSimpleError:
+		w.AppendSimpleError(v.Str)
+	case RESPBulkError:
+		w.AppendBulkErr(v.Str)
+	case RESPBulkString:
+		if v.Null {
+			w.AppendNil()
+			return
+		}
+		w.AppendBulkStr(v.Str)
+	case RESPVerbatimString:
+		w.AppendVerbatimStr(v.Format, v.Str)
+	case RESPNumber:
+		w.sb.WriteByte(byte(RESPNumber))
+		w.sb.WriteString(strconv.FormatInt(v.Int, 10))
+		w.sb.WriteByte('\r')
+		w.sb.WriteByte('\n')
+	case RESPDoubles:
+		w.AppendDouble(v.Float)
+	case RESPBoolean:
+		w.AppendBool(v.Bool)
+	case RESPBigNumber:
+		w.AppendBigNumber(v.Big)
+	case RESPNull:
+		w.AppendNil()
+	case RESPArray, RESPSet, RESPPush:
+		if v.Null {
+			if w.proto == RESP2 {
+				w.sb.WriteString("*-1\r\n")
+			} else {
+				w.AppendNil()
+			}
+			return
+		}
+
+		switch v.Type {
+		case RESPSet:
+			w.AppendSetHeader(len(v.Elems))
+		case RESPPush:
+			w.AppendPushHeader(len(v.Elems))
+		default:
+			w.AppendArrHeader(len(v.Elems))
+		}
+		for _, elem := range v.Elems {
+			w.AppendValue(elem)
+		}
+	case RESPMap:
+		w.AppendMapHeader(len(v.Elems) / 2)
+		for _, elem := range v.Elems {
+			w.AppendValue(elem)
+		}
+	}
+}
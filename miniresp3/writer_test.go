@@ -0,0 +1,126 @@
+//go:build unit
+
+package miniresp3
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestWriterProtocol(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Append func(w *Writer)
+		Want2  string
+		Want3  string
+	}{
+		{
+			Name:   "Nil",
+			Append: func(w *Writer) { w.AppendNil() },
+			Want2:  "$-1\r\n",
+			Want3:  "_\r\n",
+		},
+		{
+			Name:   "Double",
+			Append: func(w *Writer) { w.AppendFloat64(1.5) },
+			Want2:  "$4\r\n1.50\r\n",
+			Want3:  ",1.50\r\n",
+		},
+		{
+			Name:   "Map",
+			Append: func(w *Writer) { w.AppendMap(map[string]interface{}{"proto": 2}) },
+			Want2:  "*2\r\n+proto\r\n:2\r\n",
+			Want3:  "%1\r\n+proto\r\n:2\r\n",
+		},
+		{
+			Name:   "Bulk error",
+			Append: func(w *Writer) { w.AppendBulkErr("ERR a\nb") },
+			Want2:  "-ERR a b\r\n",
+			Want3:  "!7\r\nERR a\nb\r\n",
+		},
+		{
+			Name:   "Boolean",
+			Append: func(w *Writer) { w.AppendBool(true) },
+			Want2:  ":1\r\n",
+			Want3:  "#t\r\n",
+		},
+		{
+			Name:   "Big number",
+			Append: func(w *Writer) { w.AppendBigNumber(big.NewInt(-12)) },
+			Want2:  "$3\r\n-12\r\n",
+			Want3:  "(-12\r\n",
+		},
+		{
+			Name:   "Verbatim string",
+			Append: func(w *Writer) { w.AppendVerbatimStr("txt", "hi") },
+			Want2:  "$2\r\nhi\r\n",
+			Want3:  "=6\r\ntxt:hi\r\n",
+		},
+		{
+			Name:   "Set",
+			Append: func(w *Writer) { w.AppendSetHeader(1); w.AppendInt(1) },
+			Want2:  "*1\r\n:1\r\n",
+			Want3:  "~1\r\n:1\r\n",
+		},
+		{
+			Name: "Value with attributes",
+			Append: func(w *Writer) {
+				w.AppendValue(Value{
+					Type:  RESPPush,
+					Elems: []Value{{Type: RESPDoubles, Float: 0.25}},
+					Attrs: []Value{{Type: RESPSimpleString, Str: "ttl"}, {Type: RESPNumber, Int: 10}},
+				})
+			},
+			Want2: "*1\r\n$4\r\n0.25\r\n",
+			Want3: "|1\r\n+ttl\r\n:10\r\n>1\r\n,0.25\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			for proto, want := range map[int]string{RESP2: test.Want2, RESP3: test.Want3} {
+				buf := &bytes.Buffer{}
+				w := NewWriter(buf)
+				w.SetProtocol(proto)
+				test.Append(w)
+				if err := w.Write(); err != nil {
+					t.Fatalf("got err %v, want nil", err)
+				}
+
+				if got := buf.String(); got != want {
+					t.Errorf("RESP%d got %q, want %q", proto, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriterStreamed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	w.AppendStreamedHeader(RESPArray)
+	w.AppendInt(1)
+	w.AppendStreamedHeader(RESPBulkString)
+	w.AppendStreamedChunk("ab")
+	w.AppendStreamedChunk("c")
+	w.AppendStreamEnd(RESPBulkString)
+	w.AppendStreamEnd(RESPArray)
+	if err := w.Write(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	want := "*?\r\n:1\r\n$?\r\n;2\r\nab\r\n;1\r\nc\r\n;0\r\n.\r\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	v, err := NewReader(buf).ReadValue()
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if got := v.Interface(); !reflect.DeepEqual(got, []interface{}{int64(1), "abc"}) {
+		t.Errorf("got %#v, want [1 abc]", got)
+	}
+}
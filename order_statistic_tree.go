@@ -1,9 +1,13 @@
 package zdb
 
-import "errors"
+import (
+	"errors"
+	"slices"
+)
 
 var (
-	errNotFound = errors.New("err not found")
+	errNotFound  = errors.New("err not found")
+	ErrNoSuchKey = errors.New("no such key")
 )
 
 type AggFunc func(score1, score2 float64) float64
@@ -28,12 +32,15 @@ var (
 	}
 )
 
+// OrderStatisticTree is a sorted set ordered by score then member, ranks start at 1
 type OrderStatisticTree interface {
 	// CRUD
 	IsEmpty() bool
-	Root() *Node
+	Len() int
 	GetScore(key string) (float64, error)
 	Add(key string, score float64)
+	// AddBatch is Add for every node in order, faster on sorted nodes or an empty set
+	AddBatch(nodes []Node)
 	Remove(key string)
 
 	// ordering
@@ -41,6 +48,8 @@ type OrderStatisticTree interface {
 	SelectReverse(idx int) *Node
 	Rank(key string) int
 	RankReverse(key string) int
+	// Iterator walks the members in order starting at the 0 based rank start
+	Iterator(start int) Iterator
 
 	// filter operation
 	RangeByIndex(start, stop int) []Node
@@ -55,4 +64,60 @@ type OrderStatisticTree interface {
 	Diff(other OrderStatisticTree) OrderStatisticTree
 	Inter(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree
 	Union(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree
+
+	// split and join, the avltree and skiplist cut and link in O(log n) and share the
+	// hash map of the members with the other side when both hold more than splitCopyMax
+	// members, a join of sets split from the same set then costs O(log n) plus a lookup
+	// per member written to them since. Otherwise the smaller side is copied, O(min(k, n-k))
+	// for k members, the listpack copies the members it moves
+	// SplitByRank keeps the members before the 1 based rank and returns the others
+	SplitByRank(rank int) OrderStatisticTree
+	// SplitByScore keeps the members with a score lower than score and returns the others
+	SplitByScore(score float64) OrderStatisticTree
+	// Join moves the members of other, all ordered after the members of the set, to
+	// the set and leaves other empty. It fails without changing either set when a
+	// member of other is ordered before the set or is already a member of it
+	Join(other OrderStatisticTree) error
+
+	// introspection
+	MemoryUsage() int
+	Encoding() string
+	Validate() error
+}
+
+type Iterator interface {
+	// Next returns nil past the last member
+	Next() *Node
+}
+
+func compareNodes(x, y Node) int {
+	return compareNode(&x, &y)
+}
+
+// sortedBatch returns nodes sorted with only the last node of every member, it sorts
+// a copy and skips the sort when nodes are already sorted
+func sortedBatch(nodes []Node) []Node {
+	last := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		last[node.key] = i
+	}
+
+	batch := nodes
+	if len(last) < len(nodes) {
+		batch = make([]Node, 0, len(last))
+		for i, node := range nodes {
+			if last[node.key] == i {
+				batch = append(batch, node)
+			}
+		}
+	}
+
+	if !slices.IsSortedFunc(batch, compareNodes) {
+		if len(last) == len(nodes) {
+			batch = slices.Clone(batch)
+		}
+		slices.SortFunc(batch, compareNodes)
+	}
+
+	return batch
 }
@@ -0,0 +1,130 @@
+//go:build unit
+
+package zdb
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+func TestScan(t *testing.T) {
+	keys := []string{"user:1", "user:2", "user:10", "session:1", "session:2"}
+	tests := []struct {
+		Name string
+		Cmd  commands.ScanCmd
+		Want []string
+	}{
+		{
+			Name: "Single key pages",
+			Cmd:  commands.ScanCmd{Count: 1},
+			Want: keys,
+		},
+		{
+			Name: "Pages larger than the keyspace",
+			Cmd:  commands.ScanCmd{Count: 10},
+			Want: keys,
+		},
+		{
+			Name: "Match pattern",
+			Cmd:  commands.ScanCmd{Count: 2, MatchPattern: "user:?"},
+			Want: []string{"user:1", "user:2"},
+		},
+		{
+			Name: "Zset type",
+			Cmd:  commands.ScanCmd{Count: 3, Type: "zset"},
+			Want: keys,
+		},
+		{
+			Name: "Other types",
+			Cmd:  commands.ScanCmd{Count: 3, Type: "string"},
+			Want: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			db := NewZDB(4)
+			for _, key := range keys {
+				db.ZAdd(&commands.ZADDCmd{Key: key, Members: []commands.ZMember{{Key: "A", Score: 1}}})
+			}
+
+			got := []string{}
+			cmd := test.Cmd
+			cmd.Cursor = "0"
+			for pages := 0; ; pages++ {
+				if pages > len(keys) {
+					t.Fatalf("scan didn't finish after %d pages", pages)
+				}
+
+				page, nextCursor := db.Scan(&cmd)
+				got = append(got, page...)
+				if nextCursor == "0" {
+					break
+				}
+				cmd.Cursor = nextCursor
+			}
+
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(test.Want))
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestZScan(t *testing.T) {
+	members := []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}, {Key: "AB", Score: 3}, {Key: "C", Score: 4}}
+	tests := []struct {
+		Name string
+		Cmd  commands.ZScanCmd
+		Want []string
+	}{
+		{
+			Name: "Single member pages",
+			Cmd:  commands.ZScanCmd{Key: "zset", ScanCmd: commands.ScanCmd{Count: 1}},
+			Want: []string{"A", "B", "AB", "C"},
+		},
+		{
+			Name: "Match pattern",
+			Cmd:  commands.ZScanCmd{Key: "zset", ScanCmd: commands.ScanCmd{Count: 3, MatchPattern: "A*"}},
+			Want: []string{"A", "AB"},
+		},
+		{
+			Name: "Missing key",
+			Cmd:  commands.ZScanCmd{Key: "missing", ScanCmd: commands.ScanCmd{Count: 3}},
+			Want: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			db := NewZDB(4)
+			db.ZAdd(&commands.ZADDCmd{Key: "zset", Members: members})
+
+			got := []string{}
+			cmd := test.Cmd
+			cmd.ScanCmd.Cursor = "0"
+			for pages := 0; ; pages++ {
+				if pages > len(members) {
+					t.Fatalf("zscan didn't finish after %d pages", pages)
+				}
+
+				nodes, nextCursor := db.ZScan(&cmd)
+				for _, node := range nodes {
+					got = append(got, node.Key())
+				}
+				if nextCursor == "0" {
+					break
+				}
+				cmd.ScanCmd.Cursor = nextCursor
+			}
+
+			if !slices.Equal(got, test.Want) {
+				t.Errorf("got %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
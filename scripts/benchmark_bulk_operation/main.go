@@ -1,7 +1,10 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -10,8 +13,20 @@ import (
 )
 
 func main() {
+	backend := flag.String("backend", "avltree", "avltree or skiplist")
+	bulk := flag.Bool("bulk", false, "add the members as one batch instead of one at a time")
+	flag.Parse()
+
 	const N = 1e6
-	tree := zdb.NewTree()
+	var tree zdb.OrderStatisticTree
+	switch *backend {
+	case "avltree":
+		tree = zdb.NewTree()
+	case "skiplist":
+		tree = zdb.NewSkipList()
+	default:
+		log.Fatalf("unknown backend %s", *backend)
+	}
 
 	// randSrc := rand.NewSource(rand.Int63())
 	scores := []float64{}
@@ -25,11 +40,36 @@ func main() {
 		// scores = append(scores, randSrc.Int63())
 	}
 
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
 	start := time.Now()
-	for i := 0; i < N; i++ {
-		tree.Add(keys[i], scores[i])
+	if *bulk {
+		nodes := make([]zdb.Node, 0, N)
+		for i := 0; i < N; i++ {
+			nodes = append(nodes, *zdb.NewNode(keys[i], scores[i]))
+		}
+		tree.AddBatch(nodes)
+	} else {
+		for i := 0; i < N; i++ {
+			tree.Add(keys[i], scores[i])
+		}
 	}
 
 	end := time.Since(start)
-	fmt.Printf("Inserting %.2f nodes into AVL tree %.2f seconds \n", N, end.Seconds())
+	runtime.ReadMemStats(&after)
+	fmt.Printf("Inserting %.2f nodes into %s %.2f seconds \n", N, *backend, end.Seconds())
+	fmt.Printf("%d allocations, %d MB allocated, %d GC cycles pausing %s\n",
+		after.Mallocs-before.Mallocs,
+		(after.TotalAlloc-before.TotalAlloc)>>20,
+		after.NumGC-before.NumGC,
+		time.Duration(after.PauseTotalNs-before.PauseTotalNs),
+	)
+
+	// a full collection has to mark every node still reachable from the tree
+	start = time.Now()
+	runtime.GC()
+	fmt.Printf("Collecting with %.2f live nodes %s\n", N, time.Since(start))
+	runtime.KeepAlive(tree)
 }
@@ -0,0 +1,107 @@
+package zdb
+
+import "slices"
+
+// The set operations take every set once, a nil set is a missing key and is empty.
+// Members are looked up in the other sets by GetScore, the result is collected and
+// built in one batch, sorted only when the scores are aggregated
+
+// diffSets returns the members of the first set in none of the others, in the order
+// of the first set so no sort is needed
+func diffSets(sets []OrderStatisticTree, result OrderStatisticTree) OrderStatisticTree {
+	if len(sets) == 0 || sets[0] == nil {
+		return result
+	}
+
+	others := slices.DeleteFunc(slices.Clone(sets[1:]), func(set OrderStatisticTree) bool {
+		return set == nil || set.IsEmpty()
+	})
+	nodes := []Node{}
+	it := sets[0].Iterator(0)
+	for next := it.Next(); next != nil; next = it.Next() {
+		if !slices.ContainsFunc(others, func(other OrderStatisticTree) bool {
+			_, err := other.GetScore(next.key)
+			return err == nil
+		}) {
+			nodes = append(nodes, *next)
+		}
+	}
+
+	result.AddBatch(nodes)
+	return result
+}
+
+// interSets returns the members of every set, walking the smallest one. Weighted scores
+// are aggregated in the order of the sets
+func interSets(sets []OrderStatisticTree, weights []float64, aggFunc AggFunc, result OrderStatisticTree) OrderStatisticTree {
+	if len(sets) == 0 || slices.ContainsFunc(sets, func(set OrderStatisticTree) bool { return set == nil }) {
+		return result
+	}
+
+	smallest := slices.MinFunc(sets, func(x, y OrderStatisticTree) int { return x.Len() - y.Len() })
+	nodes := []Node{}
+	it := smallest.Iterator(0)
+	for next := it.Next(); next != nil; next = it.Next() {
+		score, found := 0.0, true
+		for i, set := range sets {
+			setScore, err := set.GetScore(next.key)
+			if err != nil {
+				found = false
+				break
+			}
+
+			if i == 0 {
+				score = setScore * weights[i]
+			} else {
+				score = aggFunc(score, setScore*weights[i])
+			}
+		}
+		if found {
+			nodes = append(nodes, Node{key: next.key, score: score})
+		}
+	}
+
+	result.AddBatch(nodes)
+	return result
+}
+
+// unionSets returns the members of any set with their weighted scores aggregated in the
+// order of the sets
+func unionSets(sets []OrderStatisticTree, weights []float64, aggFunc AggFunc, result OrderStatisticTree) OrderStatisticTree {
+	nodes := []Node{}
+	positions := map[string]int{}
+	for i, set := range sets {
+		if set == nil {
+			continue
+		}
+
+		it := set.Iterator(0)
+		for next := it.Next(); next != nil; next = it.Next() {
+			score := next.score * weights[i]
+			if pos, exists := positions[next.key]; exists {
+				nodes[pos].score = aggFunc(nodes[pos].score, score)
+				continue
+			}
+
+			positions[next.key] = len(nodes)
+			nodes = append(nodes, Node{key: next.key, score: score})
+		}
+	}
+
+	result.AddBatch(nodes)
+	return result
+}
+
+// the pairwise operations of OrderStatisticTree, their AggFunc applies the weights
+
+func diff(x, y, result OrderStatisticTree) OrderStatisticTree {
+	return diffSets([]OrderStatisticTree{x, y}, result)
+}
+
+func inter(x, y OrderStatisticTree, aggFunc AggFunc, result OrderStatisticTree) OrderStatisticTree {
+	return interSets([]OrderStatisticTree{x, y}, []float64{1, 1}, aggFunc, result)
+}
+
+func union(x, y OrderStatisticTree, aggFunc AggFunc, result OrderStatisticTree) OrderStatisticTree {
+	return unionSets([]OrderStatisticTree{x, y}, []float64{1, 1}, aggFunc, result)
+}
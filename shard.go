@@ -1,48 +1,233 @@
 package zdb
 
-import "time"
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
+type keyEntry struct {
+	tree OrderStatisticTree
+	// last access time in unix nano, used by allkeys-lru and OBJECT IDLETIME
+	lru atomic.Int64
+	// logarithmic access counter, used by allkeys-lfu
+	lfu atomic.Uint32
+	// unix milliseconds, zero means the key never expires
+	expireAt int64
+	// memory accounted for the key the last time it was written
+	memory int
+
+	// members past capacity are evicted by ZADD and the *STORE commands, zero means uncapped
+	capacity int
+	// evict the highest scored members instead of the lowest
+	evictMax bool
+	// members evicted so far because of the capacity
+	evicted int
+}
+
+func (e *keyEntry) isExpired(now time.Time) bool {
+	return e.expireAt != 0 && now.UnixMilli() >= e.expireAt
+}
+
+// Shard partitions the keys into DB maps by hash, each map is guarded by the lock at the
+// same index, shared by commands only reading their keys. keys indexes every key for SCAN
+// and has its own lock, taken after the map ones
 type Shard struct {
-	Keys OrderStatisticTree
-	DB   []map[string]OrderStatisticTree
-	mask uint64
-	hash fnv64a
+	keys   *keyIndex
+	keysMu sync.Mutex
+	DB     []map[string]*keyEntry
+	locks  []sync.RWMutex
+	mask   uint64
+	hash   fnv64a
+	// sorted sets created by UpsertDB are converted to backend past the listpack limits
+	backend            Backend
+	listpackMaxEntries int
+	listpackMaxValue   int
+
+	usedMemory atomic.Int64
 }
 
 func NewShards(shards uint) *Shard {
 	shards = max(shards, 1)
 	shard := &Shard{
-		Keys: NewTree(),
-		DB:   []map[string]OrderStatisticTree{},
-		mask: Mask64(shards-1) - 1,
-		hash: fnv64a{},
+		keys:               newKeyIndex(),
+		DB:                 []map[string]*keyEntry{},
+		locks:              make([]sync.RWMutex, shards),
+		mask:               Mask64(shards-1) - 1,
+		hash:               fnv64a{},
+		backend:            AVLTreeBackend,
+		listpackMaxEntries: DefaultListpackMaxEntries,
+		listpackMaxValue:   DefaultListpackMaxValue,
 	}
 
 	for range shards {
-		shard.DB = append(shard.DB, make(map[string]OrderStatisticTree))
+		shard.DB = append(shard.DB, make(map[string]*keyEntry))
 	}
 
 	return shard
 }
 
+func (s *Shard) index(key string) int {
+	return int(s.hash.Sum64(key) & s.mask)
+}
+
+func (s *Shard) indexes(keys []string) []int {
+	idxs := make([]int, 0, len(keys))
+	for _, key := range keys {
+		idxs = append(idxs, s.index(key))
+	}
+	slices.Sort(idxs)
+	return slices.Compact(idxs)
+}
+
+// lock locks the maps holding keys in index order, so commands on several keys can't
+// deadlock each other, and returns the function unlocking them
+func (s *Shard) lock(keys ...string) (unlock func()) {
+	idxs := s.indexes(keys)
+	for _, idx := range idxs {
+		s.locks[idx].Lock()
+	}
+
+	return func() {
+		for _, idx := range slices.Backward(idxs) {
+			s.locks[idx].Unlock()
+		}
+	}
+}
+
+// rlock is lock for commands that don't modify keys
+func (s *Shard) rlock(keys ...string) (unlock func()) {
+	idxs := s.indexes(keys)
+	for _, idx := range idxs {
+		s.locks[idx].RLock()
+	}
+
+	return func() {
+		for _, idx := range slices.Backward(idxs) {
+			s.locks[idx].RUnlock()
+		}
+	}
+}
+
+// peekEntry returns the entry of key without touching or expiring it
+func (s *Shard) peekEntry(key string) *keyEntry {
+	return s.DB[s.index(key)][key]
+}
+
+func (s *Shard) getEntry(key string) *keyEntry {
+	entry := s.peekEntry(key)
+	if entry == nil {
+		return nil
+	}
+
+	// lazily drop expired keys
+	if entry.isExpired(time.Now()) {
+		s.RemoveDB(key)
+		return nil
+	}
+
+	return entry
+}
+
 func (s *Shard) GetDBFromKey(key string) OrderStatisticTree {
-	shardIdx := int(s.hash.Sum64(key) & s.mask)
-	db := s.DB[shardIdx]
-	return db[key]
+	entry := s.getEntry(key)
+	if entry == nil {
+		return nil
+	}
+
+	entry.touch(time.Now())
+	return entry.tree
+}
+
+// readTree is GetDBFromKey for callers holding the read lock of key, expired keys are
+// left for the next write to remove
+func (s *Shard) readTree(key string) OrderStatisticTree {
+	entry := s.peekEntry(key)
+	if entry == nil || entry.isExpired(time.Now()) {
+		return nil
+	}
+
+	entry.touch(time.Now())
+	return entry.tree
+}
+
+func (s *Shard) newSortedSet() OrderStatisticTree {
+	return NewSortedSet(s.backend, s.listpackMaxEntries, s.listpackMaxValue)
 }
 
 func (s *Shard) UpsertDB(key string, tree OrderStatisticTree) OrderStatisticTree {
 	if tree == nil {
-		tree = NewTree()
+		tree = s.newSortedSet()
 	}
-	shardIdx := int(s.hash.Sum64(key) & s.mask)
-	s.DB[shardIdx][key] = tree
-	s.Keys.Add(key, float64(time.Now().Unix()))
-	return s.DB[shardIdx][key]
+	shardIdx := s.index(key)
+	entry := &keyEntry{tree: tree}
+	if old, exists := s.DB[shardIdx][key]; exists {
+		s.usedMemory.Add(int64(-old.memory))
+		// the cap belongs to the key, a set stored over it keeps it
+		if !old.isExpired(time.Now()) {
+			entry.capacity, entry.evictMax, entry.evicted = old.capacity, old.evictMax, old.evicted
+		}
+	} else {
+		s.keysMu.Lock()
+		s.keys.Add(key)
+		s.keysMu.Unlock()
+	}
+
+	entry.lfu.Store(lfuInitVal)
+	entry.touch(time.Now())
+	s.DB[shardIdx][key] = entry
+	s.UpdateMemory(key)
+	return entry.tree
 }
 
 func (s *Shard) RemoveDB(key string) {
-	shardIdx := int(s.hash.Sum64(key) & s.mask)
+	shardIdx := s.index(key)
+	entry, exists := s.DB[shardIdx][key]
+	if !exists {
+		return
+	}
+
+	s.usedMemory.Add(int64(-entry.memory))
 	delete(s.DB[shardIdx], key)
-	s.Keys.Remove(key)
+	s.keysMu.Lock()
+	s.keys.Remove(key)
+	s.keysMu.Unlock()
+}
+
+// TrimToCapacity evicts members of key until it fits its capacity and returns how many were evicted
+func (s *Shard) TrimToCapacity(key string) int {
+	entry := s.peekEntry(key)
+	if entry == nil || entry.capacity == 0 {
+		return 0
+	}
+
+	evicted := 0
+	for entry.tree.Len() > entry.capacity {
+		victim := entry.tree.Select(1)
+		if entry.evictMax {
+			victim = entry.tree.SelectReverse(1)
+		}
+		entry.tree.Remove(victim.Key())
+		evicted++
+	}
+	entry.evicted += evicted
+
+	return evicted
+}
+
+// UpdateMemory recomputes the memory accounted for key after its tree was modified
+func (s *Shard) UpdateMemory(key string) {
+	entry, exists := s.DB[s.index(key)][key]
+	if !exists {
+		return
+	}
+
+	memory := keyMemoryUsage(key, entry.tree)
+	s.usedMemory.Add(int64(memory - entry.memory))
+	entry.memory = memory
+}
+
+func (s *Shard) UsedMemory() int {
+	return int(s.usedMemory.Load())
 }
@@ -0,0 +1,214 @@
+//go:build unit
+
+package zdb
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+func TestZSetCap(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Members     []commands.ZMember
+		Cap         commands.ZSetCapCmd
+		Added       []commands.ZMember
+		WantZAdd    int
+		WantEvicted int
+		Want        []string
+	}{
+		{
+			Name:        "Trim lowest scores",
+			Members:     []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}, {Key: "C", Score: 3}},
+			Cap:         commands.ZSetCapCmd{Key: "zset", MaxLen: 2},
+			Added:       []commands.ZMember{{Key: "D", Score: 4}},
+			WantZAdd:    1,
+			WantEvicted: 2,
+			Want:        []string{"C", "D"},
+		},
+		{
+			Name:        "Trim highest scores",
+			Members:     []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}, {Key: "C", Score: 3}},
+			Cap:         commands.ZSetCapCmd{Key: "zset", MaxLen: 2, EvictMax: true},
+			Added:       []commands.ZMember{{Key: "D", Score: 0}},
+			WantZAdd:    1,
+			WantEvicted: 2,
+			Want:        []string{"D", "A"},
+		},
+		{
+			Name:        "Uncapped",
+			Members:     []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}},
+			Cap:         commands.ZSetCapCmd{Key: "zset", MaxLen: 0},
+			Added:       []commands.ZMember{{Key: "C", Score: 3}},
+			WantZAdd:    1,
+			WantEvicted: 0,
+			Want:        []string{"A", "B", "C"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			db := NewZDB(4)
+			db.ZAdd(&commands.ZADDCmd{Key: "zset", Members: test.Members})
+			if _, err := db.ZSetCap(&test.Cap); err != nil {
+				t.Fatalf("got err %v, want nil", err)
+			}
+			if got := db.ZAdd(&commands.ZADDCmd{Key: "zset", Members: test.Added}); got != test.WantZAdd {
+				t.Errorf("got ZAdd %d, want %d", got, test.WantZAdd)
+			}
+
+			got := Map(db.ZRange(&commands.ZRangeCmd{Key: "zset", ByIndex: true, StopIndex: -1}), func(n Node) string {
+				return n.Key()
+			})
+			if slices.Compare(got, test.Want) != 0 {
+				t.Errorf("got %v, want %v", got, test.Want)
+			}
+
+			info, _ := db.ZSetCapInfo(&test.Cap)
+			if info.Evicted != test.WantEvicted {
+				t.Errorf("got evicted %d, want %d", info.Evicted, test.WantEvicted)
+			}
+		})
+	}
+}
+
+// acquire takes a lock in a goroutine and sends its unlock once it holds it
+func acquire(lock func(keys ...string) func(), keys ...string) chan func() {
+	acquired := make(chan func(), 1)
+	go func() {
+		acquired <- lock(keys...)
+	}()
+
+	return acquired
+}
+
+func TestZSetCapStore(t *testing.T) {
+	db := NewZDB(4)
+	db.ZAdd(&commands.ZADDCmd{Key: "a", Members: []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}}})
+	db.ZAdd(&commands.ZADDCmd{Key: "b", Members: []commands.ZMember{{Key: "C", Score: 3}, {Key: "D", Score: 4}}})
+	db.ZAdd(&commands.ZADDCmd{Key: "dst", Members: []commands.ZMember{{Key: "Z", Score: 0}}})
+	if _, err := db.ZSetCap(&commands.ZSetCapCmd{Key: "dst", MaxLen: 3, EvictMax: true}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	stored := db.ZUnionStore(&commands.ZUnionStoreCmd{DstKey: "dst", ZUnionCmd: commands.ZUnionCmd{Keys: []string{"a", "b"}, Weights: []float64{1, 1}}})
+	if stored != 3 {
+		t.Errorf("got stored %d, want 3", stored)
+	}
+
+	got := Map(db.ZRange(&commands.ZRangeCmd{Key: "dst", ByIndex: true, StopIndex: -1}), func(n Node) string {
+		return n.Key()
+	})
+	if want := []string{"A", "B", "C"}; slices.Compare(got, want) != 0 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	info, _ := db.ZSetCapInfo(&commands.ZSetCapCmd{Key: "dst"})
+	if want := (ZSetCapInfo{MaxLen: 3, EvictMax: true, Evicted: 1}); info != want {
+		t.Errorf("got cap %+v, want %+v", info, want)
+	}
+}
+
+func TestShardLock(t *testing.T) {
+	shard := NewShards(4)
+	waitAcquired := func(t *testing.T, acquired chan func()) func() {
+		t.Helper()
+		select {
+		case unlock := <-acquired:
+			return unlock
+		case <-time.After(time.Second):
+			t.Fatal("lock not acquired")
+			return nil
+		}
+	}
+	checkBlocked := func(t *testing.T, acquired chan func()) {
+		t.Helper()
+		select {
+		case unlock := <-acquired:
+			unlock()
+			t.Fatal("lock acquired while it should block")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("Duplicate keys lock once", func(t *testing.T) {
+		// the same key twice, or two keys in the same map, locks the map once
+		waitAcquired(t, acquire(shard.lock, "a", "a", "b", "c", "d", "e"))()
+		waitAcquired(t, acquire(shard.lock))()
+	})
+
+	t.Run("Writer blocks readers and writers", func(t *testing.T) {
+		unlock := waitAcquired(t, acquire(shard.lock, "a"))
+		reader, writer := acquire(shard.rlock, "a"), acquire(shard.lock, "a", "b")
+		checkBlocked(t, reader)
+		checkBlocked(t, writer)
+
+		unlock()
+		// the reader and writer exclude each other, whichever gets the lock first
+		select {
+		case unlock := <-reader:
+			checkBlocked(t, writer)
+			unlock()
+			waitAcquired(t, writer)()
+		case unlock := <-writer:
+			checkBlocked(t, reader)
+			unlock()
+			waitAcquired(t, reader)()
+		case <-time.After(time.Second):
+			t.Fatal("lock not acquired after unlock")
+		}
+	})
+
+	t.Run("Readers share the lock", func(t *testing.T) {
+		first := waitAcquired(t, acquire(shard.rlock, "a"))
+		second := waitAcquired(t, acquire(shard.rlock, "a", "b"))
+		writer := acquire(shard.lock, "a")
+		checkBlocked(t, writer)
+
+		first()
+		checkBlocked(t, writer)
+		second()
+		waitAcquired(t, writer)()
+	})
+}
+
+func TestConcurrentCommands(t *testing.T) {
+	db := NewZDB(8)
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		db.ZAdd(&commands.ZADDCmd{Key: key, Members: []commands.ZMember{{Key: "seed", Score: 0}}})
+	}
+
+	wg := sync.WaitGroup{}
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every goroutine takes the keys in a different order
+			src := []string{keys[i%4], keys[(i+1)%4]}
+			dst := keys[(i+2)%4]
+			for j := range 200 {
+				db.ZAdd(&commands.ZADDCmd{Key: src[0], Members: []commands.ZMember{{Key: fmt.Sprint(j), Score: float64(j)}}})
+				db.ZRange(&commands.ZRangeCmd{Key: src[1], ByIndex: true, StopIndex: -1})
+				db.ZUnionStore(&commands.ZUnionStoreCmd{DstKey: dst, ZUnionCmd: commands.ZUnionCmd{Keys: src, Weights: []float64{1, 1}}})
+				db.ZDiffStore(&commands.ZDiffStoreCmd{DstKey: src[1], ZDiffCmd: commands.ZDiffCmd{Keys: []string{dst, src[0]}}})
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("commands deadlocked")
+	}
+}
@@ -0,0 +1,659 @@
+package zdb
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	skipListMaxLevel = 32
+	// chance of a node to also be linked on the level above
+	skipListP = 0.25
+)
+
+type skipListLevel struct {
+	forward *skipListNode
+	// number of nodes the forward link skips over, used to compute ranks
+	span int
+}
+
+type skipListNode struct {
+	key      string
+	score    float64
+	backward *skipListNode
+	levels   []skipListLevel
+}
+
+func (x *skipListNode) node() Node {
+	return Node{key: x.key, score: x.score}
+}
+
+// lessThan reports whether x is ordered before the member key with score
+func (x *skipListNode) lessThan(score float64, key string) bool {
+	return x.score < score || (x.score == score && x.key < key)
+}
+
+// SkipList is an indexable skip list like the zskiplist of Redis, every link keeps the
+// number of nodes it skips so ranks are found on the way down
+type SkipList struct {
+	header      *skipListNode
+	tail        *skipListNode
+	length      int
+	level       int
+	members     memberMap
+	memberBytes int
+}
+
+func NewSkipList() OrderStatisticTree {
+	return &SkipList{
+		header:  &skipListNode{levels: make([]skipListLevel, skipListMaxLevel)},
+		level:   1,
+		members: newMemberMap(0),
+	}
+}
+
+func randomSkipListLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Float64() < skipListP {
+		level++
+	}
+
+	return level
+}
+
+func (sl *SkipList) IsEmpty() bool {
+	return sl == nil || sl.length == 0
+}
+
+func (sl *SkipList) Len() int {
+	return sl.length
+}
+
+// Level is the number of levels in use, the skip list counterpart of the tree height
+func (sl *SkipList) Level() int {
+	return sl.level
+}
+
+func (sl *SkipList) GetScore(key string) (float64, error) {
+	score, exists := sl.members.get(key, sl.contains)
+	if !exists {
+		return 0, errNotFound
+	}
+
+	return score, nil
+}
+
+func (sl *SkipList) Add(key string, score float64) {
+	if oldScore, exists := sl.members.get(key, sl.contains); exists {
+		sl.delete(key, oldScore)
+	} else {
+		sl.memberBytes += len(key)
+	}
+	sl.insert(key, score)
+	sl.members.set(key, score)
+
+	if sl.members.outgrown(sl.length) {
+		sl.rebuildMembers()
+	}
+}
+
+func (sl *SkipList) rebuildMembers() {
+	sl.members = newMemberMap(sl.length)
+	sl.memberBytes = 0
+	for x := sl.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+		sl.members.set(x.key, x.score)
+		sl.memberBytes += len(x.key)
+	}
+}
+
+// contains reports whether the list holds key with score
+func (sl *SkipList) contains(key string, score float64) bool {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.lessThan(score, key) {
+			x = x.levels[i].forward
+		}
+	}
+
+	x = x.levels[0].forward
+	return x != nil && x.score == score && x.key == key
+}
+
+// AddBatch links the nodes of an empty skip list in one pass from the head to the tail
+func (sl *SkipList) AddBatch(nodes []Node) {
+	nodes = sortedBatch(nodes)
+	if sl.length > 0 {
+		for _, node := range nodes {
+			sl.Add(node.key, node.score)
+		}
+		return
+	}
+	sl.members = newMemberMap(len(nodes))
+
+	// the last node linked on every level and its rank
+	var last [skipListMaxLevel]*skipListNode
+	var lastRank [skipListMaxLevel]int
+	for i := range last {
+		last[i] = sl.header
+	}
+
+	for i, node := range nodes {
+		rank := i + 1
+		x := &skipListNode{key: node.key, score: node.score, levels: make([]skipListLevel, randomSkipListLevel())}
+		if last[0] != sl.header {
+			x.backward = last[0]
+		}
+		for level := range x.levels {
+			last[level].levels[level].forward = x
+			last[level].levels[level].span = rank - lastRank[level]
+			last[level], lastRank[level] = x, rank
+		}
+
+		sl.level = max(sl.level, len(x.levels))
+		sl.members.set(node.key, node.score)
+		sl.memberBytes += len(node.key)
+	}
+
+	for level := range sl.level {
+		last[level].levels[level].span = len(nodes) - lastRank[level]
+	}
+	if len(nodes) > 0 {
+		sl.tail = last[0]
+	}
+	sl.length = len(nodes)
+}
+
+func (sl *SkipList) Remove(key string) {
+	score, exists := sl.members.get(key, sl.contains)
+	if !exists {
+		return
+	}
+
+	sl.delete(key, score)
+	sl.members.delete(key)
+	// split lists only estimate their member bytes
+	sl.memberBytes = max(sl.memberBytes-len(key), 0)
+}
+
+func (sl *SkipList) insert(key string, score float64) {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.lessThan(score, key) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomSkipListLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			update[i] = sl.header
+			update[i].levels[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	x = &skipListNode{key: key, score: score, levels: make([]skipListLevel, level)}
+	for i := range level {
+		x.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = x
+
+		// rank[0] - rank[i] nodes are between update[i] and the new node
+		x.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	// the links above the new node now skip over it
+	for i := level; i < sl.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	if update[0] != sl.header {
+		x.backward = update[0]
+	}
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+}
+
+func (sl *SkipList) delete(key string, score float64) {
+	var update [skipListMaxLevel]*skipListNode
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.lessThan(score, key) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].forward
+	if x == nil || x.score != score || x.key != key {
+		return
+	}
+
+	for i := range sl.level {
+		if update[i].levels[i].forward == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].forward = x.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+	sl.shrinkLevel()
+	sl.length--
+}
+
+func (sl *SkipList) Rank(key string) int {
+	score, exists := sl.members.get(key, sl.contains)
+	if !exists {
+		return -1
+	}
+
+	rank := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && !(score < x.levels[i].forward.score || (score == x.levels[i].forward.score && key < x.levels[i].forward.key)) {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if x != sl.header && x.key == key {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+func (sl *SkipList) RankReverse(key string) int {
+	rank := sl.Rank(key)
+	if rank < 0 {
+		return -1
+	}
+
+	return sl.length - rank + 1
+}
+
+// byRank returns the node at the 1 based rank, nil when out of range
+func (sl *SkipList) byRank(rank int) *skipListNode {
+	if rank < 1 || rank > sl.length {
+		return nil
+	}
+
+	traversed := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= rank {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+
+	return nil
+}
+
+func (sl *SkipList) Select(idx int) *Node {
+	x := sl.byRank(idx)
+	if x == nil {
+		return nil
+	}
+
+	node := x.node()
+	return &node
+}
+
+func (sl *SkipList) SelectReverse(idx int) *Node {
+	return sl.Select(sl.length - idx + 1)
+}
+
+type skipListIterator struct {
+	next *skipListNode
+}
+
+func (it *skipListIterator) Next() *Node {
+	if it.next == nil {
+		return nil
+	}
+
+	node := it.next.node()
+	it.next = it.next.levels[0].forward
+	return &node
+}
+
+func (sl *SkipList) Iterator(start int) Iterator {
+	return &skipListIterator{next: sl.byRank(max(start, 0) + 1)}
+}
+
+func (sl *SkipList) RangeByIndex(start, stop int) (nodes []Node) {
+	stop = min(stop+1, sl.length)
+	rank := max(start+1, 1)
+	for x := sl.byRank(rank); x != nil && rank <= stop; x, rank = x.levels[0].forward, rank+1 {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+func (sl *SkipList) RangeByIndexReverse(start, stop int) (nodes []Node) {
+	stop = min(stop+1, sl.length)
+	rank := max(start+1, 1)
+	for x := sl.byRank(sl.length - rank + 1); x != nil && rank <= stop; x, rank = x.backward, rank+1 {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+// firstFrom returns the first node for which before is false, before has to be true
+// for a prefix of the list
+func (sl *SkipList) firstFrom(before func(x *skipListNode) bool) *skipListNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && before(x.levels[i].forward) {
+			x = x.levels[i].forward
+		}
+	}
+
+	return x.levels[0].forward
+}
+
+// lastBefore returns the last node for which before is true, before has to be true
+// for a prefix of the list
+func (sl *SkipList) lastBefore(before func(x *skipListNode) bool) *skipListNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && before(x.levels[i].forward) {
+			x = x.levels[i].forward
+		}
+	}
+
+	if x == sl.header {
+		return nil
+	}
+	return x
+}
+
+func (sl *SkipList) CountByScore(min, max float64) int {
+	count := 0
+	for x := sl.firstFrom(func(x *skipListNode) bool { return x.score < min }); x != nil && x.score <= max; x = x.levels[0].forward {
+		count++
+	}
+
+	return count
+}
+
+func (sl *SkipList) RangeByScore(min, max float64) (nodes []Node) {
+	for x := sl.firstFrom(func(x *skipListNode) bool { return x.score < min }); x != nil && x.score <= max; x = x.levels[0].forward {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+func (sl *SkipList) RangeByScoreReverse(min, max float64) (nodes []Node) {
+	for x := sl.lastBefore(func(x *skipListNode) bool { return x.score <= max }); x != nil && x.score >= min; x = x.backward {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+// the lex ranges expect every member to have the same score, so members are ordered by key
+
+func (sl *SkipList) RangeByLex(minKey, maxKey string) (nodes []Node) {
+	for x := sl.firstFrom(func(x *skipListNode) bool { return x.key < minKey }); x != nil && x.key <= maxKey; x = x.levels[0].forward {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+func (sl *SkipList) RangeByLexReverse(minKey, maxKey string) (nodes []Node) {
+	for x := sl.lastBefore(func(x *skipListNode) bool { return x.key <= maxKey }); x != nil && x.key >= minKey; x = x.backward {
+		nodes = append(nodes, x.node())
+	}
+
+	return nodes
+}
+
+func (sl *SkipList) Diff(other OrderStatisticTree) OrderStatisticTree {
+	return diff(sl, other, NewSkipList())
+}
+
+func (sl *SkipList) Inter(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return inter(sl, other, aggFunc, NewSkipList())
+}
+
+func (sl *SkipList) Union(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return union(sl, other, aggFunc, NewSkipList())
+}
+
+func (sl *SkipList) SplitByRank(rank int) OrderStatisticTree {
+	return sl.split(min(max(rank, 1), sl.length+1) - 1)
+}
+
+func (sl *SkipList) SplitByScore(score float64) OrderStatisticTree {
+	rank := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.score < score {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+	}
+
+	return sl.split(rank)
+}
+
+// split keeps the first k members and moves the others to a new list by cutting the
+// link over rank k on every level, in expected O(log n). When both sides hold more than
+// splitCopyMax members they share the member map, otherwise the smaller side gets one
+// of its own
+func (sl *SkipList) split(k int) OrderStatisticTree {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && rank[i]+x.levels[i].span <= k {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	right := NewSkipList().(*SkipList)
+	for i := range sl.level {
+		right.header.levels[i].forward = update[i].levels[i].forward
+		right.header.levels[i].span = update[i].levels[i].span - (k - rank[i])
+		update[i].levels[i].forward = nil
+		update[i].levels[i].span = k - rank[i]
+	}
+	right.level, right.length = sl.level, sl.length-k
+	sl.length = k
+	if first := right.header.levels[0].forward; first != nil {
+		first.backward = nil
+		right.tail, sl.tail = sl.tail, update[0]
+		if sl.tail == sl.header {
+			sl.tail = nil
+		}
+	}
+	sl.shrinkLevel()
+	right.shrinkLevel()
+
+	if min(sl.length, right.length) > splitCopyMax {
+		right.members = sl.members.split()
+		right.memberBytes = sl.memberBytes * right.length / (sl.length + right.length)
+		sl.memberBytes -= right.memberBytes
+		sl.members.merge(sl.contains)
+		right.members.merge(right.contains)
+	} else if sl.length >= right.length {
+		right.takeMembers(sl)
+	} else {
+		right.members, right.memberBytes = sl.members, sl.memberBytes
+		sl.takeMembers(right)
+	}
+
+	return right
+}
+
+// takeMembers moves the members of sl from the member map of other to one of its own
+func (sl *SkipList) takeMembers(other *SkipList) {
+	sl.members = newMemberMap(sl.length)
+	sl.memberBytes = 0
+	for x := sl.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+		sl.members.set(x.key, x.score)
+		sl.memberBytes += len(x.key)
+		other.members.delete(x.key)
+	}
+	other.memberBytes = max(other.memberBytes-sl.memberBytes, 0)
+}
+
+func (sl *SkipList) shrinkLevel() {
+	for sl.level > 1 && sl.header.levels[sl.level-1].forward == nil {
+		sl.level--
+	}
+}
+
+// Join links the nodes of other after the last node of every level in expected
+// O(log n). Lists split from the same list share their member map, a lookup of the
+// members written to either since rejects a shared member. Otherwise the members of the
+// smaller one are looked up in and added to the member map of the bigger one
+func (sl *SkipList) Join(other OrderStatisticTree) error {
+	o, ok := other.(*SkipList)
+	if !ok {
+		return joinFrom(sl, other)
+	}
+	if err := checkJoin(sl, o); err != nil {
+		return err
+	}
+
+	if sl.members.sameBase(&o.members) {
+		if sl.members.shares(&o.members, sl.contains, o.contains) {
+			return errJoinMember
+		}
+		sl.members.join(&o.members)
+	} else {
+		small, big := o, sl
+		if sl.length < o.length {
+			small, big = sl, o
+		}
+		for x := small.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+			if _, exists := big.members.get(x.key, big.contains); exists {
+				return errJoinMember
+			}
+		}
+		for x := small.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+			big.members.set(x.key, x.score)
+		}
+		sl.members = big.members
+	}
+
+	sl.link(o)
+	sl.memberBytes += o.memberBytes
+	sl.members.merge(sl.contains)
+	*o = *NewSkipList().(*SkipList)
+	return nil
+}
+
+// link appends the nodes of other, all ordered after the nodes of sl
+func (sl *SkipList) link(other *SkipList) {
+	if other.length == 0 {
+		return
+	}
+
+	level := max(sl.level, other.level)
+	rank := 0
+	x := sl.header
+	for i := level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+
+		x.levels[i].span = sl.length - rank + other.length
+		if i < other.level {
+			x.levels[i].forward = other.header.levels[i].forward
+			x.levels[i].span = sl.length - rank + other.header.levels[i].span
+		}
+	}
+
+	other.header.levels[0].forward.backward = sl.tail
+	sl.tail = other.tail
+	sl.length += other.length
+	sl.level = level
+}
+
+func (sl *SkipList) MemoryUsage() int {
+	return skipListOverhead + sl.length*(skipListNodeSize+treeMapEntrySize) + sl.memberBytes
+}
+
+func (sl *SkipList) Encoding() string {
+	return "skiplist"
+}
+
+// Validate checks the ordering, the backward links and the span of every link and that
+// the member map holds exactly the members of the list
+func (sl *SkipList) Validate() error {
+	ranks := map[*skipListNode]int{sl.header: 0}
+	var prev *skipListNode
+	rank := 0
+	for x := sl.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+		rank++
+		ranks[x] = rank
+
+		if prev != nil && !prev.lessThan(x.score, x.key) {
+			return fmt.Errorf("member %q is ordered after %q", prev.key, x.key)
+		}
+		if x.backward != prev {
+			return fmt.Errorf("member %q has a wrong backward link", x.key)
+		}
+		if score, err := sl.GetScore(x.key); err != nil || score != x.score {
+			return fmt.Errorf("member %q has score %v in the list but not in the hash map", x.key, x.score)
+		}
+		prev = x
+	}
+
+	if rank != sl.length || sl.tail != prev {
+		return fmt.Errorf("list has %d members ending at %v, want %d", rank, sl.tail, sl.length)
+	}
+	if err := sl.members.validate(sl.length, sl.contains); err != nil {
+		return err
+	}
+
+	for i := range sl.level {
+		for x := sl.header; x != nil; x = x.levels[i].forward {
+			next := x.levels[i].forward
+			want := sl.length - ranks[x]
+			if next != nil {
+				want = ranks[next] - ranks[x]
+			}
+			if x.levels[i].span != want {
+				return fmt.Errorf("member %q has span %d on level %d, want %d", x.key, x.levels[i].span, i, want)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package zdb
+
+import (
+	"errors"
+	"slices"
+)
+
+var errUnknownBackend = errors.New("unknown sorted set backend")
+
+const (
+	DefaultListpackMaxEntries = 128
+	DefaultListpackMaxValue   = 64
+)
+
+// Backend is the OrderStatisticTree sorted sets past the listpack limits use
+type Backend string
+
+const (
+	AVLTreeBackend  Backend = "avltree"
+	SkipListBackend Backend = "skiplist"
+)
+
+func ParseBackend(backend string) (Backend, error) {
+	switch b := Backend(backend); b {
+	case AVLTreeBackend, SkipListBackend:
+		return b, nil
+	}
+
+	return "", errUnknownBackend
+}
+
+func (b Backend) new() OrderStatisticTree {
+	if b == SkipListBackend {
+		return NewSkipList()
+	}
+
+	return NewTree()
+}
+
+// SortedSet is the OrderStatisticTree stored under every key, a listpack until it has
+// more than maxEntries members or a member longer than maxValue bytes, then its backend
+type SortedSet struct {
+	OrderStatisticTree
+	backend    Backend
+	maxEntries int
+	maxValue   int
+}
+
+func NewSortedSet(backend Backend, maxEntries, maxValue int) OrderStatisticTree {
+	return &SortedSet{
+		OrderStatisticTree: NewListpack(),
+		backend:            backend,
+		maxEntries:         maxEntries,
+		maxValue:           maxValue,
+	}
+}
+
+func (s *SortedSet) Add(key string, score float64) {
+	if _, ok := s.OrderStatisticTree.(*Listpack); ok && (len(key) > s.maxValue || s.Len() >= s.maxEntries) {
+		s.convert()
+	}
+
+	s.OrderStatisticTree.Add(key, score)
+}
+
+func (s *SortedSet) AddBatch(nodes []Node) {
+	if _, ok := s.OrderStatisticTree.(*Listpack); ok && !s.fits(nodes) {
+		s.convert()
+	}
+
+	s.OrderStatisticTree.AddBatch(nodes)
+}
+
+// fits reports whether the listpack stays under the limits with nodes added
+func (s *SortedSet) fits(nodes []Node) bool {
+	if s.Len()+len(nodes) > s.maxEntries {
+		return false
+	}
+
+	return !slices.ContainsFunc(nodes, func(node Node) bool {
+		return len(node.key) > s.maxValue
+	})
+}
+
+// convert moves the members of the listpack to the backend, sets are never
+// converted back when they shrink
+func (s *SortedSet) convert() {
+	tree := s.backend.new()
+	tree.AddBatch(s.RangeByIndex(0, s.Len()-1))
+	s.OrderStatisticTree = tree
+}
+
+func (s *SortedSet) empty() OrderStatisticTree {
+	return NewSortedSet(s.backend, s.maxEntries, s.maxValue)
+}
+
+func (s *SortedSet) Diff(other OrderStatisticTree) OrderStatisticTree {
+	return diff(s, other, s.empty())
+}
+
+func (s *SortedSet) Inter(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return inter(s, other, aggFunc, s.empty())
+}
+
+func (s *SortedSet) Union(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return union(s, other, aggFunc, s.empty())
+}
+
+func (s *SortedSet) SplitByRank(rank int) OrderStatisticTree {
+	return s.wrap(s.OrderStatisticTree.SplitByRank(rank))
+}
+
+func (s *SortedSet) SplitByScore(score float64) OrderStatisticTree {
+	return s.wrap(s.OrderStatisticTree.SplitByScore(score))
+}
+
+// wrap returns a sorted set with the limits of s holding tree
+func (s *SortedSet) wrap(tree OrderStatisticTree) OrderStatisticTree {
+	return &SortedSet{
+		OrderStatisticTree: tree,
+		backend:            s.backend,
+		maxEntries:         s.maxEntries,
+		maxValue:           s.maxValue,
+	}
+}
+
+func (s *SortedSet) Join(other OrderStatisticTree) error {
+	other = unwrapTree(other)
+	if err := checkJoin(s, other); err != nil {
+		return err
+	}
+
+	if _, ok := s.OrderStatisticTree.(*Listpack); ok && !s.fits(other.RangeByIndex(0, other.Len()-1)) {
+		if shareMember(s, other) {
+			return errJoinMember
+		}
+		s.convert()
+	}
+
+	return s.OrderStatisticTree.Join(other)
+}
+
+// unwrapTree returns the listpack or backend holding the members of tree
+func unwrapTree(tree OrderStatisticTree) OrderStatisticTree {
+	if s, ok := tree.(*SortedSet); ok {
+		return s.OrderStatisticTree
+	}
+
+	return tree
+}
+
+// SetBackend picks the backend of the sorted sets created from now on
+func (zdb *ZDB) SetBackend(backend Backend) {
+	zdb.shards.backend = backend
+}
+
+// SetListpackLimits sets the limits past which the sorted sets created from now on
+// are converted from a listpack to their backend, zero entries disables listpacks
+func (zdb *ZDB) SetListpackLimits(maxEntries, maxValue int) {
+	zdb.shards.listpackMaxEntries = maxEntries
+	zdb.shards.listpackMaxValue = maxValue
+}
@@ -0,0 +1,134 @@
+//go:build unit
+
+package zdb
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+)
+
+func TestBackendsRandomOperations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		r := rand.New(rand.NewPCG(1, 2))
+		tree := newTree()
+		want := NewTree()
+		for i := range 2000 {
+			key := strconv.Itoa(r.IntN(300))
+			if r.IntN(3) == 0 {
+				tree.Remove(key)
+				want.Remove(key)
+			} else {
+				score := float64(r.IntN(50))
+				tree.Add(key, score)
+				want.Add(key, score)
+			}
+
+			if i%100 != 0 {
+				continue
+			}
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("after %d operations got err %v", i, err)
+			}
+			if got := tree.RangeByIndex(0, want.Len()-1); !equalNodes(got, want.RangeByIndex(0, want.Len()-1)) {
+				t.Fatalf("after %d operations got %v, want %v", i, got, want.RangeByIndex(0, want.Len()-1))
+			}
+			if got := tree.RangeByScoreReverse(10, 30); !equalNodes(got, want.RangeByScoreReverse(10, 30)) {
+				t.Fatalf("after %d operations got %v, want %v", i, got, want.RangeByScoreReverse(10, 30))
+			}
+			for _, node := range want.RangeByIndex(0, 20) {
+				if got, want := tree.Rank(node.key), want.Rank(node.key); got != want {
+					t.Fatalf("Rank(%q) got %d, want %d", node.key, got, want)
+				}
+			}
+		}
+	})
+}
+
+func equalNodes(x, y []Node) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].key != y[i].key || x[i].score != y[i].score {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSortedSetConversion(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Backend    Backend
+		MaxEntries int
+		MaxValue   int
+		Members    []string
+		Want       string
+	}{
+		{
+			Name:       "Small set stays a listpack",
+			Backend:    AVLTreeBackend,
+			MaxEntries: 3,
+			MaxValue:   DefaultListpackMaxValue,
+			Members:    []string{"A", "B", "C"},
+			Want:       "listpack",
+		},
+		{
+			Name:       "Long member converts to avltree",
+			Backend:    AVLTreeBackend,
+			MaxEntries: DefaultListpackMaxEntries,
+			MaxValue:   4,
+			Members:    []string{"A", "BBBBB"},
+			Want:       "avltree",
+		},
+		{
+			Name:       "Too many members converts to skiplist",
+			Backend:    SkipListBackend,
+			MaxEntries: 3,
+			MaxValue:   DefaultListpackMaxValue,
+			Members:    []string{"A", "B", "C", "D"},
+			Want:       "skiplist",
+		},
+		{
+			Name:       "Zero entries disables listpacks",
+			Backend:    AVLTreeBackend,
+			MaxEntries: 0,
+			MaxValue:   DefaultListpackMaxValue,
+			Members:    []string{"A"},
+			Want:       "avltree",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			tree := NewSortedSet(test.Backend, test.MaxEntries, test.MaxValue)
+			for i, member := range test.Members {
+				tree.Add(member, float64(i))
+			}
+
+			if got := tree.Encoding(); got != test.Want {
+				t.Errorf("got encoding %s, want %s", got, test.Want)
+			}
+			if got := tree.Len(); got != len(test.Members) {
+				t.Errorf("got len %d, want %d", got, len(test.Members))
+			}
+			if err := tree.Validate(); err != nil {
+				t.Errorf("got err %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestListpackMemoryUsage(t *testing.T) {
+	listpack, tree := NewListpack(), NewTree()
+	for i := range 32 {
+		listpack.Add("member:"+strconv.Itoa(i), float64(i))
+		tree.Add("member:"+strconv.Itoa(i), float64(i))
+	}
+
+	if listpack.MemoryUsage()*3 > tree.MemoryUsage() {
+		t.Errorf("got listpack usage %d, want less than a third of the tree usage %d", listpack.MemoryUsage(), tree.MemoryUsage())
+	}
+}
@@ -0,0 +1,274 @@
+package zdb
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errJoinOrder  = errors.New("joined members must be ordered after the members of the set")
+	errJoinMember = errors.New("joined sets must not share a member")
+)
+
+// splitCopyMax is the most members a split copies to a set of their own, a split with
+// more members on both sides shares the member map of the set between them
+const splitCopyMax = treeSlabSize
+
+// scoreRank is the 0 based rank of the first member with a score of at least score
+func scoreRank(set OrderStatisticTree, score float64) int {
+	return set.Len() - set.CountByScore(score, math.Inf(1))
+}
+
+// checkJoin returns errJoinOrder unless every member of other is ordered after set
+func checkJoin(set, other OrderStatisticTree) error {
+	if set.IsEmpty() || other.IsEmpty() {
+		return nil
+	}
+
+	if compareNode(set.SelectReverse(1), other.Select(1)) >= 0 {
+		return errJoinOrder
+	}
+	return nil
+}
+
+// shareMember looks up the members of the smaller of set and other in the bigger one
+func shareMember(set, other OrderStatisticTree) bool {
+	if set.Len() > other.Len() {
+		set, other = other, set
+	}
+
+	for _, node := range set.RangeByIndex(0, set.Len()-1) {
+		if _, err := other.GetScore(node.key); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFrom is the join of the backends without a faster one
+func joinFrom(set, other OrderStatisticTree) error {
+	if err := checkJoin(set, other); err != nil {
+		return err
+	}
+	if shareMember(set, other) {
+		return errJoinMember
+	}
+
+	nodes := other.RangeByIndex(0, other.Len()-1)
+	for _, node := range nodes {
+		other.Remove(node.key)
+	}
+
+	set.AddBatch(nodes)
+	return nil
+}
+
+// SplitByRank cuts the tree in O(log n). When both sides hold more than splitCopyMax
+// members they share the slabs and the member map, otherwise the smaller side is copied
+func (t *Tree) SplitByRank(rank int) OrderStatisticTree {
+	rank = min(max(rank, 1), t.Len()+1)
+	l, r := t.splitRank(t.root, rank-1)
+	return t.detach(l, r)
+}
+
+func (t *Tree) SplitByScore(score float64) OrderStatisticTree {
+	l, r := t.splitScore(t.root, score)
+	return t.detach(l, r)
+}
+
+// Join links two trees sharing slabs in O(log n), after looking up the members written
+// to either of them since their split. Otherwise the members of the smaller one are
+// copied to the slabs of the bigger one, so it costs O(log n + min(n, m))
+func (t *Tree) Join(other OrderStatisticTree) error {
+	o, ok := other.(*Tree)
+	if !ok {
+		return joinFrom(t, other)
+	}
+	if err := checkJoin(t, o); err != nil {
+		return err
+	}
+
+	if t.family != nil && t.family == o.family {
+		if t.members.shares(&o.members, t.contains, o.contains) {
+			return errJoinMember
+		}
+		t.takeSlabs(&o.treeSlabs)
+		t.members.join(&o.members)
+		t.memberBytes += o.memberBytes
+		t.root = t.join(t.root, o.root)
+		t.members.merge(t.contains)
+	} else if t.Len() >= o.Len() {
+		r, err := t.adopt(o)
+		if err != nil {
+			return err
+		}
+		t.root = t.join(t.root, r)
+	} else {
+		l, err := o.adopt(t)
+		if err != nil {
+			return err
+		}
+		o.root = o.join(l, o.root)
+		*t = *o
+	}
+
+	*o = Tree{treeSlabs: newTreeSlabs(), members: newMemberMap(0)}
+	return nil
+}
+
+// adopt copies the members of other to the slabs and member map of t and returns the
+// root of their balanced subtree, not linked to the root of t. It returns errJoinMember
+// without changing t when t already has one of them
+func (t *Tree) adopt(other *Tree) (uint32, error) {
+	nodes := other.RangeByIndex(0, other.Len()-1)
+	for _, node := range nodes {
+		if _, exists := t.members.get(node.key, t.contains); exists {
+			return 0, errJoinMember
+		}
+	}
+
+	for _, node := range nodes {
+		t.members.set(node.key, node.score)
+	}
+	t.memberBytes += other.memberBytes
+
+	return t.buildRec(nodes), nil
+}
+
+// detach keeps l in t and returns r as a new tree. Sides of more than splitCopyMax
+// members share the slabs and the member map of t and split its member bytes by count.
+// Otherwise the smaller side is copied to new slabs and the bigger one keeps t's
+func (t *Tree) detach(l, r uint32) OrderStatisticTree {
+	if cl, cr := t.count(l), t.count(r); min(cl, cr) > splitCopyMax {
+		t.share()
+		right := &Tree{
+			treeSlabs: treeSlabs{slabs: t.slabs, family: t.family},
+			root:      r,
+			members:   t.members.split(),
+		}
+		right.memberBytes = t.memberBytes * cr / (cl + cr)
+		t.memberBytes -= right.memberBytes
+		t.root = l
+
+		t.members.merge(t.contains)
+		right.members.merge(right.contains)
+		return right
+	}
+
+	if t.count(l) >= t.count(r) {
+		t.root = l
+		return t.extract(r)
+	}
+
+	left := t.extract(l)
+	right := *t
+	right.root = r
+	*t = *left
+	return &right
+}
+
+// extract removes the subtree i from t and returns its members as a new tree
+func (t *Tree) extract(i uint32) *Tree {
+	nodes := []Node{}
+	stack := []uint32{}
+	for curr := i; curr != 0 || len(stack) > 0; {
+		if curr != 0 {
+			stack = append(stack, curr)
+			curr = t.node(curr).left
+			continue
+		}
+
+		curr = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n := t.node(curr)
+		next := n.right
+		nodes = append(nodes, n.Node)
+		t.members.delete(n.key)
+		t.memberBytes = max(t.memberBytes-len(n.key), 0)
+		t.release(curr)
+		curr = next
+	}
+
+	extracted := &Tree{}
+	extracted.build(nodes)
+	return extracted
+}
+
+// splitRank cuts the subtree root into its first k members and the others
+func (t *Tree) splitRank(root uint32, k int) (l, r uint32) {
+	if root == 0 {
+		return 0, 0
+	}
+
+	n := t.node(root)
+	left, right := n.left, n.right
+	if k <= t.count(left) {
+		ll, lr := t.splitRank(left, k)
+		return ll, t.joinWith(lr, root, right)
+	}
+
+	rl, rr := t.splitRank(right, k-t.count(left)-1)
+	return t.joinWith(left, root, rl), rr
+}
+
+// splitScore cuts the subtree root into its members with a score lower than score
+// and the others
+func (t *Tree) splitScore(root uint32, score float64) (l, r uint32) {
+	if root == 0 {
+		return 0, 0
+	}
+
+	n := t.node(root)
+	left, right := n.left, n.right
+	if n.score >= score {
+		ll, lr := t.splitScore(left, score)
+		return ll, t.joinWith(lr, root, right)
+	}
+
+	rl, rr := t.splitScore(right, score)
+	return t.joinWith(left, root, rl), rr
+}
+
+// join links the subtrees l and r, every member of l is ordered before r
+func (t *Tree) join(l, r uint32) uint32 {
+	if l == 0 {
+		return r
+	}
+
+	l, pivot := t.removeMax(l)
+	return t.joinWith(l, pivot, r)
+}
+
+// joinWith links the subtrees l and r under pivot, ordered between them. It walks down
+// the spine of the higher subtree to one of about the height of the other, so it costs
+// the difference of their heights
+func (t *Tree) joinWith(l, pivot, r uint32) uint32 {
+	if hl, hr := t.height(l), t.height(r); hl > hr+1 {
+		n := t.node(l)
+		n.right = t.joinWith(n.right, pivot, r)
+		t.update(l)
+		return t.rebalance(l)
+	} else if hr > hl+1 {
+		n := t.node(r)
+		n.left = t.joinWith(l, pivot, n.left)
+		t.update(r)
+		return t.rebalance(r)
+	}
+
+	n := t.node(pivot)
+	n.left, n.right = l, r
+	t.update(pivot)
+	return pivot
+}
+
+// removeMax unlinks the last node of the subtree root and returns it
+func (t *Tree) removeMax(root uint32) (newRoot, maxNode uint32) {
+	n := t.node(root)
+	if n.right == 0 {
+		return n.left, root
+	}
+
+	n.right, maxNode = t.removeMax(n.right)
+	t.update(root)
+	return t.rebalance(root), maxNode
+}
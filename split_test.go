@@ -0,0 +1,439 @@
+//go:build unit
+
+package zdb
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// randomNodes returns up to n unique members sorted by score then member, the scores
+// are drawn from few values so many members share a score
+func randomNodes(r *rand.Rand, n int) []Node {
+	nodes := []Node{}
+	for _, i := range r.Perm(n)[:r.IntN(n+1)] {
+		nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(r.IntN(n/4 + 1))})
+	}
+
+	slices.SortFunc(nodes, compareNodes)
+	return nodes
+}
+
+func checkSet(t *testing.T, tree OrderStatisticTree, want []Node) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+	if got := tree.RangeByIndex(0, tree.Len()-1); tree.Len() != len(want) || !equalNodes(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, node := range want {
+		if score, err := tree.GetScore(node.key); err != nil || score != node.score {
+			t.Fatalf("GetScore(%q) got %v %v, want %v", node.key, score, err, node.score)
+		}
+	}
+}
+
+func TestSplitByRank(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		r := rand.New(rand.NewPCG(1, 2))
+		for range 300 {
+			nodes := randomNodes(r, 200)
+			tree := newTree()
+			tree.AddBatch(nodes)
+
+			// ranks out of range keep every member on one side
+			rank := r.IntN(len(nodes)+4) - 1
+			k := min(max(rank, 1), len(nodes)+1) - 1
+			split := tree.SplitByRank(rank)
+			checkSet(t, tree, nodes[:k])
+			checkSet(t, split, nodes[k:])
+
+			if err := tree.Join(split); err != nil {
+				t.Fatalf("Join got err %v", err)
+			}
+			checkSet(t, tree, nodes)
+			checkSet(t, split, nil)
+		}
+	})
+}
+
+func TestSplitByScore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		r := rand.New(rand.NewPCG(3, 4))
+		for range 300 {
+			nodes := randomNodes(r, 200)
+			tree := newTree()
+			tree.AddBatch(nodes)
+
+			score := float64(r.IntN(60)) - 2
+			k := slices.IndexFunc(nodes, func(node Node) bool { return node.score >= score })
+			if k < 0 {
+				k = len(nodes)
+			}
+			split := tree.SplitByScore(score)
+			checkSet(t, tree, nodes[:k])
+			checkSet(t, split, nodes[k:])
+
+			// the members are added after the split to check both sides stay usable
+			tree.Add("low", -10)
+			split.Add("high", 100)
+			if err := tree.Join(split); err != nil {
+				t.Fatalf("Join got err %v", err)
+			}
+			want := slices.Concat([]Node{{key: "low", score: -10}}, nodes, []Node{{key: "high", score: 100}})
+			checkSet(t, tree, want)
+		}
+	})
+}
+
+func TestJoin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		r := rand.New(rand.NewPCG(5, 6))
+		for range 300 {
+			nodes := randomNodes(r, 300)
+			k := r.IntN(len(nodes) + 1)
+			tree, other := newTree(), backends[r.IntN(len(backends))].New()
+			tree.AddBatch(nodes[:k])
+			other.AddBatch(nodes[k:])
+
+			if err := tree.Join(other); err != nil {
+				t.Fatalf("Join got err %v", err)
+			}
+			checkSet(t, tree, nodes)
+			checkSet(t, other, nil)
+		}
+	})
+}
+
+func TestJoinOrder(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Set     []Node
+		Other   []Node
+		WantErr error
+	}{
+		{
+			Name:    "Lower score",
+			Set:     []Node{{key: "A", score: 2}, {key: "B", score: 3}},
+			Other:   []Node{{key: "C", score: 1}},
+			WantErr: errJoinOrder,
+		},
+		{
+			Name:    "Same score lower member",
+			Set:     []Node{{key: "A", score: 1}, {key: "C", score: 2}},
+			Other:   []Node{{key: "B", score: 2}, {key: "D", score: 5}},
+			WantErr: errJoinOrder,
+		},
+		{
+			Name:    "Overlapping member",
+			Set:     []Node{{key: "A", score: 1}, {key: "B", score: 2}},
+			Other:   []Node{{key: "B", score: 2}},
+			WantErr: errJoinOrder,
+		},
+		{
+			Name:    "Same member at a higher score",
+			Set:     []Node{{key: "A", score: 1}},
+			Other:   []Node{{key: "A", score: 5}},
+			WantErr: errJoinMember,
+		},
+		{
+			Name:    "Member of the bigger set at a higher score",
+			Set:     []Node{{key: "A", score: 1}, {key: "B", score: 2}, {key: "C", score: 3}},
+			Other:   []Node{{key: "B", score: 7}},
+			WantErr: errJoinMember,
+		},
+		{
+			Name:    "Member of the smaller set at a higher score",
+			Set:     []Node{{key: "C", score: 1}},
+			Other:   []Node{{key: "A", score: 2}, {key: "B", score: 3}, {key: "C", score: 4}},
+			WantErr: errJoinMember,
+		},
+	}
+
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		for _, test := range tests {
+			t.Run(test.Name, func(t *testing.T) {
+				tree, other := newTree(), newTree()
+				tree.AddBatch(test.Set)
+				other.AddBatch(test.Other)
+
+				if err := tree.Join(other); !errors.Is(err, test.WantErr) {
+					t.Fatalf("Join got err %v, want %v", err, test.WantErr)
+				}
+				checkSet(t, tree, test.Set)
+				checkSet(t, other, test.Other)
+			})
+		}
+	})
+}
+
+// sharingBackends share the member map between the sides of a big split
+var sharingBackends = backends[:2]
+
+// TestSplitJoinShared splits sets big enough to share their member map, and slabs for
+// trees, then adds, removes and joins members across them against plain maps
+func TestSplitJoinShared(t *testing.T) {
+	forEachSharingBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		r := rand.New(rand.NewPCG(7, 8))
+		nodes := []Node{}
+		for i := range 8000 {
+			nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(r.IntN(2000))})
+		}
+		slices.SortFunc(nodes, compareNodes)
+
+		tree := newTree()
+		tree.AddBatch(nodes)
+		sets := []OrderStatisticTree{tree}
+		wants := []map[string]float64{members(nodes)}
+
+		for range 400 {
+			i := r.IntN(len(sets))
+			set, want := sets[i], wants[i]
+			switch op := r.IntN(10); {
+			case op < 2:
+				var split OrderStatisticTree
+				if r.IntN(2) == 0 {
+					split = set.SplitByRank(r.IntN(set.Len() + 1))
+				} else {
+					split = set.SplitByScore(float64(r.IntN(2000)))
+				}
+				sets = append(sets, split)
+				wants = append(wants, members(split.RangeByIndex(0, split.Len()-1)))
+				for key := range wants[len(wants)-1] {
+					delete(want, key)
+				}
+			case op < 4:
+				j := r.IntN(len(sets))
+				if i == j {
+					continue
+				}
+				err := set.Join(sets[j])
+				if wantErr := naiveJoinErr(want, wants[j]); !errors.Is(err, wantErr) {
+					t.Fatalf("Join got err %v, want %v", err, wantErr)
+				}
+				if err == nil {
+					for key, score := range wants[j] {
+						want[key] = score
+					}
+					clear(wants[j])
+				}
+				checkMembers(t, sets[j], wants[j])
+			case op < 8:
+				key, score := strconv.Itoa(r.IntN(10000)), float64(r.IntN(2000))
+				set.Add(key, score)
+				want[key] = score
+			default:
+				key := strconv.Itoa(r.IntN(10000))
+				set.Remove(key)
+				delete(want, key)
+			}
+			checkMembers(t, set, want)
+		}
+	})
+}
+
+// TestSplitConcurrent writes to trees split from the same tree from different goroutines,
+// the race detector checks that they don't touch each other's nodes
+func TestSplitConcurrent(t *testing.T) {
+	forEachSharingBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		nodes := []Node{}
+		for i := range 20000 {
+			nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(i)})
+		}
+
+		tree := newTree()
+		tree.AddBatch(nodes)
+		sets := []OrderStatisticTree{tree}
+		for _, score := range []float64{5000, 10000, 15000} {
+			sets = append(sets, sets[len(sets)-1].SplitByScore(score))
+		}
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i, set := range sets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := range 3000 {
+					set.Add("new"+strconv.Itoa(i*5000+j), float64(i*5000+j)+0.5)
+				}
+				for j := range 3000 {
+					set.Remove(strconv.Itoa(i*5000 + j))
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		want := []Node{}
+		for i := range 4 {
+			for j := range 5000 {
+				if j < 3000 {
+					want = append(want, Node{key: "new" + strconv.Itoa(i*5000+j), score: float64(i*5000+j) + 0.5})
+				} else {
+					want = append(want, nodes[i*5000+j])
+				}
+			}
+		}
+		for _, set := range sets[1:] {
+			if err := tree.Join(set); err != nil {
+				t.Fatalf("Join got err %v", err)
+			}
+		}
+		checkSet(t, tree, want)
+	})
+}
+
+// TestSplitJoinAllocs checks that split and join don't copy members
+func TestSplitJoinAllocs(t *testing.T) {
+	forEachSharingBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		nodes := []Node{}
+		for i := range 100000 {
+			nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(i)})
+		}
+		tree := newTree()
+		tree.AddBatch(nodes)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			split := tree.SplitByRank(splitCopyMax + 2 + rand.IntN(len(nodes)-2*splitCopyMax-2))
+			if err := tree.Join(split); err != nil {
+				t.Fatalf("Join got err %v", err)
+			}
+		})
+		if allocs > 20 {
+			t.Errorf("got %v allocations, want at most 20", allocs)
+		}
+		checkSet(t, tree, nodes)
+	})
+}
+
+func forEachSharingBackend(t *testing.T, test func(t *testing.T, newTree func() OrderStatisticTree)) {
+	for _, backend := range sharingBackends {
+		t.Run(backend.Name, func(t *testing.T) {
+			test(t, backend.New)
+		})
+	}
+}
+
+func members(nodes []Node) map[string]float64 {
+	scores := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		scores[node.key] = node.score
+	}
+	return scores
+}
+
+// naiveJoinErr is the error of joining the members of other to set
+func naiveJoinErr(set, other map[string]float64) error {
+	var last, first *Node
+	for key, score := range set {
+		if node := (Node{key: key, score: score}); last == nil || compareNode(&node, last) > 0 {
+			last = &node
+		}
+	}
+	for key, score := range other {
+		if node := (Node{key: key, score: score}); first == nil || compareNode(&node, first) < 0 {
+			first = &node
+		}
+	}
+	if last != nil && first != nil && compareNode(last, first) >= 0 {
+		return errJoinOrder
+	}
+
+	for key := range other {
+		if _, exists := set[key]; exists {
+			return errJoinMember
+		}
+	}
+	return nil
+}
+
+func checkMembers(t *testing.T, tree OrderStatisticTree, want map[string]float64) {
+	t.Helper()
+	nodes := []Node{}
+	for key, score := range want {
+		nodes = append(nodes, Node{key: key, score: score})
+	}
+	slices.SortFunc(nodes, compareNodes)
+	checkSet(t, tree, nodes)
+}
+
+func TestTreeSplitShare(t *testing.T) {
+	nodes := []Node{}
+	for i := range 4000 {
+		nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(i)})
+	}
+	tree := NewTree().(*Tree)
+	tree.AddBatch(nodes)
+
+	split := tree.SplitByRank(2001).(*Tree)
+	if tree.family == nil || split.family != tree.family || !split.members.sameBase(&tree.members) {
+		t.Fatalf("split tree does not share the slabs and member map")
+	}
+
+	// the freed nodes of both sides are reused after the join
+	for i := range 100 {
+		tree.Remove(strconv.Itoa(i))
+		split.Remove(strconv.Itoa(2000 + i))
+	}
+	if err := tree.Join(split); err != nil {
+		t.Fatalf("Join got err %v", err)
+	}
+	slabs := len(tree.slabs)
+	for i := range 200 {
+		tree.Add("new"+strconv.Itoa(i), float64(4000+i))
+	}
+	if len(tree.slabs) != slabs {
+		t.Errorf("got %d slabs, want %d", len(tree.slabs), slabs)
+	}
+
+	want := slices.Concat(nodes[100:2000], nodes[2100:])
+	for i := range 200 {
+		want = append(want, Node{key: "new" + strconv.Itoa(i), score: float64(4000 + i)})
+	}
+	checkSet(t, tree, want)
+
+	// outnumbered by the writes since the split, the tree gets slabs of its own
+	for i := range 4000 {
+		tree.Add("more"+strconv.Itoa(i), float64(5000+i))
+	}
+	if tree.family != nil || tree.members.shared() {
+		t.Errorf("tree still shares its slabs or member map")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("got err %v", err)
+	}
+}
+
+func TestSkipListSplitShare(t *testing.T) {
+	nodes := []Node{}
+	for i := range 4000 {
+		nodes = append(nodes, Node{key: strconv.Itoa(i), score: float64(i)})
+	}
+	list := NewSkipList().(*SkipList)
+	list.AddBatch(nodes)
+
+	split := list.SplitByScore(2000).(*SkipList)
+	if !split.members.sameBase(&list.members) {
+		t.Fatalf("split list does not share the member map")
+	}
+
+	for i := range 2500 {
+		split.Add("new"+strconv.Itoa(i), float64(4000+i))
+	}
+	if split.members.shared() {
+		t.Errorf("list still shares its member map")
+	}
+	want := slices.Clone(nodes[2000:])
+	for i := range 2500 {
+		want = append(want, Node{key: "new" + strconv.Itoa(i), score: float64(4000 + i)})
+	}
+	checkSet(t, split, want)
+	checkSet(t, list, nodes[:2000])
+}
@@ -0,0 +1,407 @@
+package tcp
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+type client struct {
+	id        int64
+	name      string
+	conn      net.Conn
+	raddr     string
+	laddr     string
+	createdAt time.Time
+	reader    *miniresp3.Reader
+	writer    *miniresp3.Writer
+	out       *outputQueue
+
+	// only touched by the goroutine running the commands of the client, the event
+	// loop or in threaded mode the connection goroutine
+	closeAfterReply bool
+	softLimitSince  time.Time
+
+	// shared between the connection goroutine and the event loop
+	mu              sync.Mutex
+	lastCmd         string
+	lastInteraction time.Time
+	queryBuf        atomic.Int64
+	monitor         atomic.Bool
+}
+
+func newClient(id int64, conn net.Conn) *client {
+	now := time.Now()
+	out := newOutputQueue(conn)
+	writer := miniresp3.NewWriter(out)
+	writer.SetProtocol(miniresp3.RESP2)
+
+	return &client{
+		id:              id,
+		conn:            conn,
+		raddr:           conn.RemoteAddr().String(),
+		laddr:           conn.LocalAddr().String(),
+		createdAt:       now,
+		lastInteraction: now,
+		reader:          miniresp3.NewReader(conn),
+		writer:          writer,
+		out:             out,
+	}
+}
+
+func (c *client) touch(cmd string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastCmd = cmd
+	c.lastInteraction = now
+}
+
+func (c *client) info() string {
+	now := time.Now()
+	flags := "N"
+	if c.monitor.Load() {
+		flags = "O"
+	}
+
+	c.mu.Lock()
+	lastCmd, lastInteraction := c.lastCmd, c.lastInteraction
+	c.mu.Unlock()
+
+	sb := strings.Builder{}
+	sb.WriteString("id=" + strconv.FormatInt(c.id, 10))
+	sb.WriteString(" addr=" + c.raddr)
+	sb.WriteString(" laddr=" + c.laddr)
+	sb.WriteString(" name=" + c.name)
+	sb.WriteString(" age=" + strconv.Itoa(int(now.Sub(c.createdAt).Seconds())))
+	sb.WriteString(" idle=" + strconv.Itoa(int(now.Sub(lastInteraction).Seconds())))
+	sb.WriteString(" flags=" + flags)
+	sb.WriteString(" db=0")
+	sb.WriteString(" qbuf=" + strconv.FormatInt(c.queryBuf.Load(), 10))
+	sb.WriteString(" omem=" + strconv.Itoa(c.out.Len()))
+	sb.WriteString(" cmd=" + lastCmd)
+
+	return sb.String()
+}
+
+type clientRegistry struct {
+	mu      sync.Mutex
+	nextID  int64
+	clients map[int64]*client
+}
+
+func newClientRegistry() *clientRegistry {
+	return &clientRegistry{
+		clients: map[int64]*client{},
+	}
+}
+
+// register adds a client for conn, unless maxClients are already registered.
+// Zero means unlimited
+func (cr *clientRegistry) register(conn net.Conn, maxClients int) (*client, bool) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if maxClients > 0 && len(cr.clients) >= maxClients {
+		return nil, false
+	}
+
+	cr.nextID++
+	c := newClient(cr.nextID, conn)
+	cr.clients[c.id] = c
+	return c, true
+}
+
+func (cr *clientRegistry) len() int {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	return len(cr.clients)
+}
+
+func (cr *clientRegistry) unregister(c *client) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	delete(cr.clients, c.id)
+}
+
+// list returns the connected clients ordered by id
+func (cr *clientRegistry) list() []*client {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	clients := make([]*client, 0, len(cr.clients))
+	for _, c := range cr.clients {
+		clients = append(clients, c)
+	}
+	slices.SortFunc(clients, func(a, b *client) int {
+		return int(a.id - b.id)
+	})
+
+	return clients
+}
+
+// outputBufferExceeded checks the reply being built and the replies not yet written
+// to the connection against the output buffer limits
+func (srv *Server) outputBufferExceeded(c *client) bool {
+	pending := c.writer.Len() + c.out.Len()
+	if hardLimit := srv.config.ClientOutputBufferHardLimit; c.writer.Overflowed() || (hardLimit > 0 && pending > hardLimit) {
+		return true
+	}
+
+	softLimit := srv.config.ClientOutputBufferSoftLimit
+	if softLimit <= 0 || pending <= softLimit {
+		c.softLimitSince = time.Time{}
+		return false
+	}
+
+	if c.softLimitSince.IsZero() {
+		c.softLimitSince = time.Now()
+		return false
+	}
+
+	return time.Since(c.softLimitSince) > srv.config.ClientOutputBufferSoftSeconds
+}
+
+type pauseState struct {
+	writesOnly bool
+	timer      *time.Timer
+	pending    []*eventCmd
+}
+
+func (ps *pauseState) timeout() <-chan time.Time {
+	if ps.timer == nil {
+		return nil
+	}
+
+	return ps.timer.C
+}
+
+func (ps *pauseState) blocks(ev *eventCmd) bool {
+	if ps.timer == nil {
+		return false
+	}
+
+	// keep the reply ordering of clients that already have paused commands
+	for _, pending := range ps.pending {
+		if pending.client == ev.client {
+			return true
+		}
+	}
+
+	for _, evcmd := range ev.cmd {
+		// CLIENT commands are never paused so CLIENT UNPAUSE can get through
+		if evcmd.name == "client" {
+			continue
+		}
+
+		if !ps.writesOnly {
+			return true
+		}
+		if cmd, ok := lookupCommand(evcmd.name); ok && cmd.hasFlag(flagWrite) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CLIENT LIST | KILL | SETNAME | GETNAME | ID | INFO | PAUSE | UNPAUSE
+
+func (srv *Server) execClient(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) < 1 {
+		ev.writer.AppendSimpleError(wrongArityError("client"))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		sb := strings.Builder{}
+		for _, c := range srv.clients.list() {
+			sb.WriteString(c.info())
+			sb.WriteByte('\n')
+		}
+		ev.writer.AppendBulkStr(sb.String())
+	case "info":
+		ev.writer.AppendBulkStr(ev.client.info() + "\n")
+	case "id":
+		ev.writer.AppendInt(int(ev.client.id))
+	case "setname":
+		if len(args) != 2 {
+			ev.writer.AppendSimpleError(wrongArityError("client|setname"))
+			return
+		}
+		if strings.ContainsAny(args[1], " \n") {
+			ev.writer.AppendSimpleError("ERR Client names cannot contain spaces, newlines or special characters.")
+			return
+		}
+		ev.client.name = args[1]
+		ev.writer.AppendSimpleStr("OK")
+	case "getname":
+		if ev.client.name == "" {
+			ev.writer.AppendNil()
+			return
+		}
+		ev.writer.AppendBulkStr(ev.client.name)
+	case "kill":
+		srv.execClientKill(ev, args[1:])
+	case "pause":
+		srv.execClientPause(ev, args[1:])
+	case "unpause":
+		srv.unpause()
+		ev.writer.AppendSimpleStr("OK")
+	default:
+		ev.writer.AppendSimpleError("ERR unknown subcommand '" + args[0] + "'")
+	}
+}
+
+// CLIENT KILL addr:port
+// CLIENT KILL [ID client-id] [ADDR addr:port] [LADDR addr:port] [SKIPME yes|no]
+
+func (srv *Server) execClientKill(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) == 0 {
+		ev.writer.AppendSimpleError(wrongArityError("client|kill"))
+		return
+	}
+
+	// old form, kill by address and reply OK
+	if len(args) == 1 {
+		for _, c := range srv.clients.list() {
+			if c.raddr == args[0] {
+				srv.killClient(ev, c)
+				ev.writer.AppendSimpleStr("OK")
+				return
+			}
+		}
+		ev.writer.AppendSimpleError("ERR No such client")
+		return
+	}
+
+	if len(args)%2 != 0 {
+		ev.writer.AppendSimpleError("ERR syntax error")
+		return
+	}
+
+	var (
+		id     int64
+		raddr  string
+		laddr  string
+		skipMe = true
+	)
+	for i := 0; i < len(args); i += 2 {
+		val := args[i+1]
+		switch strings.ToLower(args[i]) {
+		case "id":
+			parsed, err := strconv.ParseInt(val, 10, 64)
+			if err != nil || parsed <= 0 {
+				ev.writer.AppendSimpleError("ERR client-id should be greater than 0")
+				return
+			}
+			id = parsed
+		case "addr":
+			raddr = val
+		case "laddr":
+			laddr = val
+		case "skipme":
+			switch strings.ToLower(val) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				ev.writer.AppendSimpleError("ERR syntax error")
+				return
+			}
+		default:
+			ev.writer.AppendSimpleError("ERR syntax error")
+			return
+		}
+	}
+
+	killed := 0
+	for _, c := range srv.clients.list() {
+		if (id != 0 && c.id != id) ||
+			(raddr != "" && c.raddr != raddr) ||
+			(laddr != "" && c.laddr != laddr) ||
+			(skipMe && c == ev.client) {
+			continue
+		}
+
+		srv.killClient(ev, c)
+		killed++
+	}
+
+	ev.writer.AppendInt(killed)
+}
+
+func (srv *Server) killClient(ev *eventCmd, c *client) {
+	// the caller still needs its reply
+	if c == ev.client {
+		c.closeAfterReply = true
+		return
+	}
+
+	c.conn.Close()
+}
+
+// CLIENT PAUSE timeout [WRITE | ALL]
+
+func (srv *Server) execClientPause(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) < 1 || len(args) > 2 {
+		ev.writer.AppendSimpleError(wrongArityError("client|pause"))
+		return
+	}
+
+	timeout, err := strconv.Atoi(args[0])
+	if err != nil || timeout < 0 {
+		ev.writer.AppendSimpleError("ERR timeout is not an integer or out of range")
+		return
+	}
+
+	writesOnly := false
+	if len(args) == 2 {
+		switch strings.ToLower(args[1]) {
+		case "write":
+			writesOnly = true
+		case "all":
+			writesOnly = false
+		default:
+			ev.writer.AppendSimpleError("ERR syntax error")
+			return
+		}
+	}
+
+	if srv.pause.timer != nil {
+		srv.pause.timer.Stop()
+	}
+	srv.pause.writesOnly = writesOnly
+	srv.pause.timer = time.NewTimer(time.Duration(timeout) * time.Millisecond)
+	srv.paused.Store(true)
+	ev.writer.AppendSimpleStr("OK")
+}
+
+func (srv *Server) unpause() {
+	if srv.pause.timer == nil {
+		return
+	}
+
+	srv.pause.timer.Stop()
+	srv.pause.timer = nil
+	srv.paused.Store(false)
+}
+
+func (srv *Server) resumePaused() {
+	for srv.pause.timer == nil && len(srv.pause.pending) > 0 {
+		ev := srv.pause.pending[0]
+		srv.pause.pending = srv.pause.pending[1:]
+		srv.processEvent(ev, true)
+	}
+}
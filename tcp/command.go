@@ -0,0 +1,319 @@
+package tcp
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+const (
+	flagWrite       = "write"
+	flagReadonly    = "readonly"
+	flagDenyOOM     = "denyoom"
+	flagAdmin       = "admin"
+	flagFast        = "fast"
+	flagMovableKeys = "movablekeys"
+)
+
+type command struct {
+	name string
+	// positive arity is the exact number of arguments including the command
+	// name, negative arity is the minimum
+	arity int
+	flags []string
+	// position of the first and last key and the step between keys, counting the
+	// command name. A negative last key counts from the end, zero means no keys
+	firstKey int
+	lastKey  int
+	keyStep  int
+	// keys of commands with movablekeys, returns the positions of the keys
+	getKeys func(argv []string) []int
+	exec    func(srv *Server, ev *eventCmd, args commands.CmdArgs)
+	doc     commands.Doc
+}
+
+func (cmd *command) hasFlag(flag string) bool {
+	return slices.Contains(cmd.flags, flag)
+}
+
+func (cmd *command) checkArity(argc int) bool {
+	return (cmd.arity > 0 && argc == cmd.arity) || (cmd.arity < 0 && argc >= -cmd.arity)
+}
+
+// keys returns the keys in argv, which starts with the command name
+func (cmd *command) keys(argv []string) []string {
+	if cmd.getKeys != nil {
+		keys := []string{}
+		for _, pos := range cmd.getKeys(argv) {
+			keys = append(keys, argv[pos])
+		}
+		return keys
+	}
+
+	if cmd.firstKey == 0 {
+		return nil
+	}
+
+	last := cmd.lastKey
+	if last < 0 {
+		last = len(argv) + last
+	}
+
+	keys := []string{}
+	for pos := cmd.firstKey; pos <= last && pos < len(argv); pos += cmd.keyStep {
+		keys = append(keys, argv[pos])
+	}
+	return keys
+}
+
+// numKeysPositions finds the keys of commands like ZUNION [destination] numkeys key [key ...],
+// numKeysPos is the position of numkeys in argv
+func numKeysPositions(numKeysPos int) func(argv []string) []int {
+	return func(argv []string) []int {
+		positions := []int{}
+		for pos := 1; pos < numKeysPos; pos++ {
+			positions = append(positions, pos)
+		}
+
+		numKeys, err := strconv.Atoi(argv[numKeysPos])
+		if err != nil || numKeys < 0 || numKeysPos+numKeys >= len(argv) {
+			return positions
+		}
+		for pos := numKeysPos + 1; pos <= numKeysPos+numKeys; pos++ {
+			positions = append(positions, pos)
+		}
+		return positions
+	}
+}
+
+// subcommandKey finds the key of commands like OBJECT ENCODING key
+func subcommandKey(argv []string) []int {
+	if len(argv) < 3 {
+		return nil
+	}
+	return []int{2}
+}
+
+var commandTable map[string]*command
+
+// the table is filled in init because COMMAND reads it
+func init() {
+	commandTable = map[string]*command{}
+	for _, cmd := range []*command{
+		{name: "command", arity: -1, flags: []string{flagFast}, exec: (*Server).execCommand},
+		{name: "hello", arity: -1, flags: []string{flagFast}, exec: wrap((*Server).execHello)},
+		{name: "echo", arity: 2, flags: []string{flagFast}, exec: (*Server).execEcho},
+		{name: "ping", arity: -1, flags: []string{flagFast}, exec: (*Server).execPing},
+		{name: "info", arity: -1, exec: wrap((*Server).execInfo)},
+		{name: "shards", arity: 1, exec: (*Server).execShards},
+		{name: "client", arity: -2, exec: (*Server).execClient},
+		{name: "monitor", arity: 1, flags: []string{flagAdmin}, exec: (*Server).execMonitor},
+		{name: "slowlog", arity: -2, flags: []string{flagAdmin}, exec: (*Server).execSlowlog},
+		{name: "latency", arity: -2, flags: []string{flagAdmin}, exec: (*Server).execLatency},
+		{name: "debug", arity: -2, flags: []string{flagAdmin, flagMovableKeys}, getKeys: subcommandKey, exec: wrap((*Server).execDebug)},
+		{name: "memory", arity: -2, flags: []string{flagReadonly, flagMovableKeys}, getKeys: subcommandKey, exec: wrap((*Server).execMemory)},
+		{name: "object", arity: -2, flags: []string{flagReadonly, flagMovableKeys}, getKeys: subcommandKey, exec: wrap((*Server).execObject)},
+		{name: "scan", arity: -2, flags: []string{flagReadonly}, exec: wrap((*Server).execScan)},
+		{name: "expire", arity: 3, flags: []string{flagWrite, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execExpire)},
+		{name: "ttl", arity: 2, flags: []string{flagReadonly, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execTTL)},
+		{name: "persist", arity: 2, flags: []string{flagWrite, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execPersist)},
+		{name: "zadd", arity: -4, flags: []string{flagWrite, flagDenyOOM, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZAdd)},
+		{name: "zcard", arity: 2, flags: []string{flagReadonly, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZCard)},
+		{name: "zcount", arity: 4, flags: []string{flagReadonly, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZCount)},
+		{name: "zdiff", arity: -3, flags: []string{flagReadonly, flagMovableKeys}, getKeys: numKeysPositions(1), exec: wrap((*Server).execZDiff)},
+		{name: "zdiffstore", arity: -4, flags: []string{flagWrite, flagDenyOOM, flagMovableKeys}, firstKey: 1, lastKey: 1, keyStep: 1, getKeys: numKeysPositions(2), exec: wrap((*Server).execZDiffStore)},
+		{name: "zinter", arity: -3, flags: []string{flagReadonly, flagMovableKeys}, getKeys: numKeysPositions(1), exec: wrap((*Server).execZInter)},
+		{name: "zinterstore", arity: -4, flags: []string{flagWrite, flagDenyOOM, flagMovableKeys}, firstKey: 1, lastKey: 1, keyStep: 1, getKeys: numKeysPositions(2), exec: wrap((*Server).execZInterStore)},
+		{name: "zunion", arity: -3, flags: []string{flagReadonly, flagMovableKeys}, getKeys: numKeysPositions(1), exec: wrap((*Server).execZUnion)},
+		{name: "zunionstore", arity: -4, flags: []string{flagWrite, flagDenyOOM, flagMovableKeys}, firstKey: 1, lastKey: 1, keyStep: 1, getKeys: numKeysPositions(2), exec: wrap((*Server).execZUnionStore)},
+		{name: "zrange", arity: -4, flags: []string{flagReadonly}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZRange)},
+		{name: "zrank", arity: 3, flags: []string{flagReadonly, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZRank)},
+		{name: "zrem", arity: -3, flags: []string{flagWrite, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZRem)},
+		{name: "zscan", arity: -3, flags: []string{flagReadonly}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZScan)},
+		{name: "zscore", arity: 3, flags: []string{flagReadonly, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZScore)},
+		{name: "zsetcap", arity: -2, flags: []string{flagWrite, flagFast}, firstKey: 1, lastKey: 1, keyStep: 1, exec: wrap((*Server).execZSetCap)},
+	} {
+		cmd.doc, _ = commands.LookupDoc(cmd.name)
+		commandTable[cmd.name] = cmd
+	}
+}
+
+// wrap turns a handler taking a parsed command into a handler taking the raw
+// arguments, invalid arguments are replied with the Build error
+func wrap[T any, PT interface {
+	*T
+	commands.CmdAble
+}](exec func(srv *Server, ev *eventCmd, cmd PT)) func(srv *Server, ev *eventCmd, args commands.CmdArgs) {
+	return func(srv *Server, ev *eventCmd, args commands.CmdArgs) {
+		cmd := PT(new(T))
+		if err := cmd.Build(args); err != nil {
+			ev.writer.AppendSimpleError(err.Error())
+			return
+		}
+		exec(srv, ev, cmd)
+	}
+}
+
+func lookupCommand(name string) (*command, bool) {
+	cmd, ok := commandTable[name]
+	return cmd, ok
+}
+
+func unknownCommandError(evcmd dataCmd) string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "ERR unknown command '%s', with args beginning with: ", evcmd.name)
+	for _, arg := range evcmd.args {
+		fmt.Fprintf(&sb, "'%s' ", arg)
+	}
+	return sb.String()
+}
+
+func wrongArityError(name string) string {
+	return fmt.Sprintf("ERR wrong number of arguments for '%s' command", name)
+}
+
+// COMMAND [COUNT | INFO [command ...] | DOCS [command ...] | GETKEYS command [arg ...]]
+
+func (srv *Server) execCommand(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) == 0 {
+		ev.writer.AppendArrHeader(len(commandTable))
+		for _, name := range sortedCommandNames() {
+			writeCommandInfo(ev, commandTable[name])
+		}
+		return
+	}
+
+	switch subcommand := strings.ToLower(args[0]); subcommand {
+	case "count":
+		ev.writer.AppendInt(len(commandTable))
+	case "info":
+		names := args[1:]
+		if len(names) == 0 {
+			names = sortedCommandNames()
+		}
+		ev.writer.AppendArrHeader(len(names))
+		for _, name := range names {
+			if cmd, ok := lookupCommand(strings.ToLower(name)); ok {
+				writeCommandInfo(ev, cmd)
+			} else {
+				ev.writer.AppendNil()
+			}
+		}
+	case "docs":
+		cmds := []*command{}
+		names := args[1:]
+		if len(names) == 0 {
+			names = sortedCommandNames()
+		}
+		for _, name := range names {
+			if cmd, ok := lookupCommand(strings.ToLower(name)); ok {
+				cmds = append(cmds, cmd)
+			}
+		}
+
+		ev.writer.AppendMapHeader(len(cmds))
+		for _, cmd := range cmds {
+			ev.writer.AppendBulkStr(cmd.name)
+			ev.writer.AppendMapHeader(3)
+			ev.writer.AppendBulkStr("summary")
+			ev.writer.AppendBulkStr(cmd.doc.Summary)
+			ev.writer.AppendBulkStr("group")
+			ev.writer.AppendBulkStr(cmd.doc.Group)
+			ev.writer.AppendBulkStr("syntax")
+			ev.writer.AppendBulkStr(strings.TrimSpace(strings.ToUpper(cmd.name) + " " + cmd.doc.Syntax))
+		}
+	case "getkeys":
+		if len(args) < 2 {
+			ev.writer.AppendSimpleError(wrongArityError("command|getkeys"))
+			return
+		}
+
+		argv := args[1:]
+		cmd, ok := lookupCommand(strings.ToLower(argv[0]))
+		switch {
+		case !ok:
+			ev.writer.AppendSimpleError("ERR Invalid command specified")
+		case !cmd.checkArity(len(argv)):
+			ev.writer.AppendSimpleError("ERR Invalid number of arguments specified for command")
+		default:
+			keys := cmd.keys(argv)
+			if len(keys) == 0 {
+				ev.writer.AppendSimpleError("ERR The command has no key arguments")
+				return
+			}
+			ev.writer.AppendArrStr(keys)
+		}
+	default:
+		ev.writer.AppendSimpleError(fmt.Sprintf("ERR unknown subcommand '%s'. Try COMMAND HELP.", args[0]))
+	}
+}
+
+func sortedCommandNames() []string {
+	names := make([]string, 0, len(commandTable))
+	for name := range commandTable {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// writeCommandInfo writes the redis 7 command info: name, arity, flags, first key,
+// last key, step, ACL categories, tips, key specifications and subcommands
+func writeCommandInfo(ev *eventCmd, cmd *command) {
+	ev.writer.AppendArrHeader(10)
+	ev.writer.AppendBulkStr(cmd.name)
+	ev.writer.AppendInt(cmd.arity)
+
+	ev.writer.AppendSetHeader(len(cmd.flags))
+	for _, flag := range cmd.flags {
+		ev.writer.AppendSimpleStr(flag)
+	}
+
+	ev.writer.AppendInt(cmd.firstKey)
+	ev.writer.AppendInt(cmd.lastKey)
+	ev.writer.AppendInt(cmd.keyStep)
+
+	categories := aclCategories(cmd)
+	ev.writer.AppendSetHeader(len(categories))
+	for _, category := range categories {
+		ev.writer.AppendSimpleStr(category)
+	}
+
+	ev.writer.AppendArrHeader(0)
+	ev.writer.AppendArrHeader(0)
+	ev.writer.AppendArrHeader(0)
+}
+
+func aclCategories(cmd *command) []string {
+	categories := []string{}
+	switch cmd.doc.Group {
+	case "sorted-set":
+		categories = append(categories, "@sortedset")
+	case "generic":
+		categories = append(categories, "@keyspace")
+	case "connection":
+		categories = append(categories, "@connection")
+	}
+
+	switch {
+	case cmd.hasFlag(flagWrite):
+		categories = append(categories, "@write")
+	case cmd.hasFlag(flagReadonly):
+		categories = append(categories, "@read")
+	}
+
+	if cmd.hasFlag(flagAdmin) {
+		categories = append(categories, "@admin", "@dangerous")
+	}
+
+	if cmd.hasFlag(flagFast) {
+		categories = append(categories, "@fast")
+	} else {
+		categories = append(categories, "@slow")
+	}
+
+	return categories
+}
@@ -0,0 +1,89 @@
+//go:build unit
+
+package tcp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		Name string
+		Argv []string
+		Want []string
+	}{
+		{
+			Name: "Single key",
+			Argv: []string{"zadd", "k", "1", "m"},
+			Want: []string{"k"},
+		},
+		{
+			Name: "No keys",
+			Argv: []string{"ping"},
+			Want: nil,
+		},
+		{
+			Name: "Numkeys without destination",
+			Argv: []string{"zunion", "2", "a", "b", "WITHSCORES"},
+			Want: []string{"a", "b"},
+		},
+		{
+			Name: "Numkeys with destination",
+			Argv: []string{"zinterstore", "dst", "2", "a", "b", "WEIGHTS", "1", "2"},
+			Want: []string{"dst", "a", "b"},
+		},
+		{
+			Name: "Numkeys larger than the keys given",
+			Argv: []string{"zdiff", "3", "a", "b"},
+			Want: []string{},
+		},
+		{
+			Name: "Subcommand key",
+			Argv: []string{"object", "encoding", "k"},
+			Want: []string{"k"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cmd, ok := lookupCommand(test.Argv[0])
+			if !ok {
+				t.Fatalf("lookupCommand(%q) not found", test.Argv[0])
+			}
+			if !cmd.checkArity(len(test.Argv)) {
+				t.Fatalf("checkArity(%d) = false, want true", len(test.Argv))
+			}
+
+			got := cmd.keys(test.Argv)
+			if !slices.Equal(got, test.Want) {
+				t.Errorf("keys() = %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestCommandArity(t *testing.T) {
+	tests := []struct {
+		Name string
+		Argc int
+		Want bool
+	}{
+		{Name: "echo", Argc: 2, Want: true},
+		{Name: "echo", Argc: 1, Want: false},
+		{Name: "echo", Argc: 3, Want: false},
+		{Name: "zadd", Argc: 3, Want: false},
+		{Name: "zadd", Argc: 6, Want: true},
+		{Name: "ping", Argc: 1, Want: true},
+	}
+
+	for _, test := range tests {
+		cmd, ok := lookupCommand(test.Name)
+		if !ok {
+			t.Fatalf("lookupCommand(%q) not found", test.Name)
+		}
+		if got := cmd.checkArity(test.Argc); got != test.Want {
+			t.Errorf("%s checkArity(%d) = %v, want %v", test.Name, test.Argc, got, test.Want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tcp
+
+import (
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb"
+)
+
+type Config struct {
+	// commands taking longer than this are recorded in the slowlog, negative disables it
+	SlowlogLogSlowerThan time.Duration
+	SlowlogMaxLen        int
+
+	// commands and event loop cycles taking at least this long are recorded
+	// by the latency monitor, zero disables it
+	LatencyMonitorThreshold time.Duration
+
+	// new connections past this are refused, zero means unlimited
+	MaxClients int
+	// connections without any command for this long are closed, zero disables it
+	IdleTimeout time.Duration
+	// clients are disconnected once their pending reply goes past the hard limit,
+	// or stays past the soft limit for longer than soft seconds. Zero disables a limit
+	ClientOutputBufferHardLimit   int
+	ClientOutputBufferSoftLimit   int
+	ClientOutputBufferSoftSeconds time.Duration
+	// connections are closed once writing a reply makes no progress for this long,
+	// zero disables it
+	ClientWriteTimeout time.Duration
+
+	// bytes the keys may use before the eviction policy kicks in, zero means no limit
+	MaxMemory       int
+	MaxMemoryPolicy zdb.EvictionPolicy
+
+	// sorted sets are packed in a listpack until they have more members than
+	// ZSetMaxListpackEntries or a member longer than ZSetMaxListpackValue bytes,
+	// then converted to Backend
+	Backend                zdb.Backend
+	ZSetMaxListpackEntries int
+	ZSetMaxListpackValue   int
+
+	// commands on keys run on the connection goroutines, in parallel when their keys
+	// are on different shards, instead of on the event loop
+	Threaded bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SlowlogLogSlowerThan:    10 * time.Millisecond,
+		SlowlogMaxLen:           128,
+		LatencyMonitorThreshold: 0,
+		MaxClients:              10000,
+		IdleTimeout:             0,
+		ClientWriteTimeout:      10 * time.Second,
+		MaxMemory:               0,
+		MaxMemoryPolicy:         zdb.NoEviction,
+		Backend:                 zdb.AVLTreeBackend,
+		ZSetMaxListpackEntries:  zdb.DefaultListpackMaxEntries,
+		ZSetMaxListpackValue:    zdb.DefaultListpackMaxValue,
+	}
+}
+
+type Option func(*Config)
+
+func WithSlowlog(logSlowerThan time.Duration, maxLen int) Option {
+	return func(cfg *Config) {
+		cfg.SlowlogLogSlowerThan = logSlowerThan
+		cfg.SlowlogMaxLen = maxLen
+	}
+}
+
+func WithLatencyMonitorThreshold(threshold time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.LatencyMonitorThreshold = threshold
+	}
+}
+
+func WithMaxClients(maxClients int) Option {
+	return func(cfg *Config) {
+		cfg.MaxClients = maxClients
+	}
+}
+
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.IdleTimeout = timeout
+	}
+}
+
+func WithClientOutputBufferLimit(hard, soft int, softSeconds time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ClientOutputBufferHardLimit = hard
+		cfg.ClientOutputBufferSoftLimit = soft
+		cfg.ClientOutputBufferSoftSeconds = softSeconds
+	}
+}
+
+func WithClientWriteTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ClientWriteTimeout = timeout
+	}
+}
+
+func WithMaxMemory(maxMemory int, policy zdb.EvictionPolicy) Option {
+	return func(cfg *Config) {
+		cfg.MaxMemory = maxMemory
+		cfg.MaxMemoryPolicy = policy
+	}
+}
+
+func WithBackend(backend zdb.Backend) Option {
+	return func(cfg *Config) {
+		cfg.Backend = backend
+	}
+}
+
+func WithZSetMaxListpack(maxEntries, maxValue int) Option {
+	return func(cfg *Config) {
+		cfg.ZSetMaxListpackEntries = maxEntries
+		cfg.ZSetMaxListpackValue = maxValue
+	}
+}
+
+func WithThreaded(threaded bool) Option {
+	return func(cfg *Config) {
+		cfg.Threaded = threaded
+	}
+}
@@ -0,0 +1,81 @@
+package tcp
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+var infoSections = []string{"server", "clients", "memory", "stats", "keyspace"}
+
+func (srv *Server) execInfo(ev *eventCmd, cmd *commands.InfoCmd) {
+	sections := cmd.Sections
+	if len(sections) == 0 {
+		sections = infoSections
+	}
+
+	sb := strings.Builder{}
+	for _, section := range infoSections {
+		if !slices.Contains(sections, section) {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString("# " + strings.ToUpper(section[:1]) + section[1:] + "\r\n")
+		for _, field := range srv.infoSection(section) {
+			fmt.Fprintf(&sb, "%s:%v\r\n", field.name, field.value)
+		}
+	}
+
+	ev.writer.AppendVerbatimStr("txt", sb.String())
+}
+
+type infoField struct {
+	name  string
+	value interface{}
+}
+
+func (srv *Server) infoSection(section string) []infoField {
+	switch section {
+	case "server":
+		uptime := time.Since(srv.startTime)
+		return []infoField{
+			{"zdb_version", serverVersion},
+			{"process_id", os.Getpid()},
+			{"tcp_addr", srv.addr},
+			{"uptime_in_seconds", int(uptime.Seconds())},
+			{"uptime_in_days", int(uptime.Hours() / 24)},
+		}
+	case "clients":
+		return []infoField{
+			{"connected_clients", srv.clients.len()},
+			{"maxclients", srv.config.MaxClients},
+			{"monitor_clients", len(srv.monitors)},
+		}
+	case "memory":
+		return []infoField{
+			{"used_memory", srv.avlab.UsedMemory()},
+			{"maxmemory", srv.config.MaxMemory},
+			{"maxmemory_policy", srv.config.MaxMemoryPolicy},
+		}
+	case "stats":
+		return []infoField{
+			{"total_connections_received", srv.totalConnections.Load()},
+			{"total_commands_processed", srv.totalCommands.Load()},
+		}
+	case "keyspace":
+		keys := 0
+		for _, shardKeys := range srv.avlab.ShardStats() {
+			keys += shardKeys
+		}
+		return []infoField{{"db0", fmt.Sprintf("keys=%d", keys)}}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package tcp
+
+import "github.com/AdhityaRamadhanus/zdb/commands"
+
+func (srv *Server) execScan(ev *eventCmd, cmd *commands.ScanCmd) {
+	keys, nextCursor := srv.avlab.Scan(cmd)
+	ev.writer.AppendArrAny([]interface{}{nextCursor, keys})
+}
+
+func (srv *Server) execExpire(ev *eventCmd, cmd *commands.ExpireCmd) {
+	ev.writer.AppendInt(srv.avlab.Expire(cmd))
+}
+
+func (srv *Server) execTTL(ev *eventCmd, cmd *commands.TTLCmd) {
+	ev.writer.AppendInt(srv.avlab.TTL(cmd))
+}
+
+func (srv *Server) execPersist(ev *eventCmd, cmd *commands.PersistCmd) {
+	ev.writer.AppendInt(srv.avlab.Persist(cmd))
+}
+
+func (srv *Server) execMemory(ev *eventCmd, cmd *commands.MemoryCmd) {
+	usage, err := srv.avlab.MemoryUsage(cmd)
+	if err != nil {
+		ev.writer.AppendNil()
+		return
+	}
+	ev.writer.AppendInt(usage)
+}
+
+func (srv *Server) execObject(ev *eventCmd, cmd *commands.ObjectCmd) {
+	switch cmd.Subcommand {
+	case "encoding":
+		encoding, err := srv.avlab.ObjectEncoding(cmd)
+		if err != nil {
+			ev.writer.AppendNil()
+			return
+		}
+		ev.writer.AppendBulkStr(encoding)
+	case "freq":
+		freq, err := srv.avlab.ObjectFreq(cmd)
+		if err != nil {
+			ev.writer.AppendNil()
+			return
+		}
+		ev.writer.AppendInt(freq)
+	case "idletime":
+		idle, err := srv.avlab.ObjectIdleTime(cmd)
+		if err != nil {
+			ev.writer.AppendNil()
+			return
+		}
+		ev.writer.AppendInt(idle)
+	}
+}
+
+func (srv *Server) execDebug(ev *eventCmd, cmd *commands.DebugCmd) {
+	switch cmd.Subcommand {
+	case "zsetinfo":
+		info, err := srv.avlab.DebugZSetInfo(cmd)
+		if err != nil {
+			ev.writer.AppendSimpleError(err.Error())
+			return
+		}
+		ev.writer.AppendMap(map[string]interface{}{
+			"height":       info.Height,
+			"count":        info.Count,
+			"hashmap_size": info.HashMapSize,
+			"encoding":     info.Encoding,
+			"maxlen":       info.MaxLen,
+		})
+	case "zsetcheck":
+		if err := srv.avlab.DebugZSetCheck(cmd); err != nil {
+			ev.writer.AppendSimpleError(err.Error())
+			return
+		}
+		ev.writer.AppendSimpleStr("OK")
+	}
+}
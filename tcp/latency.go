@@ -0,0 +1,158 @@
+package tcp
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+const (
+	latencyHistoryLen = 160
+
+	latencyEventCommand   = "command"
+	latencyEventEventLoop = "event-loop"
+)
+
+type latencySample struct {
+	timestamp int64
+	latency   int
+}
+
+type latencyEvent struct {
+	history []latencySample
+	max     int
+}
+
+type latencyMonitor struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	events    map[string]*latencyEvent
+}
+
+func newLatencyMonitor(threshold time.Duration) *latencyMonitor {
+	return &latencyMonitor{
+		threshold: threshold,
+		events:    map[string]*latencyEvent{},
+	}
+}
+
+func (lm *latencyMonitor) record(event string, duration time.Duration) {
+	if lm.threshold <= 0 || duration < lm.threshold {
+		return
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	ev, exists := lm.events[event]
+	if !exists {
+		ev = &latencyEvent{}
+		lm.events[event] = ev
+	}
+
+	sample := latencySample{
+		timestamp: time.Now().Unix(),
+		latency:   int(duration.Milliseconds()),
+	}
+	ev.max = max(ev.max, sample.latency)
+
+	// samples within the same second are merged, keeping the highest latency
+	if last := len(ev.history) - 1; last >= 0 && ev.history[last].timestamp == sample.timestamp {
+		ev.history[last].latency = max(ev.history[last].latency, sample.latency)
+		return
+	}
+
+	ev.history = append(ev.history, sample)
+	if len(ev.history) > latencyHistoryLen {
+		ev.history = ev.history[1:]
+	}
+}
+
+func (lm *latencyMonitor) reset(events ...string) int {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if len(events) == 0 {
+		count := len(lm.events)
+		lm.events = map[string]*latencyEvent{}
+		return count
+	}
+
+	count := 0
+	for _, event := range events {
+		if _, exists := lm.events[event]; exists {
+			delete(lm.events, event)
+			count++
+		}
+	}
+
+	return count
+}
+
+func (lm *latencyMonitor) serializeLatest(writer *miniresp3.Writer) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	names := []string{}
+	for name := range lm.events {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	writer.AppendArrHeader(len(names))
+	for _, name := range names {
+		ev := lm.events[name]
+		latest := ev.history[len(ev.history)-1]
+		writer.AppendArrHeader(4)
+		writer.AppendBulkStr(name)
+		writer.AppendInt(int(latest.timestamp))
+		writer.AppendInt(latest.latency)
+		writer.AppendInt(ev.max)
+	}
+}
+
+func (lm *latencyMonitor) serializeHistory(writer *miniresp3.Writer, event string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	ev, exists := lm.events[event]
+	if !exists {
+		writer.AppendArrHeader(0)
+		return
+	}
+
+	writer.AppendArrHeader(len(ev.history))
+	for _, sample := range ev.history {
+		writer.AppendArrHeader(2)
+		writer.AppendInt(int(sample.timestamp))
+		writer.AppendInt(sample.latency)
+	}
+}
+
+// LATENCY LATEST | LATENCY HISTORY event | LATENCY RESET [event ...]
+
+func (srv *Server) execLatency(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) < 1 {
+		ev.writer.AppendSimpleError(wrongArityError("latency"))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "latest":
+		srv.latency.serializeLatest(ev.writer)
+	case "history":
+		if len(args) < 2 {
+			ev.writer.AppendSimpleError(wrongArityError("latency|history"))
+			return
+		}
+		srv.latency.serializeHistory(ev.writer, args[1])
+	case "reset":
+		ev.writer.AppendInt(srv.latency.reset(args[1:]...))
+	default:
+		ev.writer.AppendSimpleError("ERR unknown subcommand '" + args[0] + "'")
+	}
+}
@@ -0,0 +1,90 @@
+package tcp
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/rs/zerolog/log"
+)
+
+// MONITOR
+
+func (srv *Server) execMonitor(ev *eventCmd, args commands.CmdArgs) {
+	if ev.client.monitor.Load() {
+		ev.writer.AppendSimpleStr("OK")
+		return
+	}
+
+	ev.client.monitor.Store(true)
+	srv.monitors = append(srv.monitors, ev.client)
+	srv.monitoring.Store(true)
+	ev.writer.AppendSimpleStr("OK")
+}
+
+func (srv *Server) feedMonitors(ev *eventCmd, cmd dataCmd, timestamp time.Time) {
+	sb := strings.Builder{}
+	sb.WriteString(strconv.FormatFloat(float64(timestamp.UnixMicro())/1e6, 'f', 6, 64))
+	sb.WriteString(" [0 ")
+	sb.WriteString(ev.client.raddr)
+	sb.WriteString("]")
+	sb.WriteString(" ")
+	sb.WriteString(quoteMonitorArg(cmd.name))
+	for _, arg := range cmd.args {
+		sb.WriteString(" ")
+		sb.WriteString(quoteMonitorArg(arg))
+	}
+	line := sb.String()
+
+	monitors := srv.monitors[:0]
+	for _, monitor := range srv.monitors {
+		monitor.writer.AppendSimpleStr(line)
+		if err := monitor.writer.Write(); err != nil {
+			// the connection is gone, stop feeding it
+			log.Info().Err(err).Str("raddr", monitor.raddr).Msg("removing monitor")
+			continue
+		}
+		if srv.outputBufferExceeded(monitor) {
+			log.Warn().Str("raddr", monitor.raddr).Int("omem", monitor.out.Len()).Msg("closing monitor over output buffer limit")
+			monitor.conn.Close()
+			continue
+		}
+		monitors = append(monitors, monitor)
+	}
+	srv.monitors = monitors
+	srv.monitoring.Store(len(monitors) > 0)
+}
+
+func quoteMonitorArg(arg string) string {
+	sb := strings.Builder{}
+	sb.WriteByte('"')
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\', '"':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n':
+			sb.WriteString("\\n")
+		case '\r':
+			sb.WriteString("\\r")
+		case '\t':
+			sb.WriteString("\\t")
+		case '\a':
+			sb.WriteString("\\a")
+		case '\b':
+			sb.WriteString("\\b")
+		default:
+			if c < 0x20 || c > 0x7e {
+				sb.WriteString("\\x")
+				sb.WriteString(strconv.FormatUint(uint64(c)>>4, 16))
+				sb.WriteString(strconv.FormatUint(uint64(c)&0xf, 16))
+				continue
+			}
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+package tcp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// outputChunk is the most a single write to the connection sends, the write timeout
+// applies to every chunk so a big reply to a slow but alive client isn't cut off
+const outputChunk = 64 * 1024
+
+// outputQueue is the io.Writer behind the writer of a client. The replies are queued
+// so the event loop never waits on a slow consumer, a goroutine writes them to the
+// connection and closes it once a write makes no progress within timeout
+type outputQueue struct {
+	conn    net.Conn
+	timeout time.Duration
+	wake    chan struct{}
+
+	mu      sync.Mutex
+	pending []byte
+	spare   []byte
+	writing int
+	closed  bool
+	err     error
+}
+
+func newOutputQueue(conn net.Conn) *outputQueue {
+	return &outputQueue{
+		conn: conn,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (q *outputQueue) Write(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.err != nil {
+		return 0, q.err
+	}
+	if q.closed {
+		return 0, net.ErrClosed
+	}
+
+	q.pending = append(q.pending, p...)
+	q.signal()
+	return len(p), nil
+}
+
+// signal wakes the writing goroutine, q.mu must be held
+func (q *outputQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of bytes queued or being written
+func (q *outputQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending) + q.writing
+}
+
+// closeAfterFlush closes the connection once the queued replies are written
+func (q *outputQueue) closeAfterFlush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.signal()
+}
+
+// run writes the queued replies until the queue is closed or a write fails
+func (q *outputQueue) run() {
+	defer q.conn.Close()
+
+	for range q.wake {
+		if err := q.flush(); err != nil {
+			log.Info().Err(err).Str("raddr", q.conn.RemoteAddr().String()).Msg("closing connection, writing the reply failed")
+			return
+		}
+
+		q.mu.Lock()
+		closed := q.closed && len(q.pending) == 0
+		q.mu.Unlock()
+		if closed {
+			return
+		}
+	}
+}
+
+func (q *outputQueue) flush() error {
+	for {
+		q.mu.Lock()
+		buf := q.pending
+		q.pending, q.spare = q.spare[:0], nil
+		q.writing = len(buf)
+		q.mu.Unlock()
+
+		if len(buf) == 0 {
+			return nil
+		}
+
+		err := q.write(buf)
+
+		q.mu.Lock()
+		q.writing = 0
+		q.spare = buf[:0]
+		if err != nil {
+			q.err = err
+			q.pending = nil
+		}
+		q.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (q *outputQueue) write(buf []byte) error {
+	for len(buf) > 0 {
+		chunk := buf[:min(len(buf), outputChunk)]
+		if q.timeout > 0 {
+			q.conn.SetWriteDeadline(time.Now().Add(q.timeout))
+		}
+		n, err := q.conn.Write(chunk)
+		if err != nil {
+			return err
+		}
+
+		buf = buf[n:]
+		q.mu.Lock()
+		q.writing -= n
+		q.mu.Unlock()
+	}
+
+	return nil
+}
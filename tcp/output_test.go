@@ -0,0 +1,64 @@
+//go:build unit
+
+package tcp
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputQueue(t *testing.T) {
+	t.Run("Queues while the consumer doesn't read", func(t *testing.T) {
+		server, consumer := net.Pipe()
+		defer consumer.Close()
+		q := newOutputQueue(server)
+		go q.run()
+
+		reply := strings.Repeat("x", 1000)
+		for range 100 {
+			if _, err := q.Write([]byte(reply)); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+		}
+		if got := q.Len(); got != 100*len(reply) {
+			t.Errorf("got pending %d, want %d", got, 100*len(reply))
+		}
+
+		q.closeAfterFlush()
+		got, err := io.ReadAll(consumer)
+		if err != nil {
+			t.Fatalf("got err %v", err)
+		}
+		if len(got) != 100*len(reply) {
+			t.Errorf("got %d bytes, want %d", len(got), 100*len(reply))
+		}
+		if _, err := q.Write([]byte(reply)); err == nil {
+			t.Errorf("got no err writing to a closed queue")
+		}
+	})
+
+	t.Run("Closes a stuck consumer after the write timeout", func(t *testing.T) {
+		server, consumer := net.Pipe()
+		defer consumer.Close()
+		q := newOutputQueue(server)
+		q.timeout = 50 * time.Millisecond
+		done := make(chan struct{})
+		go func() {
+			q.run()
+			close(done)
+		}()
+
+		q.Write([]byte("+OK\r\n"))
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("queue still writing after the write timeout")
+		}
+		if _, err := q.Write([]byte("+OK\r\n")); err == nil {
+			t.Errorf("got no err writing after the write timeout")
+		}
+	})
+}
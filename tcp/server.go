@@ -2,23 +2,20 @@ package tcp
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/AdhityaRamadhanus/zdb"
 	"github.com/AdhityaRamadhanus/zdb/commands"
-	"github.com/AdhityaRamadhanus/zdb/encoding/resp"
 	"github.com/AdhityaRamadhanus/zdb/miniresp3"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
-var serverInfo = map[string]interface{}{
-	"server":  "zdb",
-	"proto":   3,
-	"version": "0.0.1",
-}
+const serverVersion = "0.0.1"
 
 type dataCmd struct {
 	name string
@@ -28,23 +25,56 @@ type dataCmd struct {
 type eventCmd struct {
 	cmd    []dataCmd
 	writer *miniresp3.Writer
+	client *client
+	// set when the connection sent malformed data after cmd
+	err error
+	// closed once the event is processed, set when the connection waits for the event loop
+	done chan struct{}
 }
 
 type Server struct {
-	avlab     zdb.ZDB
+	avlab     *zdb.ZDB
 	proto     string
 	addr      string
-	clients   int
+	clients   *clientRegistry
 	eventChan chan *eventCmd
+	config    Config
+	slowlog   *slowlog
+	latency   *latencyMonitor
+	monitors  []*client
+	pause     pauseState
+
+	// mirror monitors and pause for the connection goroutines in threaded mode
+	monitoring atomic.Bool
+	paused     atomic.Bool
+
+	startTime        time.Time
+	totalCommands    atomic.Int64
+	totalConnections atomic.Int64
 }
 
-func NewServer(proto, addr string) *Server {
-	return &Server{
-		avlab:     *zdb.NewZDB(16),
+func NewServer(proto, addr string, opts ...Option) *Server {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	srv := &Server{
+		avlab:     zdb.NewZDB(16),
 		proto:     proto,
 		addr:      addr,
+		clients:   newClientRegistry(),
 		eventChan: make(chan *eventCmd, 1000),
+		config:    config,
+		slowlog:   newSlowlog(config.SlowlogLogSlowerThan, config.SlowlogMaxLen),
+		latency:   newLatencyMonitor(config.LatencyMonitorThreshold),
+		startTime: time.Now(),
 	}
+	srv.avlab.SetMaxMemory(config.MaxMemory, config.MaxMemoryPolicy)
+	srv.avlab.SetBackend(config.Backend)
+	srv.avlab.SetListpackLimits(config.ZSetMaxListpackEntries, config.ZSetMaxListpackValue)
+
+	return srv
 }
 
 func (srv *Server) eventLoop(ctx context.Context) {
@@ -54,166 +84,161 @@ func (srv *Server) eventLoop(ctx context.Context) {
 			log.Info().Msg("shutdown event loop")
 			return
 		case ev := <-srv.eventChan:
-			for _, evcmd := range ev.cmd {
-				//TODO: Maybe change to function map if it doesn't affect performance too much
-				switch evcmd.name {
-				case "hello":
-					ev.writer.AppendMap(serverInfo)
-				case "echo":
-					ev.writer.AppendBulkStr(evcmd.args[0])
-				case "ping":
-					ev.writer.AppendSimpleStr("OK")
-				case "shards":
-					lengths := srv.avlab.ShardStats()
-					ev.writer.AppendArrInt(lengths)
-				case "scan":
-					cmd := &commands.ScanCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					keys, nextCursor, err := srv.avlab.Scan(cmd)
-					if err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					ev.writer.AppendArrAny([]interface{}{nextCursor, keys})
-				case "zadd":
-					cmd := &commands.ZADDCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					success := srv.avlab.ZAdd(cmd)
-					ev.writer.AppendInt(success)
-				case "zcard":
-					cmd := &commands.ZCardCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					success := srv.avlab.ZCard(cmd)
-					ev.writer.AppendInt(success)
-				case "zcount":
-					cmd := &commands.ZCountCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					count := srv.avlab.ZCount(cmd)
-					ev.writer.AppendInt(count)
-				case "zdiff":
-					cmd := &commands.ZDiffCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					diff := srv.avlab.ZDiff(cmd)
-					resp.SerializeTree(ev.writer, diff, cmd.WithScores)
-				case "zdiffstore":
-					cmd := &commands.ZDiffStoreCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					count := srv.avlab.ZDiffStore(cmd)
-					ev.writer.AppendInt(count)
-				case "zinter":
-					cmd := &commands.ZInterCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					diff := srv.avlab.ZInter(cmd)
-					resp.SerializeTree(ev.writer, diff, cmd.WithScores)
-				case "zinterstore":
-					cmd := &commands.ZDiffStoreCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					count := srv.avlab.ZDiffStore(cmd)
-					ev.writer.AppendInt(count)
-				case "zrange":
-					cmd := &commands.ZRangeCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					nodes := srv.avlab.ZRange(cmd)
-					if cmd.WithScores {
-						ev.writer.AppendArrStr(zdb.Reduce(nodes, func(acc []string, n zdb.Node) []string {
-							acc = append(acc, n.Key())
-							acc = append(acc, fmt.Sprintf("%.2f", n.Score()))
-							return acc
-						}, []string{}))
-					} else {
-						ev.writer.AppendArrStr(zdb.Map(nodes, func(n zdb.Node) string {
-							return n.Key()
-						}))
-					}
-				case "zrank":
-					cmd := &commands.ZRankCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					success := srv.avlab.ZRank(cmd)
-					ev.writer.AppendInt(success)
-				case "zrem":
-					cmd := &commands.ZRemCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					success := srv.avlab.ZRem(cmd)
-					ev.writer.AppendInt(success)
-				case "zscan":
-					cmd := &commands.ZScanCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					keys, nextCursor := srv.avlab.ZScan(cmd)
-					ev.writer.AppendArrAny([]interface{}{nextCursor, keys})
-				case "zscore":
-					cmd := &commands.ZScoreCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-
-					score, err := srv.avlab.ZScore(cmd)
-					if err != nil {
-						ev.writer.AppendNil()
-						continue
-					}
-
-					ev.writer.AppendFloat64(score)
-				case "zunion":
-					cmd := &commands.ZUnionCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					diff := srv.avlab.ZUnion(cmd)
-					resp.SerializeTree(ev.writer, diff, cmd.WithScores)
-					ev.writer.Write()
-				case "zunionstore":
-					cmd := &commands.ZUnionStoreCmd{}
-					if err := cmd.Build(evcmd.args); err != nil {
-						ev.writer.AppendSimpleError(err.Error())
-						continue
-					}
-					count := srv.avlab.ZUnionStore(cmd)
-					ev.writer.AppendInt(count)
-				default:
-					ev.writer.AppendSimpleStr("OK")
-				}
+			if srv.pause.blocks(ev) {
+				srv.pause.pending = append(srv.pause.pending, ev)
+				continue
 			}
-			ev.writer.Write()
+			srv.processEvent(ev, true)
+		case <-srv.pause.timeout():
+			srv.pause.timer = nil
+			srv.paused.Store(false)
+		}
+		srv.resumePaused()
+	}
+}
+
+// dispatch hands ev to the event loop, in threaded mode commands on keys run right away
+// on the connection goroutine instead, which otherwise waits for the event loop so the
+// replies keep the order of the commands
+func (srv *Server) dispatch(ev *eventCmd) {
+	if !srv.config.Threaded {
+		srv.eventChan <- ev
+		return
+	}
+
+	if srv.runsOnConnection(ev) {
+		srv.processEvent(ev, false)
+		return
+	}
+
+	ev.done = make(chan struct{})
+	srv.eventChan <- ev
+	<-ev.done
+}
+
+// runsOnConnection reports whether every command of ev only touches keys, which
+// ZDB guards with its shard locks. Monitors and pauses need the event loop to see
+// every command in order, a command that already went past this check when MONITOR
+// runs isn't fed to the new monitor
+func (srv *Server) runsOnConnection(ev *eventCmd) bool {
+	if srv.monitoring.Load() || srv.paused.Load() || ev.client.monitor.Load() {
+		return false
+	}
+
+	for _, evcmd := range ev.cmd {
+		cmd, ok := lookupCommand(evcmd.name)
+		if !ok || cmd.hasFlag(flagAdmin) || !(cmd.hasFlag(flagReadonly) || cmd.hasFlag(flagWrite)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// processEvent runs the commands of ev, onLoop is false on the connection goroutines
+// of threaded mode which never touch the monitors owned by the event loop
+func (srv *Server) processEvent(ev *eventCmd, onLoop bool) {
+	if ev.done != nil {
+		defer close(ev.done)
+	}
+
+	loopStart := time.Now()
+	for _, evcmd := range ev.cmd {
+		start := time.Now()
+		if onLoop && len(srv.monitors) > 0 && !ev.client.monitor.Load() && evcmd.name != "monitor" {
+			srv.feedMonitors(ev, evcmd, start)
+		}
+		ev.client.touch(evcmd.name, start)
+		srv.totalCommands.Add(1)
+		srv.execCmd(ev, evcmd)
+		duration := time.Since(start)
+
+		srv.slowlog.record(evcmd, ev.client.raddr, ev.client.name, start, duration)
+		srv.latency.record(latencyEventCommand, duration)
+
+		if srv.outputBufferExceeded(ev.client) {
+			log.Warn().Str("raddr", ev.client.raddr).Int("omem", ev.writer.Len()+ev.client.out.Len()).Msg("closing client over output buffer limit")
+			ev.writer.Reset()
+			ev.client.conn.Close()
+			return
+		}
+	}
+	if ev.err != nil {
+		ev.writer.AppendSimpleError("ERR " + ev.err.Error())
+		ev.client.closeAfterReply = true
+	}
+	ev.writer.Write()
+	if onLoop {
+		srv.latency.record(latencyEventEventLoop, time.Since(loopStart))
+	}
+
+	if ev.client.closeAfterReply {
+		ev.client.out.closeAfterFlush()
+	}
+}
+
+func (srv *Server) execCmd(ev *eventCmd, evcmd dataCmd) {
+	cmd, ok := lookupCommand(evcmd.name)
+	if !ok {
+		ev.writer.AppendSimpleError(unknownCommandError(evcmd))
+		return
+	}
+
+	if !cmd.checkArity(len(evcmd.args) + 1) {
+		ev.writer.AppendSimpleError(wrongArityError(evcmd.name))
+		return
+	}
+
+	if ev.client.monitor.Load() && evcmd.name != "monitor" {
+		ev.writer.AppendSimpleError("ERR monitor clients can't interact with the keyspace")
+		return
+	}
+
+	if cmd.hasFlag(flagWrite) {
+		if err := srv.avlab.FreeMemoryIfNeeded(); err != nil && cmd.hasFlag(flagDenyOOM) {
+			ev.writer.AppendSimpleError(err.Error())
+			return
 		}
 	}
+
+	cmd.exec(srv, ev, evcmd.args)
+}
+
+func (srv *Server) execEcho(ev *eventCmd, args commands.CmdArgs) {
+	ev.writer.AppendBulkStr(args[0])
+}
+
+func (srv *Server) execPing(ev *eventCmd, args commands.CmdArgs) {
+	ev.writer.AppendSimpleStr("OK")
+}
+
+func (srv *Server) execShards(ev *eventCmd, args commands.CmdArgs) {
+	ev.writer.AppendArrInt(srv.avlab.ShardStats())
+}
+
+// HELLO replies with the server info using the negotiated protocol, connections start with RESP2
+func (srv *Server) execHello(ev *eventCmd, cmd *commands.HelloCmd) {
+	if cmd.ClientName != "" {
+		if strings.ContainsAny(cmd.ClientName, " \n") {
+			ev.writer.AppendSimpleError("ERR Client names cannot contain spaces, newlines or special characters.")
+			return
+		}
+		ev.client.name = cmd.ClientName
+	}
+
+	if cmd.ProtoVer != 0 {
+		ev.writer.SetProtocol(cmd.ProtoVer)
+	}
+
+	ev.writer.AppendMap(map[string]interface{}{
+		"server":  "zdb",
+		"version": serverVersion,
+		"proto":   ev.writer.Protocol(),
+		"id":      int(ev.client.id),
+		"mode":    "standalone",
+		"role":    "master",
+		"modules": []string{},
+	})
 }
 
 func (srv *Server) Run(ctx context.Context) error {
@@ -238,8 +263,18 @@ func (srv *Server) Run(ctx context.Context) error {
 				}
 			}
 
-			log.Info().Str("raddr", conn.RemoteAddr().String()).Msg("accepting connection")
-			go srv.handleClient(ctx, conn)
+			// registered before the goroutine starts so a burst of connections can't
+			// get past maxclients
+			c, ok := srv.clients.register(conn, srv.config.MaxClients)
+			if !ok {
+				log.Warn().Str("raddr", conn.RemoteAddr().String()).Msg("refusing connection, max number of clients reached")
+				conn.Write([]byte("-ERR max number of clients reached\r\n"))
+				conn.Close()
+				continue
+			}
+
+			log.Info().Str("raddr", c.raddr).Msg("accepting connection")
+			go srv.handleClient(ctx, c)
 		}
 	}()
 
@@ -250,19 +285,25 @@ func (srv *Server) Run(ctx context.Context) error {
 	return nil
 }
 
-func (srv *Server) handleClient(ctx context.Context, conn net.Conn) (err error) {
+func (srv *Server) handleClient(ctx context.Context, c *client) (err error) {
+	srv.totalConnections.Add(1)
+	c.writer.SetLimit(srv.config.ClientOutputBufferHardLimit)
+	c.out.timeout = srv.config.ClientWriteTimeout
+	go c.out.run()
 	defer func() {
 		if err != nil && err.Error() != "EOF" {
-			log.Error().Err(err).Str("raddr", conn.RemoteAddr().String()).Msg("error in handle client")
+			log.Error().Err(err).Str("raddr", c.raddr).Msg("error in handle client")
 		} else {
-			log.Info().Str("raddr", conn.RemoteAddr().String()).Msg("closing connection")
+			log.Info().Str("raddr", c.raddr).Msg("closing connection")
 		}
 
-		conn.Close()
+		srv.clients.unregister(c)
+		c.conn.Close()
+		c.out.closeAfterFlush()
 	}()
 
 	doneChan := make(chan error, 10)
-	go srv.handleData(conn, doneChan)
+	go srv.handleData(c, doneChan)
 	for {
 		select {
 		case <-ctx.Done():
@@ -273,40 +314,57 @@ func (srv *Server) handleClient(ctx context.Context, conn net.Conn) (err error)
 	}
 }
 
-func (srv *Server) handleData(conn net.Conn, doneChan chan<- error) (err error) {
+func (srv *Server) handleData(c *client, doneChan chan<- error) (err error) {
 	defer func() {
 		doneChan <- err
 	}()
 
-	r := miniresp3.NewReader(conn)
-	w := miniresp3.NewWriter(conn)
+	r := c.reader
 	cmds := []dataCmd{}
 	for {
+		// monitors only receive data, they are never idle
+		if srv.config.IdleTimeout > 0 && !c.monitor.Load() {
+			c.conn.SetReadDeadline(time.Now().Add(srv.config.IdleTimeout))
+		} else {
+			c.conn.SetReadDeadline(time.Time{})
+		}
+
 		// blocking the loop, return err on closed connection
-		arrayCount, err := r.ReadArrayHeader()
+		args, err := r.ReadCommand()
 		if err != nil {
-			return err
-		}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				log.Info().Str("raddr", c.raddr).Msg("closing idle connection")
+				return nil
+			}
 
-		args := []string{}
-		for i := 0; i <= int(arrayCount-1); i++ {
-			bulkStr, err := r.ReadBulkString()
-			if err != nil {
-				return errors.Wrap(err, "failed to parse Bulk String")
+			var protoErr *miniresp3.ProtocolError
+			if errors.As(err, &protoErr) {
+				// let the event loop reply with the error and close the connection
+				srv.eventChan <- &eventCmd{
+					cmd:    cmds,
+					writer: c.writer,
+					client: c,
+					err:    protoErr,
+				}
+				io.Copy(io.Discard, c.conn)
 			}
-			args = append(args, bulkStr)
+			return err
 		}
 
-		cmds = append(cmds, dataCmd{
-			name: strings.ToLower(args[0]),
-			args: args[1:],
-		})
+		if len(args) > 0 {
+			cmds = append(cmds, dataCmd{
+				name: strings.ToLower(args[0]),
+				args: args[1:],
+			})
+		}
+		c.queryBuf.Store(int64(r.Buffered()))
 
-		if r.IsAllRead() {
-			srv.eventChan <- &eventCmd{
+		if r.IsAllRead() && len(cmds) > 0 {
+			srv.dispatch(&eventCmd{
 				cmd:    cmds,
-				writer: w,
-			}
+				writer: c.writer,
+				client: c,
+			})
 			cmds = []dataCmd{}
 		}
 	}
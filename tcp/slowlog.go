@@ -0,0 +1,154 @@
+package tcp
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/miniresp3"
+)
+
+const (
+	slowlogMaxArgc   = 32
+	slowlogMaxArgLen = 128
+)
+
+type slowlogEntry struct {
+	id        int
+	timestamp time.Time
+	duration  time.Duration
+	args      []string
+	raddr     string
+	name      string
+}
+
+type slowlog struct {
+	mu sync.Mutex
+	// newest entry first
+	entries       []slowlogEntry
+	nextID        int
+	logSlowerThan time.Duration
+	maxLen        int
+}
+
+func newSlowlog(logSlowerThan time.Duration, maxLen int) *slowlog {
+	return &slowlog{
+		logSlowerThan: logSlowerThan,
+		maxLen:        max(maxLen, 0),
+	}
+}
+
+func (sl *slowlog) record(cmd dataCmd, raddr, name string, start time.Time, duration time.Duration) {
+	if sl.logSlowerThan < 0 || duration < sl.logSlowerThan || sl.maxLen == 0 {
+		return
+	}
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	entry := slowlogEntry{
+		id:        sl.nextID,
+		timestamp: start,
+		duration:  duration,
+		args:      truncateSlowlogArgs(cmd),
+		raddr:     raddr,
+		name:      name,
+	}
+	sl.nextID++
+
+	sl.entries = append([]slowlogEntry{entry}, sl.entries...)
+	if len(sl.entries) > sl.maxLen {
+		sl.entries = sl.entries[:sl.maxLen]
+	}
+}
+
+func (sl *slowlog) get(count int) []slowlogEntry {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	if count < 0 || count > len(sl.entries) {
+		count = len(sl.entries)
+	}
+
+	return sl.entries[:count]
+}
+
+func (sl *slowlog) len() int {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	return len(sl.entries)
+}
+
+func (sl *slowlog) reset() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	sl.entries = nil
+}
+
+func truncateSlowlogArgs(cmd dataCmd) []string {
+	argv := append([]string{cmd.name}, cmd.args...)
+	argc := min(len(argv), slowlogMaxArgc)
+
+	args := make([]string, 0, argc)
+	for i := 0; i < argc; i++ {
+		// the last slot tells how many arguments were left out
+		if i == slowlogMaxArgc-1 && len(argv) > slowlogMaxArgc {
+			args = append(args, "... ("+strconv.Itoa(len(argv)-slowlogMaxArgc+1)+" more arguments)")
+			break
+		}
+
+		arg := argv[i]
+		if len(arg) > slowlogMaxArgLen {
+			arg = arg[:slowlogMaxArgLen] + "... (" + strconv.Itoa(len(arg)-slowlogMaxArgLen) + " more bytes)"
+		}
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+func serializeSlowlogEntries(writer *miniresp3.Writer, entries []slowlogEntry) {
+	writer.AppendArrHeader(len(entries))
+	for _, entry := range entries {
+		writer.AppendArrHeader(6)
+		writer.AppendInt(entry.id)
+		writer.AppendInt(int(entry.timestamp.Unix()))
+		writer.AppendInt(int(entry.duration.Microseconds()))
+		writer.AppendArrStr(entry.args)
+		writer.AppendBulkStr(entry.raddr)
+		writer.AppendBulkStr(entry.name)
+	}
+}
+
+// SLOWLOG GET [count] | SLOWLOG LEN | SLOWLOG RESET
+
+func (srv *Server) execSlowlog(ev *eventCmd, args commands.CmdArgs) {
+	if len(args) < 1 {
+		ev.writer.AppendSimpleError(wrongArityError("slowlog"))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		count := 10
+		if len(args) > 1 {
+			var err error
+			if count, err = strconv.Atoi(args[1]); err != nil {
+				ev.writer.AppendSimpleError("ERR value is out of range, must be positive")
+				return
+			}
+		}
+		serializeSlowlogEntries(ev.writer, srv.slowlog.get(count))
+	case "len":
+		ev.writer.AppendInt(srv.slowlog.len())
+	case "reset":
+		srv.slowlog.reset()
+		ev.writer.AppendSimpleStr("OK")
+	default:
+		ev.writer.AppendSimpleError("ERR unknown subcommand '" + args[0] + "'")
+	}
+}
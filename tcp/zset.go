@@ -0,0 +1,113 @@
+package tcp
+
+import (
+	"fmt"
+
+	"github.com/AdhityaRamadhanus/zdb"
+	"github.com/AdhityaRamadhanus/zdb/commands"
+	"github.com/AdhityaRamadhanus/zdb/encoding/resp"
+)
+
+func (srv *Server) execZAdd(ev *eventCmd, cmd *commands.ZADDCmd) {
+	ev.writer.AppendInt(srv.avlab.ZAdd(cmd))
+}
+
+func (srv *Server) execZCard(ev *eventCmd, cmd *commands.ZCardCmd) {
+	ev.writer.AppendInt(srv.avlab.ZCard(cmd))
+}
+
+func (srv *Server) execZCount(ev *eventCmd, cmd *commands.ZCountCmd) {
+	ev.writer.AppendInt(srv.avlab.ZCount(cmd))
+}
+
+func (srv *Server) execZDiff(ev *eventCmd, cmd *commands.ZDiffCmd) {
+	resp.SerializeTree(ev.writer, srv.avlab.ZDiff(cmd), cmd.WithScores)
+}
+
+func (srv *Server) execZDiffStore(ev *eventCmd, cmd *commands.ZDiffStoreCmd) {
+	ev.writer.AppendInt(srv.avlab.ZDiffStore(cmd))
+}
+
+func (srv *Server) execZInter(ev *eventCmd, cmd *commands.ZInterCmd) {
+	resp.SerializeTree(ev.writer, srv.avlab.ZInter(cmd), cmd.WithScores)
+}
+
+func (srv *Server) execZInterStore(ev *eventCmd, cmd *commands.ZInterStoreCmd) {
+	ev.writer.AppendInt(srv.avlab.ZInterStore(cmd))
+}
+
+func (srv *Server) execZUnion(ev *eventCmd, cmd *commands.ZUnionCmd) {
+	resp.SerializeTree(ev.writer, srv.avlab.ZUnion(cmd), cmd.WithScores)
+}
+
+func (srv *Server) execZUnionStore(ev *eventCmd, cmd *commands.ZUnionStoreCmd) {
+	ev.writer.AppendInt(srv.avlab.ZUnionStore(cmd))
+}
+
+func (srv *Server) execZRange(ev *eventCmd, cmd *commands.ZRangeCmd) {
+	nodes := srv.avlab.ZRange(cmd)
+	if cmd.WithScores {
+		ev.writer.AppendArrStr(zdb.Reduce(nodes, func(acc []string, n zdb.Node) []string {
+			acc = append(acc, n.Key())
+			acc = append(acc, fmt.Sprintf("%.2f", n.Score()))
+			return acc
+		}, []string{}))
+		return
+	}
+
+	ev.writer.AppendArrStr(zdb.Map(nodes, func(n zdb.Node) string {
+		return n.Key()
+	}))
+}
+
+func (srv *Server) execZRank(ev *eventCmd, cmd *commands.ZRankCmd) {
+	ev.writer.AppendInt(srv.avlab.ZRank(cmd))
+}
+
+func (srv *Server) execZRem(ev *eventCmd, cmd *commands.ZRemCmd) {
+	ev.writer.AppendInt(srv.avlab.ZRem(cmd))
+}
+
+func (srv *Server) execZScan(ev *eventCmd, cmd *commands.ZScanCmd) {
+	nodes, nextCursor := srv.avlab.ZScan(cmd)
+	ev.writer.AppendArrHeader(2)
+	ev.writer.AppendBulkStr(nextCursor)
+	resp.SerializeNodes(ev.writer, nodes, !cmd.NoScores)
+}
+
+func (srv *Server) execZScore(ev *eventCmd, cmd *commands.ZScoreCmd) {
+	score, err := srv.avlab.ZScore(cmd)
+	if err != nil {
+		ev.writer.AppendNil()
+		return
+	}
+
+	ev.writer.AppendFloat64(score)
+}
+
+func (srv *Server) execZSetCap(ev *eventCmd, cmd *commands.ZSetCapCmd) {
+	if cmd.Query {
+		info, err := srv.avlab.ZSetCapInfo(cmd)
+		if err != nil {
+			ev.writer.AppendNil()
+			return
+		}
+		evict := "min"
+		if info.EvictMax {
+			evict = "max"
+		}
+		ev.writer.AppendMap(map[string]interface{}{
+			"maxlen":  info.MaxLen,
+			"evict":   evict,
+			"evicted": info.Evicted,
+		})
+		return
+	}
+
+	evicted, err := srv.avlab.ZSetCap(cmd)
+	if err != nil {
+		ev.writer.AppendSimpleError(err.Error())
+		return
+	}
+	ev.writer.AppendInt(evicted)
+}
@@ -1,33 +1,65 @@
 package zdb
 
+import (
+	"fmt"
+	"math/bits"
+)
+
 type Tree struct {
-	root    *Node
-	HashMap map[uint64]float64
-	hasher  fnv64a
+	treeSlabs
+	root uint32
+	// the keys share their bytes with the nodes so only the headers are extra
+	members     memberMap
+	memberBytes int
 }
 
 func NewTree() OrderStatisticTree {
 	return &Tree{
-		HashMap: make(map[uint64]float64),
-		hasher:  fnv64a{},
+		treeSlabs: newTreeSlabs(),
+		members:   newMemberMap(0),
 	}
 }
 
+// NewTreeFromSorted builds a perfectly balanced tree in O(n), nodes must be sorted by
+// score then member without duplicate members
+func NewTreeFromSorted(nodes []Node) OrderStatisticTree {
+	t := &Tree{}
+	t.build(nodes)
+	return t
+}
+
 func (t *Tree) IsEmpty() bool {
-	return t == nil || t.root == nil
+	return t == nil || t.root == 0
 }
 
-func (t *Tree) Root() *Node {
+func (t *Tree) Len() int {
 	if t == nil {
-		return nil
+		return 0
+	}
+
+	return t.count(t.root)
+}
+
+func (t *Tree) Height() int {
+	if t == nil {
+		return 0
+	}
+
+	return t.height(t.root)
+}
+
+func (t *Tree) Iterator(start int) Iterator {
+	it := NewTreeIterator(t)
+	if start >= t.Len() {
+		return it
 	}
 
-	return t.root
+	it.Seek(t.Select(max(start, 0) + 1))
+	return it
 }
 
 func (t *Tree) GetScore(key string) (float64, error) {
-	hashedKey := t.hasher.Sum64(key)
-	score, exists := t.HashMap[hashedKey]
+	score, exists := t.members.get(key, t.contains)
 	if !exists {
 		return 0, errNotFound
 	}
@@ -36,52 +68,205 @@ func (t *Tree) GetScore(key string) (float64, error) {
 }
 
 func (t *Tree) Add(key string, score float64) {
-	hashedKey := t.hasher.Sum64(key)
-	if oldScore, exists := t.HashMap[hashedKey]; exists {
+	if oldScore, exists := t.members.get(key, t.contains); exists {
 		// delete and insert new
 		t.root = t.deleteRec(t.root, NewNode(key, oldScore))
+	} else {
+		t.memberBytes += len(key)
+	}
+	// allocated before walking down the tree, which holds nodes across the recursion
+	inserted := t.alloc(key, score)
+	t.root = t.insertRec(t.root, inserted)
+	t.members.set(key, score)
+
+	// a split tree rebuilds its own slabs and member map, which also lets go of the
+	// members of the other side
+	if t.members.outgrown(t.Len()) {
+		t.build(t.RangeByIndex(0, t.Len()-1))
 	}
-	t.root = t.insertRec(t.root, NewNode(key, score))
-	t.HashMap[hashedKey] = score
+}
+
+// contains reports whether the tree holds key with score
+func (t *Tree) contains(key string, score float64) bool {
+	seek := Node{key: key, score: score}
+	for curr := t.root; curr != 0; {
+		n := t.node(curr)
+		switch c := compareNode(&n.Node, &seek); {
+		case c > 0:
+			curr = n.left
+		case c < 0:
+			curr = n.right
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddBatch adds a batch smaller than the tree one member at a time. A bigger one is merged
+// with the members in order and the tree rebuilt in O(n)
+func (t *Tree) AddBatch(nodes []Node) {
+	nodes = sortedBatch(nodes)
+	if n := t.Len(); len(nodes)*bits.Len(uint(n+len(nodes))) < n {
+		for _, node := range nodes {
+			t.Add(node.key, node.score)
+		}
+		return
+	}
+
+	replaced := map[string]bool{}
+	for _, node := range nodes {
+		if _, exists := t.members.get(node.key, t.contains); exists {
+			replaced[node.key] = true
+		}
+	}
+
+	merged := make([]Node, 0, t.Len()+len(nodes))
+	it := NewTreeIterator(t)
+	it.Seek(nil)
+	for next := it.Next(); next != nil; next = it.Next() {
+		if replaced[next.key] {
+			continue
+		}
+		for len(nodes) > 0 && compareNode(&nodes[0], next) < 0 {
+			merged = append(merged, nodes[0])
+			nodes = nodes[1:]
+		}
+		merged = append(merged, *next)
+	}
+	merged = append(merged, nodes...)
+
+	t.build(merged)
+}
+
+// build replaces the members with nodes, sorted and unique
+func (t *Tree) build(nodes []Node) {
+	t.treeSlabs = newTreeSlabs()
+	t.members = newMemberMap(len(nodes))
+	t.memberBytes = 0
+	for _, node := range nodes {
+		t.members.set(node.key, node.score)
+		t.memberBytes += len(node.key)
+	}
+
+	t.root = t.buildRec(nodes)
+}
+
+func (t *Tree) buildRec(nodes []Node) uint32 {
+	if len(nodes) == 0 {
+		return 0
+	}
+
+	mid := len(nodes) / 2
+	i := t.alloc(nodes[mid].key, nodes[mid].score)
+	left, right := t.buildRec(nodes[:mid]), t.buildRec(nodes[mid+1:])
+
+	n := t.node(i)
+	n.left, n.right = left, right
+	t.update(i)
+	return i
 }
 
 func (t *Tree) Remove(key string) {
-	hashedKey := t.hasher.Sum64(key)
-	score, exists := t.HashMap[hashedKey]
+	score, exists := t.members.get(key, t.contains)
 	if !exists {
 		return
 	}
 	t.root = t.deleteRec(t.root, NewNode(key, score))
-	delete(t.HashMap, hashedKey)
+	t.members.delete(key)
+	// split trees only estimate their member bytes
+	t.memberBytes = max(t.memberBytes-len(key), 0)
+}
+
+func (t *Tree) MemoryUsage() int {
+	return treeOverhead + t.Len()*(treeNodeSize+treeMapEntrySize) + t.memberBytes
+}
+
+func (t *Tree) Encoding() string {
+	return "avltree"
+}
+
+// Validate checks the height, count, balance factor and ordering of every node
+// and that the member map holds exactly the members of the tree
+func (t *Tree) Validate() error {
+	var prev *Node
+	visited := 0
+
+	var validate func(i uint32) error
+	validate = func(i uint32) error {
+		if i == 0 {
+			return nil
+		}
+		n := t.node(i)
+
+		if err := validate(n.left); err != nil {
+			return err
+		}
+
+		if prev != nil && compareNode(prev, &n.Node) >= 0 {
+			return fmt.Errorf("member %q is not ordered after %q", n.key, prev.key)
+		}
+		prev = &n.Node
+		visited++
+
+		score, err := t.GetScore(n.key)
+		if err != nil || score != n.score {
+			return fmt.Errorf("member %q has score %v in the tree but not in the hash map", n.key, n.score)
+		}
+
+		if err := validate(n.right); err != nil {
+			return err
+		}
+
+		if height := 1 + max(t.height(n.left), t.height(n.right)); int(n.height) != height {
+			return fmt.Errorf("member %q has height %d, want %d", n.key, n.height, height)
+		}
+
+		if count := 1 + t.count(n.left) + t.count(n.right); int(n.count) != count {
+			return fmt.Errorf("member %q has count %d, want %d", n.key, n.count, count)
+		}
+
+		if balance := t.balance(i); balance < -1 || balance > 1 {
+			return fmt.Errorf("member %q has balance factor %d", n.key, balance)
+		}
+
+		return nil
+	}
+
+	if err := validate(t.root); err != nil {
+		return err
+	}
+
+	return t.members.validate(visited, t.contains)
 }
 
 func (t *Tree) Rank(key string) int {
-	if t.root == nil {
+	if t.root == 0 {
 		return -1
 	}
 
-	hashedKey := t.hasher.Sum64(key)
-	score, exists := t.HashMap[hashedKey]
+	score, exists := t.members.get(key, t.contains)
 	if !exists {
 		return -1
 	}
 
 	seek := NewNode(key, score)
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil {
-		currRank := curr.rankByParent(parent, parentRank)
-		currComparison := compareNode(curr, seek)
+	for curr != 0 {
+		currRank := t.rank(curr, parent, parentRank)
+		currComparison := compareNode(&t.node(curr).Node, seek)
 		if currComparison > 0 {
 			parent = curr
 			parentRank = currRank
-			curr = curr.left
+			curr = t.node(curr).left
 		} else if currComparison < 0 {
 			parent = curr
 			parentRank = currRank
-			curr = curr.right
+			curr = t.node(curr).right
 		} else {
 			return currRank
 		}
@@ -91,33 +276,32 @@ func (t *Tree) Rank(key string) int {
 }
 
 func (t *Tree) RankReverse(key string) int {
-	if t.root == nil {
+	if t.root == 0 {
 		return -1
 	}
 
-	hashedKey := t.hasher.Sum64(key)
-	score, exists := t.HashMap[hashedKey]
+	score, exists := t.members.get(key, t.contains)
 	if !exists {
 		return -1
 	}
 
 	seek := NewNode(key, score)
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil {
-		currRank := curr.rankByParentReverse(parent, parentRank)
-		currComparison := compareNode(curr, seek)
+	for curr != 0 {
+		currRank := t.rankReverse(curr, parent, parentRank)
+		currComparison := compareNode(&t.node(curr).Node, seek)
 
 		if currComparison > 0 {
 			parent = curr
 			parentRank = currRank
-			curr = curr.left
+			curr = t.node(curr).left
 		} else if currComparison < 0 {
 			parent = curr
 			parentRank = currRank
-			curr = curr.right
+			curr = t.node(curr).right
 		} else {
 			return currRank
 		}
@@ -126,106 +310,97 @@ func (t *Tree) RankReverse(key string) int {
 	return -1
 }
 
-func (t *Tree) Select(idx int) *Node {
-	if t.root == nil || idx > t.root.Count() {
-		return nil
+// selectIndex returns the index of the node at the 1 based rank idx, 0 when out of range
+func (t *Tree) selectIndex(idx int) uint32 {
+	if t.root == 0 || idx > t.Len() {
+		return 0
 	}
 
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil {
-		currRank := curr.rankByParent(parent, parentRank)
+	for curr != 0 {
+		currRank := t.rank(curr, parent, parentRank)
 
 		if idx < currRank {
 			parent = curr
 			parentRank = currRank
-			curr = curr.left
+			curr = t.node(curr).left
 		} else if idx > currRank {
 			parent = curr
 			parentRank = currRank
-			curr = curr.right
+			curr = t.node(curr).right
 		} else {
 			return curr
 		}
 	}
 
-	return nil
+	return 0
 }
 
-func (t *Tree) SelectReverse(idx int) *Node {
-	if t.root == nil || idx > t.root.Count() {
+func (t *Tree) Select(idx int) *Node {
+	i := t.selectIndex(idx)
+	if i == 0 {
 		return nil
 	}
 
-	curr := t.root
-	var parent *Node
-	parentRank := -1
-
-	for curr != nil {
-		currRank := curr.rankByParentReverse(parent, parentRank)
+	node := t.node(i).Node
+	return &node
+}
 
-		if idx < currRank {
-			parent = curr
-			parentRank = currRank
-			curr = curr.right
-		} else if idx > currRank {
-			parent = curr
-			parentRank = currRank
-			curr = curr.left
-		} else {
-			return curr
-		}
+func (t *Tree) SelectReverse(idx int) *Node {
+	if t.root == 0 || idx > t.Len() {
+		return nil
 	}
 
-	return nil
+	return t.Select(t.Len() - idx + 1)
 }
 
 func (t *Tree) RangeByIndex(start, stop int) (nodes []Node) {
 	start += 1
 	stop += 1
 
-	if t.root == nil {
+	if t.root == 0 {
 		return
 	}
 
 	type stackElmt struct {
-		Curr     *Node
+		Curr     uint32
 		CurrRank int
 	}
 	stack := []stackElmt{}
 
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
+	for curr != 0 || len(stack) != 0 {
+		if curr == 0 && len(stack) != 0 {
 			se := stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
 
 			curr = se.Curr
 			currRank := se.CurrRank
 
-			nodes = append(nodes, *curr)
+			nodes = append(nodes, t.node(curr).Node)
 			parent = curr
 			parentRank = currRank
-			curr = curr.right
+			curr = t.node(curr).right
 			continue
 		}
 
-		currRank := curr.rankByParent(parent, parentRank)
+		currRank := t.rank(curr, parent, parentRank)
 		parent = curr
 		parentRank = currRank
 
 		if currRank < start {
-			curr = curr.left
+			curr = t.node(curr).right
 		} else if currRank >= start && currRank <= stop {
 			stack = append(stack, stackElmt{Curr: curr, CurrRank: currRank})
-			curr = curr.left
+			curr = t.node(curr).left
 		} else { // currRank > stop
-			curr = curr.left
+			curr = t.node(curr).left
 		}
 	}
 
@@ -236,46 +411,46 @@ func (t *Tree) RangeByIndexReverse(start, stop int) (nodes []Node) {
 	start += 1
 	stop += 1
 
-	if t.root == nil {
+	if t.root == 0 {
 		return
 	}
 
 	type stackElmt struct {
-		Curr     *Node
+		Curr     uint32
 		CurrRank int
 	}
 	stack := []stackElmt{}
 
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
+	for curr != 0 || len(stack) != 0 {
+		if curr == 0 && len(stack) != 0 {
 			se := stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
 
 			curr = se.Curr
 			currRank := se.CurrRank
 
-			nodes = append(nodes, *curr)
+			nodes = append(nodes, t.node(curr).Node)
 			parent = curr
 			parentRank = currRank
-			curr = curr.left
+			curr = t.node(curr).left
 			continue
 		}
 
-		currRank := curr.rankByParentReverse(parent, parentRank)
+		currRank := t.rankReverse(curr, parent, parentRank)
 		parent = curr
 		parentRank = currRank
 
 		if currRank < start {
-			curr = curr.left
+			curr = t.node(curr).left
 		} else if currRank >= start && currRank <= stop {
 			stack = append(stack, stackElmt{Curr: curr, CurrRank: currRank})
-			curr = curr.right
+			curr = t.node(curr).right
 		} else { // currRank > stop
-			curr = curr.right
+			curr = t.node(curr).right
 		}
 	}
 
@@ -283,27 +458,27 @@ func (t *Tree) RangeByIndexReverse(start, stop int) (nodes []Node) {
 }
 
 func (t *Tree) rankByScoreLowerBound(score float64) int {
-	if t.root == nil {
+	if t.root == 0 {
 		return 0
 	}
 
 	// find min
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil {
-		currRank := curr.rankByParent(parent, parentRank)
+	for curr != 0 {
+		currRank := t.rank(curr, parent, parentRank)
 		parent = curr
 		parentRank = currRank
-		if score <= curr.score {
-			curr = curr.left
+		if score <= t.node(curr).score {
+			curr = t.node(curr).left
 		} else {
-			curr = curr.right
+			curr = t.node(curr).right
 		}
 	}
 
-	if score <= parent.score {
+	if score <= t.node(parent).score {
 		return parentRank
 	} else {
 		return parentRank + 1
@@ -311,27 +486,27 @@ func (t *Tree) rankByScoreLowerBound(score float64) int {
 }
 
 func (t *Tree) rankByScoreUpperBound(score float64) int {
-	if t.root == nil {
+	if t.root == 0 {
 		return 0
 	}
 
 	// find max
 	curr := t.root
-	var parent *Node
+	var parent uint32
 	parentRank := -1
 
-	for curr != nil {
-		currRank := curr.rankByParent(parent, parentRank)
+	for curr != 0 {
+		currRank := t.rank(curr, parent, parentRank)
 		parent = curr
 		parentRank = currRank
-		if score < curr.score {
-			curr = curr.left
+		if score < t.node(curr).score {
+			curr = t.node(curr).left
 		} else {
-			curr = curr.right
+			curr = t.node(curr).right
 		}
 	}
 
-	if score < parent.score {
+	if score < t.node(parent).score {
 		return parentRank - 1
 	} else {
 		return parentRank + 1
@@ -339,7 +514,7 @@ func (t *Tree) rankByScoreUpperBound(score float64) int {
 }
 
 func (t *Tree) CountByScore(min, max float64) int {
-	if t.root == nil {
+	if t.root == 0 {
 		return 0
 	}
 
@@ -349,285 +524,171 @@ func (t *Tree) CountByScore(min, max float64) int {
 	return maxRank - minRank
 }
 
-func (t *Tree) RangeByScore(min, max float64) (nodes []Node) {
-	if t.root == nil {
-		return
-	}
-
-	stack := []*Node{}
+// rangeInOrder returns the nodes for which cmp is 0 in order, or in reverse order, cmp is
+// negative for the nodes before the range and positive for the ones after it
+func (t *Tree) rangeInOrder(cmp func(n *treeNode) int, reverse bool) (nodes []Node) {
+	stack := []uint32{}
 
 	curr := t.root
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
+	for curr != 0 || len(stack) != 0 {
+		if curr == 0 && len(stack) != 0 {
 			curr = stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
 
-			nodes = append(nodes, *curr)
+			nodes = append(nodes, t.node(curr).Node)
 
-			curr = curr.right
+			if reverse {
+				curr = t.node(curr).left
+			} else {
+				curr = t.node(curr).right
+			}
 			continue
 		}
 
-		if curr.score < min {
-			curr = curr.right
-		} else if curr.score >= min && curr.score <= max {
+		n := t.node(curr)
+		switch c := cmp(n); {
+		case c < 0:
+			curr = n.right
+		case c > 0:
+			curr = n.left
+		case reverse:
 			stack = append(stack, curr)
-			curr = curr.left
-		} else { // curr.score > max
-			curr = curr.left
-		}
-	}
-
-	return nodes
-}
-
-func (t *Tree) RangeByScoreReverse(min, max float64) (nodes []Node) {
-	if t.root == nil {
-		return
-	}
-
-	stack := []*Node{}
-
-	curr := t.root
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
-			curr = stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			nodes = append(nodes, *curr)
-
-			curr = curr.left
-			continue
-		}
-
-		if curr.score < min {
-			curr = curr.right
-		} else if curr.score >= min && curr.score <= max {
+			curr = n.right
+		default:
 			stack = append(stack, curr)
-			curr = curr.right
-		} else { // curr.score > max
-			curr = curr.left
+			curr = n.left
 		}
 	}
 
 	return nodes
 }
 
-func (t *Tree) RangeByLex(minKey, maxKey string) (nodes []Node) {
-	if t.root == nil {
-		return
-	}
-
-	stack := []*Node{}
-
-	curr := t.root
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
-			curr = stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			nodes = append(nodes, *curr)
-
-			curr = curr.right
-			continue
-		}
-
-		if curr.key < minKey {
-			curr = curr.right
-		} else if curr.key >= minKey && curr.key <= maxKey {
-			stack = append(stack, curr)
-			curr = curr.left
-		} else { // curr.key > max
-			curr = curr.left
+func scoreRange(min, max float64) func(n *treeNode) int {
+	return func(n *treeNode) int {
+		if n.score < min {
+			return -1
+		} else if n.score > max {
+			return 1
 		}
+		return 0
 	}
-
-	return nodes
 }
 
-func (t *Tree) RangeByLexReverse(minKey, maxKey string) (nodes []Node) {
-	if t.root == nil {
-		return
-	}
-
-	stack := []*Node{}
-
-	curr := t.root
-	for curr != nil || len(stack) != 0 {
-		if curr == nil && len(stack) != 0 {
-			curr = stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			nodes = append(nodes, *curr)
-
-			curr = curr.left
-			continue
-		}
-
-		if curr.key < minKey {
-			curr = curr.right
-		} else if curr.key >= minKey && curr.key <= maxKey {
-			stack = append(stack, curr)
-			curr = curr.right
-		} else { // curr.key > max
-			curr = curr.left
+func lexRange(minKey, maxKey string) func(n *treeNode) int {
+	return func(n *treeNode) int {
+		if n.key < minKey {
+			return -1
+		} else if n.key > maxKey {
+			return 1
 		}
+		return 0
 	}
-
-	return nodes
 }
 
-func (t *Tree) Diff(other OrderStatisticTree) (diff OrderStatisticTree) {
-	it := NewTreeIterator(t)
-	it.Seek(nil)
-
-	diff = NewTree()
-	for {
-		next := it.Next()
-		if next == nil {
-			break
-		}
-
-		_, err := other.GetScore(next.key)
-		// either key doesn't exist in other or exists with different score
-		if err == errNotFound {
-			diff.Add(next.key, next.score)
-		}
-	}
-
-	return diff
+func (t *Tree) RangeByScore(min, max float64) (nodes []Node) {
+	return t.rangeInOrder(scoreRange(min, max), false)
 }
 
-func (t *Tree) Inter(other OrderStatisticTree, aggFunc AggFunc) (inter OrderStatisticTree) {
-	it := NewTreeIterator(t)
-	it.Seek(nil)
-
-	inter = NewTree()
-	for {
-		next := it.Next()
-		if next == nil {
-			break
-		}
-
-		score, err := other.GetScore(next.key)
-		// either key doesn't exist in other or exists with different score
-		if err != errNotFound {
-			inter.Add(next.key, aggFunc(next.score, score))
-		}
-	}
-
-	return inter
+func (t *Tree) RangeByScoreReverse(min, max float64) (nodes []Node) {
+	return t.rangeInOrder(scoreRange(min, max), true)
 }
 
-func (t *Tree) Union(other OrderStatisticTree, aggFunc AggFunc) (union OrderStatisticTree) {
-	it := NewTreeIterator(t)
-	it.Seek(nil)
-
-	it2 := NewTreeIterator(other)
-	it2.Seek(nil)
-
-	union = NewTree()
-	for {
-		next := it.Next()
-		if next == nil {
-			break
-		}
-
-		score, err := other.GetScore(next.key)
-		inserted := NewNode(next.key, next.score)
-		// either key doesn't exist in other or exists with different score
-		if err != errNotFound {
-			inserted.score = aggFunc(next.score, score)
-		}
+func (t *Tree) RangeByLex(minKey, maxKey string) (nodes []Node) {
+	return t.rangeInOrder(lexRange(minKey, maxKey), false)
+}
 
-		union.Add(inserted.key, inserted.score)
-	}
+func (t *Tree) RangeByLexReverse(minKey, maxKey string) (nodes []Node) {
+	return t.rangeInOrder(lexRange(minKey, maxKey), true)
+}
 
-	for {
-		next := it2.Next()
-		if next == nil {
-			break
-		}
+func (t *Tree) Diff(other OrderStatisticTree) OrderStatisticTree {
+	return diff(t, other, NewTree())
+}
 
-		_, err := union.GetScore(next.key)
-		if err == errNotFound {
-			union.Add(next.key, next.score)
-		}
-	}
+func (t *Tree) Inter(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return inter(t, other, aggFunc, NewTree())
+}
 
-	return union
+func (t *Tree) Union(other OrderStatisticTree, aggFunc AggFunc) OrderStatisticTree {
+	return union(t, other, aggFunc, NewTree())
 }
 
-func (t *Tree) insertRec(root *Node, inserted *Node) *Node {
-	if root == nil {
+func (t *Tree) insertRec(root, inserted uint32) uint32 {
+	if root == 0 {
 		return inserted
 	}
 
-	rootComparison := compareNode(root, inserted)
+	n := t.node(root)
+	rootComparison := compareNode(&n.Node, &t.node(inserted).Node)
 
 	if rootComparison > 0 { // insert left
-		root.left = t.insertRec(root.left, inserted)
+		n.left = t.insertRec(n.left, inserted)
 	} else if rootComparison < 0 { // insert right
-		root.right = t.insertRec(root.right, inserted)
+		n.right = t.insertRec(n.right, inserted)
 	} else {
+		t.release(inserted)
 		return root
 	}
 
-	root.updateHeightAndCount()
+	t.update(root)
 	return t.rebalance(root)
 }
 
-func (t *Tree) deleteRec(root, deleted *Node) *Node {
-	if root == nil {
-		return nil
+func (t *Tree) deleteRec(root uint32, deleted *Node) uint32 {
+	if root == 0 {
+		return 0
 	}
 
-	rootComparison := compareNode(root, deleted)
+	n := t.node(root)
+	rootComparison := compareNode(&n.Node, deleted)
 	if rootComparison > 0 {
-		root.left = t.deleteRec(root.left, deleted)
+		n.left = t.deleteRec(n.left, deleted)
 	} else if rootComparison < 0 {
-		root.right = t.deleteRec(root.right, deleted)
+		n.right = t.deleteRec(n.right, deleted)
 	} else {
-		if root.left == nil && root.right == nil {
-			return nil
+		if n.left == 0 || n.right == 0 {
+			child := n.left | n.right
+			t.release(root)
+			return child
 		}
 
-		if root.left == nil {
-			return root.right
-		}
+		predecessor := t.node(t.inorderPredecessor(root))
+		n.Node, predecessor.Node = predecessor.Node, n.Node
 
-		if root.right == nil {
-			return root.left
-		}
+		n.left = t.deleteRec(n.left, deleted)
+	}
 
-		predecessor := root.inorderPredecessor()
-		root.key, predecessor.key = predecessor.key, root.key
-		root.score, predecessor.score = predecessor.score, root.score
+	t.update(root)
+	return t.rebalance(root)
+}
 
-		return t.deleteRec(root.left, deleted)
+func (t *Tree) inorderPredecessor(i uint32) uint32 {
+	curr := t.node(i).left
+	for t.node(curr).right != 0 {
+		curr = t.node(curr).right
 	}
 
-	root.updateHeightAndCount()
-	return t.rebalance(root)
+	return curr
 }
 
-func (t *Tree) rebalance(root *Node) *Node {
-	if root == nil {
-		return nil
+func (t *Tree) rebalance(root uint32) uint32 {
+	if root == 0 {
+		return 0
 	}
 
-	balanceFactor := root.Balance()
+	n := t.node(root)
+	balanceFactor := t.balance(root)
 	if balanceFactor < -1 {
-		if root.right.Balance() > 0 {
-			root.right = t.rightRotate(root.right)
+		if t.balance(n.right) > 0 {
+			n.right = t.rightRotate(n.right)
 		}
 
 		return t.leftRotate(root)
 	}
 
 	if balanceFactor > 1 {
-		if root.left.Balance() < 0 {
-			root.left = t.leftRotate(root.left)
+		if t.balance(n.left) < 0 {
+			n.left = t.leftRotate(n.left)
 		}
 
 		return t.rightRotate(root)
@@ -636,24 +697,26 @@ func (t *Tree) rebalance(root *Node) *Node {
 	return root
 }
 
-func (t *Tree) leftRotate(root *Node) *Node {
-	newRoot := root.right
-	root.right = newRoot.left
-	newRoot.left = root
+func (t *Tree) leftRotate(root uint32) uint32 {
+	n := t.node(root)
+	newRoot := n.right
+	n.right = t.node(newRoot).left
+	t.node(newRoot).left = root
 
-	root.updateHeightAndCount()
-	newRoot.updateHeightAndCount()
+	t.update(root)
+	t.update(newRoot)
 
 	return newRoot
 }
 
-func (ln *Tree) rightRotate(root *Node) *Node {
-	newRoot := root.left
-	root.left = newRoot.right
-	newRoot.right = root
+func (t *Tree) rightRotate(root uint32) uint32 {
+	n := t.node(root)
+	newRoot := n.left
+	n.left = t.node(newRoot).right
+	t.node(newRoot).right = root
 
-	root.updateHeightAndCount()
-	newRoot.updateHeightAndCount()
+	t.update(root)
+	t.update(newRoot)
 
 	return newRoot
 }
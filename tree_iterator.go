@@ -1,20 +1,24 @@
 package zdb
 
+// TreeIterator walks a Tree in order, the nodes it returns are only valid until
+// the tree is modified
 type TreeIterator struct {
-	stack []*Node
-	curr  *Node
+	tree  *Tree
+	stack []uint32
+	curr  uint32
 }
 
-func NewTreeIterator(t OrderStatisticTree) *TreeIterator {
+func NewTreeIterator(t *Tree) *TreeIterator {
 	return &TreeIterator{
-		curr: t.Root(),
+		tree: t,
+		curr: t.root,
 	}
 }
 
 func (it *TreeIterator) moveToStart() {
-	for it.curr != nil {
+	for it.curr != 0 {
 		it.stack = append(it.stack, it.curr)
-		it.curr = it.curr.left
+		it.curr = it.tree.node(it.curr).left
 	}
 }
 
@@ -25,37 +29,37 @@ func (it *TreeIterator) Seek(node *Node) {
 		return
 	}
 
-	for it.curr != nil {
-		comp := compareNode(it.curr, node)
+	for it.curr != 0 {
+		curr := it.tree.node(it.curr)
+		comp := compareNode(&curr.Node, node)
 		if comp > 0 {
 			it.stack = append(it.stack, it.curr)
-			it.curr = it.curr.left
+			it.curr = curr.left
 		} else if comp < 0 {
-			it.curr = it.curr.right
+			it.curr = curr.right
 		} else { // it.curr == node
 			it.stack = append(it.stack, it.curr)
-			it.curr = nil
+			it.curr = 0
 			return
 		}
 	}
 
 	// not found
-	it.curr = nil
-	it.stack = []*Node{}
+	it.curr = 0
+	it.stack = []uint32{}
 }
 
 func (it *TreeIterator) Next() *Node {
 	// inorder traversal with stack
-	for it.curr != nil || len(it.stack) > 0 {
-		var next *Node
-		if it.curr != nil {
+	for it.curr != 0 || len(it.stack) > 0 {
+		if it.curr != 0 {
 			it.stack = append(it.stack, it.curr)
-			it.curr = it.curr.left
+			it.curr = it.tree.node(it.curr).left
 		} else {
-			next = it.stack[len(it.stack)-1]
+			next := it.tree.node(it.stack[len(it.stack)-1])
 			it.stack = it.stack[:len(it.stack)-1]
 			it.curr = next.right
-			return next
+			return &next.Node
 		}
 	}
 
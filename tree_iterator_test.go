@@ -98,7 +98,7 @@ func TestTreeIterator(t *testing.T) {
 		}
 
 		t.Run(test.Name, func(t *testing.T) {
-			tree := NewTree()
+			tree := NewTree().(*Tree)
 			for _, node := range test.Tree {
 				tree.Add(node.key, node.score)
 			}
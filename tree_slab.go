@@ -0,0 +1,175 @@
+package zdb
+
+import "sync"
+
+const (
+	treeSlabBits = 10
+	treeSlabSize = 1 << treeSlabBits
+)
+
+// treeNode is a node of a Tree, its children are indexes in the slabs of the tree
+// so the GC has no pointer to trace per node besides the member. Index 0 is the
+// nil node, its count and height are 0 and it is never written to
+type treeNode struct {
+	Node
+	left   uint32
+	right  uint32
+	count  uint32
+	height uint8
+}
+
+// treeSlabs stores the nodes of a Tree in slabs of treeSlabSize nodes, only the first
+// one grows by appending so a small tree stays small and a big one never copies its
+// nodes. Freed nodes are chained through left and reused before the slabs grow,
+// the slabs never shrink
+type treeSlabs struct {
+	slabs    [][]treeNode
+	free     uint32
+	freeTail uint32
+	// family is set once the tree is split, its slabs are then shared with the other
+	// side and every new slab comes from the family
+	family *treeFamily
+}
+
+// treeFamily holds the slabs of the trees split from the same tree. Every tree reads
+// them through its own prefix of slabs, only appended to under mu, and only writes the
+// nodes it holds or freed, so trees of a family can be used from different goroutines
+type treeFamily struct {
+	mu    sync.Mutex
+	slabs [][]treeNode
+}
+
+func newTreeSlabs() treeSlabs {
+	return treeSlabs{slabs: [][]treeNode{make([]treeNode, 1, 8)}}
+}
+
+func (s *treeSlabs) node(i uint32) *treeNode {
+	return &s.slabs[i>>treeSlabBits][i&(treeSlabSize-1)]
+}
+
+// alloc returns the index of a new leaf, it invalidates the *treeNode taken before
+func (s *treeSlabs) alloc(key string, score float64) uint32 {
+	leaf := treeNode{Node: Node{key: key, score: score}, count: 1, height: 1}
+
+	if s.free == 0 && s.family != nil {
+		s.claim()
+	}
+	if i := s.free; i != 0 {
+		s.free = s.node(i).left
+		if s.free == 0 {
+			s.freeTail = 0
+		}
+		*s.node(i) = leaf
+		return i
+	}
+
+	last := len(s.slabs) - 1
+	if len(s.slabs[last]) == treeSlabSize {
+		s.slabs = append(s.slabs, make([]treeNode, 0, treeSlabSize))
+		last++
+	}
+	s.slabs[last] = append(s.slabs[last], leaf)
+	return uint32(last<<treeSlabBits + len(s.slabs[last]) - 1)
+}
+
+func (s *treeSlabs) release(i uint32) {
+	*s.node(i) = treeNode{left: s.free}
+	if s.free == 0 {
+		s.freeTail = i
+	}
+	s.free = i
+}
+
+// claim appends a new slab to the family and frees all of its nodes, the free list has
+// to be empty
+func (s *treeSlabs) claim() {
+	slab := make([]treeNode, treeSlabSize)
+	s.family.mu.Lock()
+	s.family.slabs = append(s.family.slabs, slab)
+	s.slabs = s.family.slabs
+	s.family.mu.Unlock()
+
+	first := uint32(len(s.slabs)-1) << treeSlabBits
+	for i := range slab[:treeSlabSize-1] {
+		slab[i].left = first + uint32(i) + 1
+	}
+	s.free, s.freeTail = first, first+treeSlabSize-1
+}
+
+// share moves the slabs to a family, so they can be shared with the other side of a
+// split. The family only hands out whole slabs, the unused nodes of the last one are
+// freed first. The first slab has to be full
+func (s *treeSlabs) share() {
+	if s.family != nil {
+		return
+	}
+
+	last := len(s.slabs) - 1
+	used := len(s.slabs[last])
+	s.slabs[last] = s.slabs[last][:treeSlabSize]
+	for i := used; i < treeSlabSize; i++ {
+		s.release(uint32(last<<treeSlabBits + i))
+	}
+	s.family = &treeFamily{slabs: s.slabs}
+}
+
+// takeSlabs reads the slabs of other, from the same family, and takes its free nodes
+func (s *treeSlabs) takeSlabs(other *treeSlabs) {
+	if len(other.slabs) > len(s.slabs) {
+		s.slabs = other.slabs
+	}
+
+	if other.free == 0 {
+		return
+	}
+	if s.free == 0 {
+		s.free = other.free
+	} else {
+		s.node(s.freeTail).left = other.free
+	}
+	s.freeTail = other.freeTail
+}
+
+func (s *treeSlabs) count(i uint32) int {
+	return int(s.node(i).count)
+}
+
+func (s *treeSlabs) height(i uint32) int {
+	return int(s.node(i).height)
+}
+
+func (s *treeSlabs) balance(i uint32) int {
+	n := s.node(i)
+	return s.height(n.left) - s.height(n.right)
+}
+
+func (s *treeSlabs) update(i uint32) {
+	n := s.node(i)
+	n.height = uint8(1 + max(s.height(n.left), s.height(n.right)))
+	n.count = uint32(1 + s.count(n.left) + s.count(n.right))
+}
+
+// rank returns the rank of i from the rank of its parent p, p is 0 for the root
+func (s *treeSlabs) rank(i, p uint32, pRank int) int {
+	n := s.node(i)
+	if p == 0 {
+		return 1 + s.count(n.left)
+	}
+
+	if compareNode(&n.Node, &s.node(p).Node) < 0 {
+		return pRank - s.count(n.right) - 1
+	}
+	return 1 + pRank + s.count(n.left)
+}
+
+func (s *treeSlabs) rankReverse(i, p uint32, pRank int) int {
+	n := s.node(i)
+	if p == 0 {
+		return 1 + s.count(n.right)
+	}
+
+	if compareNode(&n.Node, &s.node(p).Node) > 0 {
+		return pRank - s.count(n.left) - 1
+	}
+	return 1 + pRank + s.count(n.right)
+}
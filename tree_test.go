@@ -3,7 +3,9 @@
 package zdb
 
 import (
+	"math/bits"
 	"slices"
+	"strconv"
 	"testing"
 )
 
@@ -76,7 +78,7 @@ func TestAVLAdd(t *testing.T) {
 			continue
 		}
 		t.Run(test.Name, func(t *testing.T) {
-			tree := NewTree()
+			tree := NewTree().(*Tree)
 			for _, node := range test.Nodes {
 				tree.Add(node.key, node.score)
 			}
@@ -141,6 +143,18 @@ func TestAVLRemove(t *testing.T) {
 			DeletedNodes: []Node{*NewNode("C", 107), *NewNode("D", 17)},
 			Want:         []string{"E", "B", "A"},
 		},
+		{
+			Name: "Two Children",
+			Nodes: []Node{
+				*NewNode("A", 105),
+				*NewNode("B", 72),
+				*NewNode("C", 107),
+				*NewNode("D", 17),
+				*NewNode("E", 89),
+			},
+			DeletedNodes: []Node{*NewNode("B", 72)},
+			Want:         []string{"A", "D", "E", "C"},
+		},
 	}
 
 	for _, test := range tests {
@@ -149,7 +163,7 @@ func TestAVLRemove(t *testing.T) {
 			continue
 		}
 		t.Run(test.Name, func(t *testing.T) {
-			tree := NewTree()
+			tree := NewTree().(*Tree)
 			for _, node := range test.Nodes {
 				tree.Add(node.key, node.score)
 			}
@@ -163,805 +177,834 @@ func TestAVLRemove(t *testing.T) {
 	}
 }
 
-func TestAVLRank(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("A1", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
-
-	tests := []struct {
-		Name      string
-		SearchKey string
-		Want      int
-		Skip      bool
-	}{
-		{
-			Name:      "Rank 50",
-			SearchKey: "A",
-			Want:      4,
-		},
-		{
-			Name:      "Rank 50, A1",
-			SearchKey: "A1",
-			Want:      5,
-		},
-		{
-			Name:      "Rank B",
-			SearchKey: "B",
-			Want:      3,
-		},
-		{
-			Name:      "Rank C",
-			SearchKey: "C",
-			Want:      6,
-		},
-		{
-			Name:      "Rank E",
-			SearchKey: "E",
-			Want:      2,
-		},
-		{
-			Name:      "Rank D",
-			SearchKey: "D",
-			Want:      1,
-		},
-		{
-			Name:      "Search for rank of non-existing element",
-			SearchKey: "NOT",
-			Want:      -1,
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+func TestRank(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("A1", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name      string
+			SearchKey string
+			Want      int
+			Skip      bool
+		}{
+			{
+				Name:      "Rank 50",
+				SearchKey: "A",
+				Want:      4,
+			},
+			{
+				Name:      "Rank 50, A1",
+				SearchKey: "A1",
+				Want:      5,
+			},
+			{
+				Name:      "Rank B",
+				SearchKey: "B",
+				Want:      3,
+			},
+			{
+				Name:      "Rank C",
+				SearchKey: "C",
+				Want:      6,
+			},
+			{
+				Name:      "Rank E",
+				SearchKey: "E",
+				Want:      2,
+			},
+			{
+				Name:      "Rank D",
+				SearchKey: "D",
+				Want:      1,
+			},
+			{
+				Name:      "Search for rank of non-existing element",
+				SearchKey: "NOT",
+				Want:      -1,
+			},
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := avl.Rank(test.SearchKey)
-
-			if got != test.Want {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
-}
-
-func TestAVLRankReverse(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("A1", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
 
-	tests := []struct {
-		Name      string
-		SearchKey string
-		Want      int
-		Skip      bool
-	}{
-		{
-			Name:      "Rank 50",
-			SearchKey: "A",
-			Want:      3,
-		},
-		{
-			Name:      "Rank 50, A1",
-			SearchKey: "A1",
-			Want:      2,
-		},
-		{
-			Name:      "Rank B",
-			SearchKey: "B",
-			Want:      4,
-		},
-		{
-			Name:      "Rank C",
-			SearchKey: "C",
-			Want:      1,
-		},
-		{
-			Name:      "Rank E",
-			SearchKey: "E",
-			Want:      5,
-		},
-		{
-			Name:      "Rank D",
-			SearchKey: "D",
-			Want:      6,
-		},
-		{
-			Name:      "Search for rank of non-existing element",
-			SearchKey: "NOT",
-			Want:      -1,
-		},
-	}
+			t.Run(test.Name, func(t *testing.T) {
+				got := avl.Rank(test.SearchKey)
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+				if got != test.Want {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
 		}
-
-		t.Run(test.Name, func(t *testing.T) {
-			got := avl.RankReverse(test.SearchKey)
-
-			if got != test.Want {
-				t.Errorf("got %v, want %v\n", got, test.Want)
-			}
-		})
-	}
+	})
 }
 
-func TestAVLSelect(t *testing.T) {
-	// setup
+func TestRankReverse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("A1", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name      string
+			SearchKey string
+			Want      int
+			Skip      bool
+		}{
+			{
+				Name:      "Rank 50",
+				SearchKey: "A",
+				Want:      3,
+			},
+			{
+				Name:      "Rank 50, A1",
+				SearchKey: "A1",
+				Want:      2,
+			},
+			{
+				Name:      "Rank B",
+				SearchKey: "B",
+				Want:      4,
+			},
+			{
+				Name:      "Rank C",
+				SearchKey: "C",
+				Want:      1,
+			},
+			{
+				Name:      "Rank E",
+				SearchKey: "E",
+				Want:      5,
+			},
+			{
+				Name:      "Rank D",
+				SearchKey: "D",
+				Want:      6,
+			},
+			{
+				Name:      "Search for rank of non-existing element",
+				SearchKey: "NOT",
+				Want:      -1,
+			},
+		}
 
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
+			}
 
-	tests := []struct {
-		Name      string
-		SelectIdx int
-		Want      *Node
-		Skip      bool
-	}{
-		{
-			Name:      "Search for 2nd ranked",
-			SelectIdx: 2,
-			Want:      NewNode("E", 11),
-		},
-		{
-			Name:      "Search for last ranked",
-			SelectIdx: 4,
-			Want:      NewNode("A", 50),
-		},
-		{
-			Name:      "Search for out of bound index",
-			SelectIdx: 10,
-			Want:      nil,
-		},
-	}
+			t.Run(test.Name, func(t *testing.T) {
+				got := avl.RankReverse(test.SearchKey)
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+				if got != test.Want {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
 		}
-
-		t.Run(test.Name, func(t *testing.T) {
-			got := avl.Select(test.SelectIdx)
-			checkNilOrWantedNode(t, got, test.Want)
-		})
-	}
+	})
 }
 
-func TestAVLSelectReverse(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
+func TestSelect(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name      string
+			SelectIdx int
+			Want      *Node
+			Skip      bool
+		}{
+			{
+				Name:      "Search for 2nd ranked",
+				SelectIdx: 2,
+				Want:      NewNode("E", 11),
+			},
+			{
+				Name:      "Search for last ranked",
+				SelectIdx: 4,
+				Want:      NewNode("A", 50),
+			},
+			{
+				Name:      "Search for out of bound index",
+				SelectIdx: 10,
+				Want:      nil,
+			},
+		}
 
-	tests := []struct {
-		Name      string
-		SelectIdx int
-		Want      *Node
-		Skip      bool
-	}{
-		{
-			Name:      "Search for 2nd ranked",
-			SelectIdx: 2,
-			Want:      NewNode("A", 50),
-		},
-		{
-			Name:      "Search for last ranked",
-			SelectIdx: 5,
-			Want:      NewNode("D", 10),
-		},
-		{
-			Name:      "Search for out of bound index",
-			SelectIdx: 10,
-			Want:      nil,
-		},
-	}
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
+			}
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+			t.Run(test.Name, func(t *testing.T) {
+				got := avl.Select(test.SelectIdx)
+				checkNilOrWantedNode(t, got, test.Want)
+			})
 		}
-
-		t.Run(test.Name, func(t *testing.T) {
-			got := avl.SelectReverse(test.SelectIdx)
-			checkNilOrWantedNode(t, got, test.Want)
-		})
-	}
+	})
 }
 
-func TestAVLRangeByIndex(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
-
-	tests := []struct {
-		Name  string
-		Start int
-		End   int
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get 0-2 ranked elements",
-			Start: 0,
-			End:   2,
-			Want:  []string{"D", "E", "B"},
-		},
-		{
-			Name:  "Get 2 ranked elements",
-			Start: 1,
-			End:   1,
-			Want:  []string{"E"},
-		},
-		{
-			Name:  "Get 0-100 ranked elements, out of bound end",
-			Start: 0,
-			End:   100,
-			Want:  []string{"D", "E", "B", "A", "C"},
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+func TestSelectReverse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name      string
+			SelectIdx int
+			Want      *Node
+			Skip      bool
+		}{
+			{
+				Name:      "Search for 2nd ranked",
+				SelectIdx: 2,
+				Want:      NewNode("A", 50),
+			},
+			{
+				Name:      "Search for last ranked",
+				SelectIdx: 5,
+				Want:      NewNode("D", 10),
+			},
+			{
+				Name:      "Search for out of bound index",
+				SelectIdx: 10,
+				Want:      nil,
+			},
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByIndex(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
-
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
-}
-
-func TestAVLRangeByIndexReverse(t *testing.T) {
-	// setup
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
-
-	tests := []struct {
-		Name  string
-		Start int
-		End   int
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get 0-2 ranked elements",
-			Start: 0,
-			End:   2,
-			Want:  []string{"C", "A", "B"},
-		},
-		{
-			Name:  "Get 2 ranked elements",
-			Start: 1,
-			End:   1,
-			Want:  []string{"A"},
-		},
-		{
-			Name:  "Get 0-100 ranked elements, out of bound end",
-			Start: 0,
-			End:   100,
-			Want:  []string{"C", "A", "B", "E", "D"},
-		},
-	}
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+			t.Run(test.Name, func(t *testing.T) {
+				got := avl.SelectReverse(test.SelectIdx)
+				checkNilOrWantedNode(t, got, test.Want)
+			})
 		}
-
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByIndexReverse(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
-
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
-			}
-		})
-	}
+	})
 }
 
-func TestAVLRangeByScore(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
-
-	tests := []struct {
-		Name  string
-		Start float64
-		End   float64
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get elements with score range within limit",
-			Start: 10,
-			End:   70,
-			Want:  []string{"D", "E", "B", "A", "C"},
-		},
-		{
-			Name:  "Get elements with score range high out of limit",
-			Start: 50,
-			End:   100,
-			Want:  []string{"A", "C"},
-		},
-		{
-			Name:  "Get elements with score range low and high out of limit",
-			Start: 100,
-			End:   100,
-			Want:  []string{},
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+func TestRangeByIndex(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name  string
+			Start int
+			End   int
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get 0-2 ranked elements",
+				Start: 0,
+				End:   2,
+				Want:  []string{"D", "E", "B"},
+			},
+			{
+				Name:  "Get 2 ranked elements",
+				Start: 1,
+				End:   1,
+				Want:  []string{"E"},
+			},
+			{
+				Name:  "Get 0-100 ranked elements, out of bound end",
+				Start: 0,
+				End:   100,
+				Want:  []string{"D", "E", "B", "A", "C"},
+			},
+			{
+				Name:  "Get 4-5 ranked elements, start past the root",
+				Start: 4,
+				End:   5,
+				Want:  []string{"C"},
+			},
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByScore(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
-
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
-}
-
-func TestAVLRangeByScoreReverse(t *testing.T) {
-	// setup
-
-	avl := NewTree()
-	avl.Add("A", 50)
-	avl.Add("B", 15)
-	avl.Add("C", 70)
-	avl.Add("D", 10)
-	avl.Add("E", 11)
 
-	tests := []struct {
-		Name  string
-		Start float64
-		End   float64
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get elements with score range within limit",
-			Start: 10,
-			End:   70,
-			Want:  []string{"C", "A", "B", "E", "D"},
-		},
-		{
-			Name:  "Get elements with score range high out of limit",
-			Start: 50,
-			End:   100,
-			Want:  []string{"C", "A"},
-		},
-		{
-			Name:  "Get elements with score range low and high out of limit",
-			Start: 100,
-			End:   100,
-			Want:  []string{},
-		},
-		{
-			Name:  "Get elements with score range low and high out of limit",
-			Start: 70,
-			End:   90,
-			Want:  []string{"C"},
-		},
-	}
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByIndex(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
 		}
+	})
+}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByScoreReverse(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
+func TestRangeByIndexReverse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name  string
+			Start int
+			End   int
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get 0-2 ranked elements",
+				Start: 0,
+				End:   2,
+				Want:  []string{"C", "A", "B"},
+			},
+			{
+				Name:  "Get 2 ranked elements",
+				Start: 1,
+				End:   1,
+				Want:  []string{"A"},
+			},
+			{
+				Name:  "Get 0-100 ranked elements, out of bound end",
+				Start: 0,
+				End:   100,
+				Want:  []string{"C", "A", "B", "E", "D"},
+			},
+		}
 
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
-}
-
-func TestAVLRangeByLex(t *testing.T) {
-	// setup
 
-	avl := NewTree()
-	avl.Add("A", 0)
-	avl.Add("B", 0)
-	avl.Add("C", 0)
-	avl.Add("D", 0)
-	avl.Add("E", 0)
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByIndexReverse(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
 
-	tests := []struct {
-		Name  string
-		Start string
-		End   string
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get elements with score range within limit",
-			Start: "A",
-			End:   "Z",
-			Want:  []string{"A", "B", "C", "D", "E"},
-		},
-		{
-			Name:  "Get elements with score range within limit",
-			Start: "C",
-			End:   "D",
-			Want:  []string{"C", "D"},
-		},
-	}
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
+		}
+	})
+}
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+func TestRangeByScore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name  string
+			Start float64
+			End   float64
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get elements with score range within limit",
+				Start: 10,
+				End:   70,
+				Want:  []string{"D", "E", "B", "A", "C"},
+			},
+			{
+				Name:  "Get elements with score range high out of limit",
+				Start: 50,
+				End:   100,
+				Want:  []string{"A", "C"},
+			},
+			{
+				Name:  "Get elements with score range low and high out of limit",
+				Start: 100,
+				End:   100,
+				Want:  []string{},
+			},
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByLex(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
-
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
+
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByScore(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
+
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
+		}
+	})
 }
 
-func TestAVLRangeByLexReverse(t *testing.T) {
-	// setup
+func TestRangeByScoreReverse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 50)
+		avl.Add("B", 15)
+		avl.Add("C", 70)
+		avl.Add("D", 10)
+		avl.Add("E", 11)
+
+		tests := []struct {
+			Name  string
+			Start float64
+			End   float64
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get elements with score range within limit",
+				Start: 10,
+				End:   70,
+				Want:  []string{"C", "A", "B", "E", "D"},
+			},
+			{
+				Name:  "Get elements with score range high out of limit",
+				Start: 50,
+				End:   100,
+				Want:  []string{"C", "A"},
+			},
+			{
+				Name:  "Get elements with score range low and high out of limit",
+				Start: 100,
+				End:   100,
+				Want:  []string{},
+			},
+			{
+				Name:  "Get elements with score range low and high out of limit",
+				Start: 70,
+				End:   90,
+				Want:  []string{"C"},
+			},
+		}
 
-	avl := NewTree()
-	avl.Add("A", 0)
-	avl.Add("B", 0)
-	avl.Add("C", 0)
-	avl.Add("D", 0)
-	avl.Add("E", 0)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
+			}
 
-	tests := []struct {
-		Name  string
-		Start string
-		End   string
-		Want  []string
-		Skip  bool
-	}{
-		{
-			Name:  "Get elements with score range within limit",
-			Start: "A",
-			End:   "Z",
-			Want:  []string{"E", "D", "C", "B", "A"},
-		},
-		{
-			Name:  "Get elements with score range within limit",
-			Start: "C",
-			End:   "D",
-			Want:  []string{"D", "C"},
-		},
-	}
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByScoreReverse(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
 
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
 		}
+	})
+}
 
-		t.Run(test.Name, func(t *testing.T) {
-			got := Map(
-				avl.RangeByLexReverse(test.Start, test.End),
-				func(n Node) string { return n.key },
-			)
+func TestRangeByLex(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 0)
+		avl.Add("B", 0)
+		avl.Add("C", 0)
+		avl.Add("D", 0)
+		avl.Add("E", 0)
+
+		tests := []struct {
+			Name  string
+			Start string
+			End   string
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get elements with score range within limit",
+				Start: "A",
+				End:   "Z",
+				Want:  []string{"A", "B", "C", "D", "E"},
+			},
+			{
+				Name:  "Get elements with score range within limit",
+				Start: "C",
+				End:   "D",
+				Want:  []string{"C", "D"},
+			},
+		}
 
-			if slices.Compare(got, test.Want) != 0 {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
-		})
-	}
+
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByLex(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
+
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
+		}
+	})
 }
 
-func TestAVLDiff(t *testing.T) {
-	tests := []struct {
-		Name  string
-		Tree1 []Node
-		Tree2 []Node
-		Want  []Node
-		Skip  bool
-	}{
-		{
-			Name: "Diff key diff score",
-			Tree1: []Node{
-				*NewNode("A", 50),
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-			},
-			Tree2: []Node{
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-			},
-			Want: []Node{
-				*NewNode("A", 50),
+func TestRangeByLexReverse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		avl := newTree()
+		avl.Add("A", 0)
+		avl.Add("B", 0)
+		avl.Add("C", 0)
+		avl.Add("D", 0)
+		avl.Add("E", 0)
+
+		tests := []struct {
+			Name  string
+			Start string
+			End   string
+			Want  []string
+			Skip  bool
+		}{
+			{
+				Name:  "Get elements with score range within limit",
+				Start: "A",
+				End:   "Z",
+				Want:  []string{"E", "D", "C", "B", "A"},
 			},
-		},
-		{
-			Name: "Same key different score",
-			Tree1: []Node{
-				*NewNode("A", 50),
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
+			{
+				Name:  "Get elements with score range within limit",
+				Start: "C",
+				End:   "D",
+				Want:  []string{"D", "C"},
 			},
-			Tree2: []Node{
-				*NewNode("B", 10),
-				*NewNode("E", 11),
+		}
+
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
+			}
+
+			t.Run(test.Name, func(t *testing.T) {
+				got := Map(
+					avl.RangeByLexReverse(test.Start, test.End),
+					func(n Node) string { return n.key },
+				)
+
+				if slices.Compare(got, test.Want) != 0 {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		tests := []struct {
+			Name  string
+			Tree1 []Node
+			Tree2 []Node
+			Want  []Node
+			Skip  bool
+		}{
+			{
+				Name: "Diff key diff score",
+				Tree1: []Node{
+					*NewNode("A", 50),
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				Tree2: []Node{
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				Want: []Node{
+					*NewNode("A", 50),
+				},
 			},
-			Want: []Node{
-				*NewNode("D", 10),
-				*NewNode("A", 50),
-				*NewNode("C", 70),
+			{
+				Name: "Same key different score",
+				Tree1: []Node{
+					*NewNode("A", 50),
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				Tree2: []Node{
+					*NewNode("B", 10),
+					*NewNode("E", 11),
+				},
+				Want: []Node{
+					*NewNode("D", 10),
+					*NewNode("A", 50),
+					*NewNode("C", 70),
+				},
 			},
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			t1 := NewTree()
-			for _, node := range test.Tree1 {
-				t1.Add(node.key, node.score)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
 
-			t2 := NewTree()
-			for _, node := range test.Tree2 {
-				t2.Add(node.key, node.score)
-			}
+			t.Run(test.Name, func(t *testing.T) {
+				t1 := newTree()
+				for _, node := range test.Tree1 {
+					t1.Add(node.key, node.score)
+				}
+
+				t2 := newTree()
+				for _, node := range test.Tree2 {
+					t2.Add(node.key, node.score)
+				}
 
-			diff := t1.Diff(t2)
-			it := NewTreeIterator(diff)
-			it.Seek(nil)
+				diff := t1.Diff(t2)
+				it := diff.Iterator(0)
 
-			diffNodes := []*Node{}
-			for {
-				next := it.Next()
-				if next == nil {
-					break
+				diffNodes := []*Node{}
+				for {
+					next := it.Next()
+					if next == nil {
+						break
+					}
+					diffNodes = append(diffNodes, next)
 				}
-				diffNodes = append(diffNodes, next)
-			}
 
-			for i, node := range diffNodes {
-				checkNilOrWantedNode(t, node, &test.Want[i])
-			}
-		})
-	}
+				for i, node := range diffNodes {
+					checkNilOrWantedNode(t, node, &test.Want[i])
+				}
+			})
+		}
+	})
 }
 
-func TestAVLInter(t *testing.T) {
-	// setup
+func TestInter(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
 
-	sumAggFunc := func(score1, score2 float64) float64 {
-		return score1 + score2
-	}
+		sumAggFunc := func(score1, score2 float64) float64 {
+			return score1 + score2
+		}
 
-	tests := []struct {
-		Name    string
-		Tree1   []Node
-		Tree2   []Node
-		Want    []Node
-		AggFunc func(score1, score2 float64) float64
-		Skip    bool
-	}{
-		{
-			Name: "Diff key diff score",
-			Tree1: []Node{
-				*NewNode("A", 50),
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-			},
-			Tree2: []Node{
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-			},
-			AggFunc: sumAggFunc,
-			Want: []Node{
-				*NewNode("D", 20),
-				*NewNode("E", 22),
-				*NewNode("B", 30),
-				*NewNode("C", 140),
+		tests := []struct {
+			Name    string
+			Tree1   []Node
+			Tree2   []Node
+			Want    []Node
+			AggFunc func(score1, score2 float64) float64
+			Skip    bool
+		}{
+			{
+				Name: "Diff key diff score",
+				Tree1: []Node{
+					*NewNode("A", 50),
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				Tree2: []Node{
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				AggFunc: sumAggFunc,
+				Want: []Node{
+					*NewNode("D", 20),
+					*NewNode("E", 22),
+					*NewNode("B", 30),
+					*NewNode("C", 140),
+				},
 			},
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			t1 := NewTree()
-			for _, node := range test.Tree1 {
-				t1.Add(node.key, node.score)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
 
-			t2 := NewTree()
-			for _, node := range test.Tree2 {
-				t2.Add(node.key, node.score)
-			}
+			t.Run(test.Name, func(t *testing.T) {
+				t1 := newTree()
+				for _, node := range test.Tree1 {
+					t1.Add(node.key, node.score)
+				}
+
+				t2 := newTree()
+				for _, node := range test.Tree2 {
+					t2.Add(node.key, node.score)
+				}
 
-			diff := t1.Inter(t2, test.AggFunc)
-			it := NewTreeIterator(diff)
-			it.Seek(nil)
+				diff := t1.Inter(t2, test.AggFunc)
+				it := diff.Iterator(0)
 
-			diffNodes := []*Node{}
-			for {
-				next := it.Next()
-				if next == nil {
-					break
+				diffNodes := []*Node{}
+				for {
+					next := it.Next()
+					if next == nil {
+						break
+					}
+					diffNodes = append(diffNodes, next)
 				}
-				diffNodes = append(diffNodes, next)
-			}
 
-			for i, node := range diffNodes {
-				checkNilOrWantedNode(t, node, &test.Want[i])
-			}
-		})
-	}
+				for i, node := range diffNodes {
+					checkNilOrWantedNode(t, node, &test.Want[i])
+				}
+			})
+		}
+	})
 }
 
-func TestAVLUnion(t *testing.T) {
-	// setup
+func TestUnion(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
 
-	sumAggFunc := func(score1, score2 float64) float64 {
-		return score1 + score2
-	}
+		sumAggFunc := func(score1, score2 float64) float64 {
+			return score1 + score2
+		}
 
-	tests := []struct {
-		Name    string
-		Tree1   []Node
-		Tree2   []Node
-		Want    []Node
-		AggFunc func(score1, score2 float64) float64
-		Skip    bool
-	}{
-		{
-			Name: "Union 2 Trees",
-			Tree1: []Node{
-				*NewNode("A", 50),
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-			},
-			Tree2: []Node{
-				*NewNode("B", 15),
-				*NewNode("C", 70),
-				*NewNode("D", 10),
-				*NewNode("E", 11),
-				*NewNode("F", 12),
-			},
-			AggFunc: sumAggFunc,
-			Want: []Node{
-				*NewNode("F", 12),
-				*NewNode("D", 20),
-				*NewNode("E", 22),
-				*NewNode("B", 30),
-				*NewNode("A", 50),
-				*NewNode("C", 140),
+		tests := []struct {
+			Name    string
+			Tree1   []Node
+			Tree2   []Node
+			Want    []Node
+			AggFunc func(score1, score2 float64) float64
+			Skip    bool
+		}{
+			{
+				Name: "Union 2 Trees",
+				Tree1: []Node{
+					*NewNode("A", 50),
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+				},
+				Tree2: []Node{
+					*NewNode("B", 15),
+					*NewNode("C", 70),
+					*NewNode("D", 10),
+					*NewNode("E", 11),
+					*NewNode("F", 12),
+				},
+				AggFunc: sumAggFunc,
+				Want: []Node{
+					*NewNode("F", 12),
+					*NewNode("D", 20),
+					*NewNode("E", 22),
+					*NewNode("B", 30),
+					*NewNode("A", 50),
+					*NewNode("C", 140),
+				},
 			},
-		},
-	}
-
-	for _, test := range tests {
-		if test.Skip {
-			t.Logf("Skipping test %s\n", test.Name)
-			continue
 		}
 
-		t.Run(test.Name, func(t *testing.T) {
-			t1 := NewTree()
-			for _, node := range test.Tree1 {
-				t1.Add(node.key, node.score)
-			}
-
-			t2 := NewTree()
-			for _, node := range test.Tree2 {
-				t2.Add(node.key, node.score)
+		for _, test := range tests {
+			if test.Skip {
+				t.Logf("Skipping test %s\n", test.Name)
+				continue
 			}
 
-			union := t1.Union(t2, test.AggFunc)
-			it := NewTreeIterator(union)
-			it.Seek(nil)
+			t.Run(test.Name, func(t *testing.T) {
+				t1 := newTree()
+				for _, node := range test.Tree1 {
+					t1.Add(node.key, node.score)
+				}
 
-			idx := 0
-			for {
-				next := it.Next()
-				if next == nil {
-					break
+				t2 := newTree()
+				for _, node := range test.Tree2 {
+					t2.Add(node.key, node.score)
 				}
 
-				checkNilOrWantedNode(t, next, &test.Want[idx])
-				idx += 1
-			}
-		})
-	}
+				union := t1.Union(t2, test.AggFunc)
+				it := union.Iterator(0)
+
+				idx := 0
+				for {
+					next := it.Next()
+					if next == nil {
+						break
+					}
+
+					checkNilOrWantedNode(t, next, &test.Want[idx])
+					idx += 1
+				}
+			})
+		}
+	})
 }
 
 func TestAVLrankByScoreLowerBound(t *testing.T) {
@@ -1045,71 +1088,176 @@ func TestAVLrankByScoreUpperBound(t *testing.T) {
 	}
 }
 
-func TestAVLCountByScore(t *testing.T) {
-	// setup
+func TestCountByScore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// setup
+
+		tree := newTree()
+		tree.Add("A", 50)
+		tree.Add("B", 15)
+		tree.Add("C", 70)
+		tree.Add("D", 10)
+		tree.Add("E", 11)
+		tree.Add("E1", 11)
+
+		tests := []struct {
+			Name string
+			Min  float64
+			Max  float64
+			Want int
+		}{
+			{
+				Name: "Lower than first rank",
+				Min:  9,
+				Max:  11,
+				Want: 3,
+			},
+			{
+				Name: "Lower than first rank",
+				Min:  10,
+				Max:  11,
+				Want: 3,
+			},
+			{
+				Name: "Lower than first rank",
+				Min:  10,
+				Max:  95,
+				Want: 6,
+			},
+			{
+				Name: "Lower than first rank",
+				Min:  0,
+				Max:  95,
+				Want: 6,
+			},
+			{
+				Name: "Lower than first rank",
+				Min:  11,
+				Max:  95,
+				Want: 5,
+			},
+			{
+				Name: "Lower than first rank",
+				Min:  11,
+				Max:  70,
+				Want: 5,
+			},
+		}
 
-	tree := NewTree()
-	tree.Add("A", 50)
-	tree.Add("B", 15)
-	tree.Add("C", 70)
-	tree.Add("D", 10)
-	tree.Add("E", 11)
-	tree.Add("E1", 11)
+		for _, test := range tests {
+			t.Run(test.Name, func(t *testing.T) {
+				got := tree.CountByScore(test.Min, test.Max)
+				if got != test.Want {
+					t.Errorf("got %v, want %v\n", got, test.Want)
+				}
+			})
+		}
+	})
+}
 
+func TestAVLValidate(t *testing.T) {
 	tests := []struct {
-		Name string
-		Min  float64
-		Max  float64
-		Want int
+		Name    string
+		Nodes   []Node
+		Corrupt func(tree *Tree)
+		WantErr bool
 	}{
 		{
-			Name: "Lower than first rank",
-			Min:  9,
-			Max:  11,
-			Want: 3,
-		},
-		{
-			Name: "Lower than first rank",
-			Min:  10,
-			Max:  11,
-			Want: 3,
+			Name: "Valid tree",
+			Nodes: []Node{
+				*NewNode("A", 15),
+				*NewNode("B", 5),
+				*NewNode("C", 1),
+				*NewNode("D", 20),
+				*NewNode("E", 17),
+			},
+			Corrupt: func(tree *Tree) {},
+			WantErr: false,
 		},
 		{
-			Name: "Lower than first rank",
-			Min:  10,
-			Max:  95,
-			Want: 6,
+			Name: "Wrong count",
+			Nodes: []Node{
+				*NewNode("A", 15),
+				*NewNode("B", 5),
+				*NewNode("C", 1),
+			},
+			Corrupt: func(tree *Tree) { tree.node(tree.root).count = 2 },
+			WantErr: true,
 		},
 		{
-			Name: "Lower than first rank",
-			Min:  0,
-			Max:  95,
-			Want: 6,
+			Name: "Wrong height",
+			Nodes: []Node{
+				*NewNode("A", 15),
+				*NewNode("B", 5),
+				*NewNode("C", 1),
+			},
+			Corrupt: func(tree *Tree) { tree.node(tree.node(tree.root).left).height = 3 },
+			WantErr: true,
 		},
 		{
-			Name: "Lower than first rank",
-			Min:  11,
-			Max:  95,
-			Want: 5,
+			Name: "Wrong ordering",
+			Nodes: []Node{
+				*NewNode("A", 15),
+				*NewNode("B", 5),
+				*NewNode("C", 1),
+			},
+			Corrupt: func(tree *Tree) { tree.node(tree.node(tree.root).left).score = 10 },
+			WantErr: true,
 		},
 		{
-			Name: "Lower than first rank",
-			Min:  11,
-			Max:  70,
-			Want: 5,
+			Name: "Unbalanced",
+			Nodes: []Node{
+				*NewNode("A", 15),
+			},
+			Corrupt: func(tree *Tree) {
+				b, c := tree.alloc("B", 20), tree.alloc("C", 25)
+				tree.node(tree.root).right = b
+				tree.node(b).right = c
+				tree.update(b)
+				tree.update(tree.root)
+				tree.members.set("B", 20)
+				tree.members.set("C", 25)
+			},
+			WantErr: true,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			got := tree.CountByScore(test.Min, test.Max)
-			if got != test.Want {
-				t.Errorf("got %v, want %v\n", got, test.Want)
+			tree := NewTree()
+			for _, node := range test.Nodes {
+				tree.Add(node.key, node.score)
+			}
+			test.Corrupt(tree.(*Tree))
+
+			err := tree.Validate()
+			if (err != nil) != test.WantErr {
+				t.Errorf("got err %v, want err %v", err, test.WantErr)
 			}
 		})
 	}
 }
 
+var backends = []struct {
+	Name string
+	New  func() OrderStatisticTree
+}{
+	{Name: "avltree", New: NewTree},
+	{Name: "skiplist", New: NewSkipList},
+	{Name: "listpack", New: NewListpack},
+	// converted to its backend in the middle of most tests
+	{Name: "sortedset", New: func() OrderStatisticTree { return NewSortedSet(SkipListBackend, 4, DefaultListpackMaxValue) }},
+}
+
+// forEachBackend runs test against every OrderStatisticTree implementation
+func forEachBackend(t *testing.T, test func(t *testing.T, newTree func() OrderStatisticTree)) {
+	for _, backend := range backends {
+		t.Run(backend.Name, func(t *testing.T) {
+			test(t, backend.New)
+		})
+	}
+}
+
 func checkNilOrWantedNode(t *testing.T, got, want *Node) {
 	t.Helper()
 
@@ -1124,24 +1272,24 @@ func checkNilOrWantedNode(t *testing.T, got, want *Node) {
 	}
 }
 
-func checkPreOrderKeyTree(t *testing.T, tree OrderStatisticTree, want []string) {
+func checkPreOrderKeyTree(t *testing.T, tree *Tree, want []string) {
 	t.Helper()
 
 	traversalFunc := func() []string {
 		keys := []string{}
-		stack := []*Node{}
-		curr := tree.Root()
-		for curr != nil || len(stack) > 0 {
-			if curr == nil {
+		stack := []uint32{}
+		curr := tree.root
+		for curr != 0 || len(stack) > 0 {
+			if curr == 0 {
 				curr = stack[len(stack)-1]
 				stack = stack[:len(stack)-1]
-				curr = curr.right
+				curr = tree.node(curr).right
 				continue
 			}
 
-			keys = append(keys, curr.key)
+			keys = append(keys, tree.node(curr).key)
 			stack = append(stack, curr)
-			curr = curr.left
+			curr = tree.node(curr).left
 		}
 		return keys
 	}
@@ -1151,3 +1299,133 @@ func checkPreOrderKeyTree(t *testing.T, tree OrderStatisticTree, want []string)
 		t.Errorf("got %v, want %v", got, want)
 	}
 }
+
+func TestHashCollision(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+		// distinct members with the same FNV-1a hash
+		x, y := "9c13933ed2e72da9", "3397c11d43d5b366"
+		if (fnv64a{}).Sum64(x) != (fnv64a{}).Sum64(y) {
+			t.Fatalf("%q and %q don't collide", x, y)
+		}
+
+		tree := newTree()
+		tree.Add(x, 1)
+		tree.Add(y, 2)
+
+		for member, want := range map[string]float64{x: 1, y: 2} {
+			if got, err := tree.GetScore(member); err != nil || got != want {
+				t.Errorf("GetScore(%q) = %v, %v, want %v", member, got, err, want)
+			}
+		}
+		if got := tree.Len(); got != 2 {
+			t.Errorf("got count %d, want 2", got)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Errorf("got err %v, want nil", err)
+		}
+
+		tree.Remove(x)
+		if got, err := tree.GetScore(y); err != nil || got != 2 {
+			t.Errorf("GetScore(%q) after removing %q = %v, %v, want 2", y, x, got, err)
+		}
+		if got := tree.Rank(y); got != 1 {
+			t.Errorf("Rank(%q) = %d, want 1", y, got)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Errorf("got err %v, want nil", err)
+		}
+	})
+}
+
+func TestAddBatch(t *testing.T) {
+	many := func(n int, score func(i int) float64) (nodes []Node) {
+		for i := range n {
+			nodes = append(nodes, *NewNode("M"+strconv.Itoa(i), score(i)))
+		}
+		return nodes
+	}
+
+	tests := []struct {
+		Name    string
+		Initial []Node
+		Batch   []Node
+	}{
+		{
+			Name:  "Sorted batch into empty set",
+			Batch: many(300, func(i int) float64 { return float64(i) }),
+		},
+		{
+			Name:  "Unsorted batch into empty set",
+			Batch: many(300, func(i int) float64 { return float64(i * 7 % 13) }),
+		},
+		{
+			Name: "Last node of a member wins",
+			Batch: []Node{
+				*NewNode("A", 5),
+				*NewNode("B", 1),
+				*NewNode("A", 0),
+			},
+		},
+		{
+			Name:    "Big batch replacing members",
+			Initial: many(100, func(i int) float64 { return float64(i) }),
+			Batch:   many(200, func(i int) float64 { return float64(-i) }),
+		},
+		{
+			Name:    "Small batch into big set",
+			Initial: many(300, func(i int) float64 { return float64(i) }),
+			Batch: []Node{
+				*NewNode("M5", 1000),
+				*NewNode("new", 2.5),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, newTree func() OrderStatisticTree) {
+				got, want := newTree(), newTree()
+				got.AddBatch(test.Initial)
+				for _, node := range append(slices.Clone(test.Initial), test.Batch...) {
+					want.Add(node.key, node.score)
+				}
+				got.AddBatch(test.Batch)
+
+				if err := got.Validate(); err != nil {
+					t.Fatalf("got err %v, want nil", err)
+				}
+				if !equalNodes(got.RangeByIndex(0, got.Len()-1), want.RangeByIndex(0, want.Len()-1)) {
+					t.Errorf("got %v, want %v", got.RangeByIndex(0, got.Len()-1), want.RangeByIndex(0, want.Len()-1))
+				}
+				for _, node := range test.Batch {
+					if got, want := got.Rank(node.key), want.Rank(node.key); got != want {
+						t.Errorf("Rank(%q) got %d, want %d", node.key, got, want)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestNewTreeFromSorted(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 8, 1000} {
+		nodes := []Node{}
+		for i := range n {
+			nodes = append(nodes, *NewNode(strconv.Itoa(i), float64(i)))
+		}
+
+		tree := NewTreeFromSorted(nodes).(*Tree)
+		if err := tree.Validate(); err != nil {
+			t.Errorf("%d nodes got err %v, want nil", n, err)
+		}
+		// perfectly balanced
+		if got, want := tree.Height(), bits.Len(uint(n)); got != want {
+			t.Errorf("%d nodes got height %d, want %d", n, got, want)
+		}
+
+		tree.Add("new", -1)
+		if got := tree.Rank("new"); got != 1 {
+			t.Errorf("%d nodes got rank %d for a new member, want 1", n, got)
+		}
+	}
+}
@@ -1,95 +1,187 @@
 package zdb
 
 import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/AdhityaRamadhanus/zdb/commands"
 )
 
 type ZDB struct {
 	// TODO: Abstract out shards
-	shards Shard
+	shards *Shard
+	// only one goroutine evicts at a time
+	evictMu sync.Mutex
+
+	maxMemory      int
+	evictionPolicy EvictionPolicy
 }
 
 func NewZDB(shards uint) *ZDB {
 	return &ZDB{
-		shards: *NewShards(shards),
+		shards:         NewShards(shards),
+		evictionPolicy: NoEviction,
 	}
 }
 
 func (zdb *ZDB) ShardStats() []int {
 	lengths := []int{}
 	//TODO: encapsulate this better
-	for _, shard := range zdb.shards.DB {
+	for i, shard := range zdb.shards.DB {
+		zdb.shards.locks[i].RLock()
 		lengths = append(lengths, len(shard))
+		zdb.shards.locks[i].RUnlock()
 	}
 
 	return lengths
 }
 
-func (zdb *ZDB) Scan(cmd *commands.ScanCmd) (keys []string, nextCursor string, err error) {
-	it := NewTreeIterator(zdb.shards.Keys)
-	var cursor *Node
-	// TODO: Create a type for 0 cursor
-	if cmd.Cursor != "0" {
-		score, err := zdb.shards.Keys.GetScore(cmd.Cursor)
-		if err != nil {
-			return keys, nextCursor, err
-		}
-
-		cursor = NewNode(cmd.Cursor, score)
+func (zdb *ZDB) Scan(cmd *commands.ScanCmd) (keys []string, nextCursor string) {
+	cursor, err := strconv.ParseUint(cmd.Cursor, 10, 64)
+	if err != nil {
+		return nil, "0"
 	}
-	it.Seek(cursor)
 
-	// TODO: Match glob pattern
-	count := 0
-	for {
-		if count == cmd.Count {
-			break
+	matches := []string{}
+	zdb.shards.keysMu.Lock()
+	cursor = zdb.shards.keys.Scan(cursor, cmd.Count, func(key string) {
+		// every key holds a sorted set
+		if cmd.Type != "" && cmd.Type != "zset" {
+			return
+		}
+		if cmd.MatchPattern != "" && !matchGlob(cmd.MatchPattern, key) {
+			return
 		}
+		matches = append(matches, key)
+	})
+	zdb.shards.keysMu.Unlock()
+
+	// the maps are locked before keys, so expired keys are filtered out after the walk
+	now := time.Now()
+	for _, key := range matches {
+		unlock := zdb.shards.rlock(key)
+		if entry := zdb.shards.peekEntry(key); entry != nil && !entry.isExpired(now) {
+			keys = append(keys, key)
+		}
+		unlock()
+	}
+
+	return keys, strconv.FormatUint(cursor, 10)
+}
 
+// scanTree visits up to count nodes starting at the rank in cursor, the next cursor is the rank
+// after the last visited node or 0 once every node has been visited. Nodes added or removed
+// before the cursor during an iteration shift the ranks, so some nodes may be skipped or repeated
+func scanTree(tree OrderStatisticTree, cursor string, count int, visit func(n *Node)) (nextCursor string) {
+	rank, err := strconv.Atoi(cursor)
+	if err != nil || rank < 0 || tree == nil || tree.IsEmpty() || rank >= tree.Len() {
+		return "0"
+	}
+
+	it := tree.Iterator(rank)
+	visited := 0
+	for ; visited < count; visited++ {
 		next := it.Next()
 		if next == nil {
 			break
 		}
-		keys = append(keys, next.key)
-		count += 1
+		visit(next)
 	}
 
-	if len(keys) > 1 {
-		nextCursor = keys[len(keys)-1]
-	} else {
-		nextCursor = "0"
+	if rank+visited >= tree.Len() {
+		return "0"
+	}
+	return strconv.Itoa(rank + visited)
+}
+
+func (zdb *ZDB) Expire(cmd *commands.ExpireCmd) int {
+	unlock := zdb.shards.lock(cmd.Key)
+	defer unlock()
+
+	entry := zdb.shards.getEntry(cmd.Key)
+	if entry == nil {
+		return 0
+	}
+
+	// non positive timeouts delete the key right away
+	if cmd.Seconds <= 0 {
+		zdb.shards.RemoveDB(cmd.Key)
+		return 1
 	}
 
-	return keys, nextCursor, nil
+	entry.expireAt = time.Now().Add(time.Duration(cmd.Seconds) * time.Second).UnixMilli()
+	return 1
+}
+
+func (zdb *ZDB) TTL(cmd *commands.TTLCmd) int {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return -2
+	}
+
+	if entry.expireAt == 0 {
+		return -1
+	}
+
+	remaining := time.Until(time.UnixMilli(entry.expireAt))
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+func (zdb *ZDB) Persist(cmd *commands.PersistCmd) int {
+	unlock := zdb.shards.lock(cmd.Key)
+	defer unlock()
+
+	entry := zdb.shards.getEntry(cmd.Key)
+	if entry == nil || entry.expireAt == 0 {
+		return 0
+	}
+
+	entry.expireAt = 0
+	return 1
 }
 
 func (zdb *ZDB) ZAdd(cmd *commands.ZADDCmd) int {
+	unlock := zdb.shards.lock(cmd.Key)
+	defer unlock()
+
 	tree := zdb.shards.GetDBFromKey(cmd.Key)
 	if tree == nil {
-		tree = NewTree()
-		tree = zdb.shards.UpsertDB(cmd.Key, tree)
+		tree = zdb.shards.UpsertDB(cmd.Key, nil)
 	}
 
-	success := 0
+	nodes := make([]Node, 0, len(cmd.Members))
 	for _, z := range cmd.Members {
-		tree.Add(z.Key, z.Score)
-		success += 1
+		nodes = append(nodes, Node{key: z.Key, score: z.Score})
 	}
+	tree.AddBatch(nodes)
+	zdb.shards.TrimToCapacity(cmd.Key)
+	zdb.shards.UpdateMemory(cmd.Key)
 
-	return success
+	return len(cmd.Members)
 }
 
 func (zdb *ZDB) ZCard(cmd *commands.ZCardCmd) int {
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := zdb.shards.readTree(cmd.Key)
 	if tree == nil {
 		return 0
 	}
 
-	return tree.Root().Count()
+	return tree.Len()
 }
 
 func (zdb *ZDB) ZCount(cmd *commands.ZCountCmd) int {
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := zdb.shards.readTree(cmd.Key)
 	if tree == nil {
 		return 0
 	}
@@ -98,70 +190,61 @@ func (zdb *ZDB) ZCount(cmd *commands.ZCountCmd) int {
 }
 
 func (zdb *ZDB) ZDiff(cmd *commands.ZDiffCmd) OrderStatisticTree {
-	diff := zdb.shards.GetDBFromKey(cmd.Keys[0])
-	if diff == nil {
-		return nil
-	}
-
-	for i := 1; i < len(cmd.Keys); i++ {
-		other := zdb.shards.GetDBFromKey(cmd.Keys[i])
-		if other == nil {
-			return nil
-		}
+	unlock := zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
 
-		diff = diff.Diff(other)
-	}
+	return zdb.zdiff(cmd)
+}
 
-	return diff
+func (zdb *ZDB) zdiff(cmd *commands.ZDiffCmd) OrderStatisticTree {
+	return diffSets(zdb.readTrees(cmd.Keys), zdb.shards.newSortedSet())
 }
 
 func (zdb *ZDB) ZDiffStore(cmd *commands.ZDiffStoreCmd) int {
-	diff := zdb.ZDiff(&cmd.ZDiffCmd)
-	zdb.shards.UpsertDB(cmd.DstKey, diff)
-	if diff.IsEmpty() {
+	unlock := zdb.shards.lock(append([]string{cmd.DstKey}, cmd.ZDiffCmd.Keys...)...)
+	defer unlock()
+
+	return zdb.store(cmd.DstKey, zdb.zdiff(&cmd.ZDiffCmd))
+}
+
+// store replaces dst with the result of a set operation, an empty result deletes dst.
+// The result is trimmed to the cap of dst
+func (zdb *ZDB) store(dst string, result OrderStatisticTree) int {
+	if result == nil || result.IsEmpty() {
+		zdb.shards.RemoveDB(dst)
 		return 0
 	}
-	return diff.Root().count
+
+	zdb.shards.UpsertDB(dst, result)
+	if zdb.shards.TrimToCapacity(dst) > 0 {
+		zdb.shards.UpdateMemory(dst)
+	}
+	return result.Len()
 }
 
 func (zdb *ZDB) ZInter(cmd *commands.ZInterCmd) OrderStatisticTree {
-	inter := zdb.shards.GetDBFromKey(cmd.Keys[0])
-	if inter == nil {
-		return nil
-	}
-
-	for i := 1; i < len(cmd.Keys); i++ {
-		aggFunc := SumAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		switch cmd.Aggregate {
-		case "max":
-			aggFunc = MaxAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		case "min":
-			aggFunc = MinAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		}
+	unlock := zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
 
-		other := zdb.shards.GetDBFromKey(cmd.Keys[i])
-		if other == nil {
-			return nil
-		}
-
-		inter = inter.Inter(other, aggFunc)
-	}
+	return zdb.zinter(cmd)
+}
 
-	return inter
+func (zdb *ZDB) zinter(cmd *commands.ZInterCmd) OrderStatisticTree {
+	return interSets(zdb.readTrees(cmd.Keys), cmd.Weights, aggregateFunc(cmd.Aggregate), zdb.shards.newSortedSet())
 }
 
 func (zdb *ZDB) ZInterStore(cmd *commands.ZInterStoreCmd) int {
-	inter := zdb.ZInter(&cmd.ZInterCmd)
-	zdb.shards.UpsertDB(cmd.DstKey, inter)
+	unlock := zdb.shards.lock(append([]string{cmd.DstKey}, cmd.ZInterCmd.Keys...)...)
+	defer unlock()
 
-	if inter.IsEmpty() {
-		return 0
-	}
-	return inter.Root().count
+	return zdb.store(cmd.DstKey, zdb.zinter(&cmd.ZInterCmd))
 }
 
 func (zdb *ZDB) ZRank(cmd *commands.ZRankCmd) int {
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := zdb.shards.readTree(cmd.Key)
 	if tree == nil {
 		return -1
 	}
@@ -170,44 +253,93 @@ func (zdb *ZDB) ZRank(cmd *commands.ZRankCmd) int {
 }
 
 func (zdb *ZDB) ZRange(cmd *commands.ZRangeCmd) []Node {
-	// TODO: Pagination
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := zdb.shards.readTree(cmd.Key)
 	if tree == nil {
 		return []Node{}
 	}
 
 	if cmd.ByIndex {
-		if cmd.StopIndex == -1 {
-			cmd.StopIndex = tree.Root().Count()
+		stop := cmd.StopIndex
+		if stop == -1 {
+			stop = tree.Len()
 		}
 
 		if cmd.Reverse {
-			return tree.RangeByIndexReverse(cmd.StartIndex, cmd.StopIndex)
+			return tree.RangeByIndexReverse(cmd.StartIndex, stop)
 		}
 
-		return tree.RangeByIndex(cmd.StartIndex, cmd.StopIndex)
+		return tree.RangeByIndex(cmd.StartIndex, stop)
 	}
 
-	if cmd.ByScore {
-		if cmd.Reverse {
-			return tree.RangeByScoreReverse(cmd.MinScore, cmd.MaxScore)
-		}
+	var nodes []Node
+	switch {
+	case cmd.ByScore && cmd.Reverse:
+		nodes = tree.RangeByScoreReverse(cmd.MinScore, cmd.MaxScore)
+	case cmd.ByScore:
+		nodes = tree.RangeByScore(cmd.MinScore, cmd.MaxScore)
+	case cmd.ByLex:
+		nodes = rangeByLex(tree, cmd)
+	}
 
-		return tree.RangeByScore(cmd.MinScore, cmd.MaxScore)
+	if cmd.Limit {
+		return limitNodes(nodes, cmd.Offset, cmd.Count)
 	}
+	return nodes
+}
 
-	if cmd.ByLex {
-		if cmd.Reverse {
-			return tree.RangeByLexReverse(cmd.MinKey, cmd.MaxKey)
-		}
+// rangeByLex turns the exclusive and infinite BYLEX bounds of cmd into the inclusive
+// ones of the sorted sets
+func rangeByLex(tree OrderStatisticTree, cmd *commands.ZRangeCmd) []Node {
+	if cmd.MinKeyInf > 0 || cmd.MaxKeyInf < 0 || tree.IsEmpty() {
+		return []Node{}
+	}
 
-		return tree.RangeByLex(cmd.MinKey, cmd.MaxKey)
+	minKey, maxKey := cmd.MinKey, cmd.MaxKey
+	if cmd.MinKeyInf < 0 {
+		minKey = ""
+	} else if cmd.MinKeyExclusive {
+		// the lowest member after MinKey
+		minKey += "\x00"
 	}
+	if cmd.MaxKeyInf > 0 {
+		maxKey = tree.SelectReverse(1).key
+	}
+
+	var nodes []Node
+	if cmd.Reverse {
+		nodes = tree.RangeByLexReverse(minKey, maxKey)
+	} else {
+		nodes = tree.RangeByLex(minKey, maxKey)
+	}
+
+	if cmd.MaxKeyExclusive && cmd.MaxKeyInf == 0 {
+		nodes = slices.DeleteFunc(nodes, func(n Node) bool {
+			return n.key == cmd.MaxKey
+		})
+	}
+	return nodes
+}
 
-	return []Node{}
+// limitNodes keeps count nodes from offset, every one of them for a negative count
+func limitNodes(nodes []Node, offset, count int) []Node {
+	if offset < 0 || offset >= len(nodes) {
+		return []Node{}
+	}
+
+	nodes = nodes[offset:]
+	if count >= 0 && count < len(nodes) {
+		nodes = nodes[:count]
+	}
+	return nodes
 }
 
 func (zdb *ZDB) ZRem(cmd *commands.ZRemCmd) int {
+	unlock := zdb.shards.lock(cmd.Key)
+	defer unlock()
+
 	tree := zdb.shards.GetDBFromKey(cmd.Key)
 	if tree == nil {
 		return 0
@@ -219,57 +351,74 @@ func (zdb *ZDB) ZRem(cmd *commands.ZRemCmd) int {
 		success += 1
 	}
 
-	if tree.Root().Count() == 0 {
+	if tree.Len() == 0 {
 		zdb.shards.RemoveDB(cmd.Key)
+	} else {
+		zdb.shards.UpdateMemory(cmd.Key)
 	}
 
 	return success
 }
 
-func (zdb *ZDB) ZScan(cmd *commands.ZScanCmd) (keys []string, nextCursor string) {
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
-	if tree == nil {
-		return keys, "0"
-	}
+func (zdb *ZDB) ZScan(cmd *commands.ZScanCmd) (nodes []Node, nextCursor string) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
 
-	it := NewTreeIterator(tree)
-	var cursor *Node
-	if cmd.ScanCmd.Cursor != "0" {
-		score, err := tree.GetScore(cmd.ScanCmd.Cursor)
-		if err != nil {
+	tree := zdb.shards.readTree(cmd.Key)
+	nextCursor = scanTree(tree, cmd.ScanCmd.Cursor, cmd.ScanCmd.Count, func(n *Node) {
+		if cmd.ScanCmd.MatchPattern != "" && !matchGlob(cmd.ScanCmd.MatchPattern, n.key) {
 			return
 		}
+		nodes = append(nodes, *n)
+	})
 
-		cursor = NewNode(cmd.ScanCmd.Cursor, score)
-	}
-	it.Seek(cursor)
+	return nodes, nextCursor
+}
 
-	// TODO: Match glob pattern
-	count := 0
-	for {
-		if count == cmd.ScanCmd.Count {
-			break
-		}
+type ZSetCapInfo struct {
+	MaxLen   int
+	EvictMax bool
+	Evicted  int
+}
 
-		next := it.Next()
-		if next == nil {
-			break
-		}
-		keys = append(keys, next.key)
-		count += 1
+func (zdb *ZDB) ZSetCap(cmd *commands.ZSetCapCmd) (evicted int, err error) {
+	unlock := zdb.shards.lock(cmd.Key)
+	defer unlock()
+
+	entry := zdb.shards.getEntry(cmd.Key)
+	if entry == nil {
+		return 0, ErrNoSuchKey
 	}
 
-	if len(keys) > 1 {
-		nextCursor = keys[len(keys)-1]
-	} else {
-		nextCursor = "0"
+	entry.capacity = cmd.MaxLen
+	entry.evictMax = cmd.EvictMax
+	evicted = zdb.shards.TrimToCapacity(cmd.Key)
+	zdb.shards.UpdateMemory(cmd.Key)
+
+	return evicted, nil
+}
+
+func (zdb *ZDB) ZSetCapInfo(cmd *commands.ZSetCapCmd) (ZSetCapInfo, error) {
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	entry, err := zdb.peekLiveEntry(cmd.Key)
+	if err != nil {
+		return ZSetCapInfo{}, err
 	}
 
-	return keys, nextCursor
+	return ZSetCapInfo{
+		MaxLen:   entry.capacity,
+		EvictMax: entry.evictMax,
+		Evicted:  entry.evicted,
+	}, nil
 }
 
 func (zdb *ZDB) ZScore(cmd *commands.ZScoreCmd) (float64, error) {
-	tree := zdb.shards.GetDBFromKey(cmd.Key)
+	unlock := zdb.shards.rlock(cmd.Key)
+	defer unlock()
+
+	tree := zdb.shards.readTree(cmd.Key)
 	if tree == nil {
 		return 0, errNotFound
 	}
@@ -278,38 +427,36 @@ func (zdb *ZDB) ZScore(cmd *commands.ZScoreCmd) (float64, error) {
 }
 
 func (zdb *ZDB) ZUnion(cmd *commands.ZUnionCmd) OrderStatisticTree {
-	union := zdb.shards.GetDBFromKey(cmd.Keys[0])
-	if union == nil {
-		return nil
-	}
-
-	for i := 1; i < len(cmd.Keys); i++ {
-		aggFunc := SumAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		switch cmd.Aggregate {
-		case "max":
-			aggFunc = MaxAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		case "min":
-			aggFunc = MinAggFunc(cmd.Weights[i-1], cmd.Weights[i])
-		}
+	unlock := zdb.shards.rlock(cmd.Keys...)
+	defer unlock()
 
-		other := zdb.shards.GetDBFromKey(cmd.Keys[1])
-		if other == nil {
-			return nil
-		}
+	return zdb.zunion(cmd)
+}
 
-		union = union.Union(other, aggFunc)
+func (zdb *ZDB) zunion(cmd *commands.ZUnionCmd) OrderStatisticTree {
+	return unionSets(zdb.readTrees(cmd.Keys), cmd.Weights, aggregateFunc(cmd.Aggregate), zdb.shards.newSortedSet())
+}
+
+// readTrees is readTree for every key, missing keys are nil
+func (zdb *ZDB) readTrees(keys []string) []OrderStatisticTree {
+	return Map(keys, zdb.shards.readTree)
+}
+
+// aggregateFunc combines the weighted scores of a member, sum by default like Redis
+func aggregateFunc(aggregate string) AggFunc {
+	switch aggregate {
+	case "max":
+		return MaxAggFunc(1, 1)
+	case "min":
+		return MinAggFunc(1, 1)
 	}
 
-	return union
+	return SumAggFunc(1, 1)
 }
 
 func (zdb *ZDB) ZUnionStore(cmd *commands.ZUnionStoreCmd) int {
-	union := zdb.ZUnion(&cmd.ZUnionCmd)
-	zdb.shards.UpsertDB(cmd.DstKey, union)
-
-	if union.IsEmpty() {
-		return 0
-	}
+	unlock := zdb.shards.lock(append([]string{cmd.DstKey}, cmd.ZUnionCmd.Keys...)...)
+	defer unlock()
 
-	return union.Root().count
+	return zdb.store(cmd.DstKey, zdb.zunion(&cmd.ZUnionCmd))
 }
@@ -0,0 +1,48 @@
+//go:build unit
+
+package zdb
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/zdb/commands"
+)
+
+func TestZRange(t *testing.T) {
+	db := NewZDB(4)
+	db.ZAdd(&commands.ZADDCmd{Key: "lex", Members: []commands.ZMember{{Key: "a"}, {Key: "b"}, {Key: "c"}, {Key: "d"}}})
+	db.ZAdd(&commands.ZADDCmd{Key: "score", Members: []commands.ZMember{{Key: "A", Score: 1}, {Key: "B", Score: 2}, {Key: "C", Score: 3}, {Key: "D", Score: 4}}})
+
+	tests := []struct {
+		Name string
+		Args []string
+		Want []string
+	}{
+		{Name: "Lex inclusive and exclusive", Args: []string{"lex", "[b", "(d", "bylex"}, Want: []string{"b", "c"}},
+		{Name: "Lex infinite", Args: []string{"lex", "-", "+", "bylex"}, Want: []string{"a", "b", "c", "d"}},
+		{Name: "Lex reversed", Args: []string{"lex", "+", "(a", "bylex", "rev"}, Want: []string{"d", "c", "b"}},
+		{Name: "Lex reversed exclusive max", Args: []string{"lex", "(c", "-", "bylex", "rev"}, Want: []string{"b", "a"}},
+		{Name: "Lex empty infinite range", Args: []string{"lex", "+", "-", "bylex"}, Want: []string{}},
+		{Name: "Lex with limit", Args: []string{"lex", "-", "+", "bylex", "limit", "1", "2"}, Want: []string{"b", "c"}},
+		{Name: "Score reversed", Args: []string{"score", "(4", "2", "byscore", "rev"}, Want: []string{"C", "B"}},
+		{Name: "Score with negative count", Args: []string{"score", "-inf", "+inf", "byscore", "limit", "2", "-1"}, Want: []string{"C", "D"}},
+		{Name: "Score with offset past the range", Args: []string{"score", "-inf", "+inf", "byscore", "limit", "4", "1"}, Want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cmd := commands.ZRangeCmd{}
+			if err := cmd.Build(test.Args); err != nil {
+				t.Fatalf("got err %v", err)
+			}
+
+			got := Map(db.ZRange(&cmd), func(n Node) string {
+				return n.Key()
+			})
+			if !slices.Equal(got, test.Want) {
+				t.Errorf("got %v, want %v", got, test.Want)
+			}
+		})
+	}
+}